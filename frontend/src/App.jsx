@@ -98,9 +98,13 @@ export default function App() {
     form.append("file", chosen);
 
     try {
-      const res = await fetch(`${API_URL}/upload`, { method: "POST", body: form });
+      const res = await fetch(`${API_URL}/upload`, {
+        method: "POST",
+        body: form,
+        headers: { "Accept-Language": "th" },
+      });
       const js = await res.json().catch(() => ({}));
-      if (!res.ok) throw new Error(js?.error || "upload failed");
+      if (!res.ok) throw new Error(js?.message || "upload failed");
 
       // Show the immediate JSON we got back as a pretty string
       setRawJSON(JSON.stringify(js, null, 2));
@@ -130,9 +134,9 @@ export default function App() {
     async function tick() {
       if (pollStopRef.current) return;
       try {
-        const res = await fetch(`${API_URL}/status/${id}`);
+        const res = await fetch(`${API_URL}/status/${id}`, { headers: { "Accept-Language": "th" } });
         const js = await res.json().catch(() => ({}));
-        if (!res.ok) throw new Error(js?.error || "status error");
+        if (!res.ok) throw new Error(js?.message || "status error");
 
         setStatus(js.status || "processing");
 
@@ -0,0 +1,70 @@
+// Package apierr defines the JSON body of every error response and the
+// catalog of codes clients can match on instead of the message.
+package apierr
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error is the body of an error response. Code is stable; Message is for
+// people and may change. Details carries code-specific fields such as the
+// size limit that was exceeded.
+type Error struct {
+	Code      Code           `json:"code"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New returns an error with code's catalog message.
+func New(code Code, details map[string]any) *Error {
+	return &Error{Code: code, Message: code.Message(details), Details: details}
+}
+
+// From returns err if it is an *Error, or fallback with err's text as the
+// reason detail.
+func From(err error, fallback Code) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return New(fallback, map[string]any{"reason": err.Error()})
+}
+
+// Abort writes code with status and its catalog message, and stops the
+// handler chain.
+func Abort(c *gin.Context, status int, code Code, details map[string]any) {
+	Write(c, status, New(code, details))
+}
+
+// Write writes e with status, its message in the language the client
+// accepts, and stops the handler chain.
+func Write(c *gin.Context, status int, e *Error) {
+	body := Localize(c, e)
+	c.Header("Content-Language", Language(c).String())
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	c.AbortWithStatusJSON(status, body)
+}
+
+// Localize returns a copy of e with its message in the language the client
+// accepts and the request ID set, for errors sent other than as a response.
+func Localize(c *gin.Context, e *Error) *Error {
+	body := *e
+	body.Message = e.Code.MessageIn(Language(c), e.Details)
+	body.RequestID = logging.RequestID(c.Request.Context())
+	return &body
+}
+
+// NotFound answers requests for unknown routes.
+func NotFound(c *gin.Context) {
+	Abort(c, http.StatusNotFound, RouteNotFound, nil)
+}
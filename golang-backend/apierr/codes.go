@@ -0,0 +1,196 @@
+package apierr
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Code identifies an error independently of its message.
+type Code string
+
+const (
+	// Requests
+	InvalidBody    Code = "INVALID_BODY"
+	InvalidQuery   Code = "INVALID_QUERY"
+	InvalidFields  Code = "VALIDATION_FAILED"
+	RouteNotFound  Code = "ROUTE_NOT_FOUND"
+	InternalError  Code = "INTERNAL_ERROR"
+	ShuttingDown   Code = "SHUTTING_DOWN"
+	StorageOff     Code = "STORAGE_DISABLED"
+	ImageStoreOff  Code = "IMAGE_STORAGE_DISABLED"
+	ImageLoadError Code = "IMAGE_UNAVAILABLE"
+	NotWebSocket   Code = "UPGRADE_REQUIRED"
+
+	// Uploads
+	FileMissing          Code = "FILE_MISSING"
+	FileUnreadable       Code = "FILE_UNREADABLE"
+	FileTooLarge         Code = "FILE_TOO_LARGE"
+	MultipartInvalid     Code = "MULTIPART_INVALID"
+	BatchTooLarge        Code = "BATCH_TOO_LARGE"
+	TooManyFiles         Code = "TOO_MANY_FILES"
+	UnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	InvalidBase64        Code = "INVALID_BASE64"
+	URLForbidden         Code = "URL_FORBIDDEN"
+	DownloadFailed       Code = "DOWNLOAD_FAILED"
+	InvalidCallbackURL   Code = "INVALID_CALLBACK_URL"
+	TusVersion           Code = "TUS_VERSION_UNSUPPORTED"
+	UploadNotFound       Code = "UPLOAD_NOT_FOUND"
+	UploadOffset         Code = "UPLOAD_OFFSET_MISMATCH"
+	UploadLocked         Code = "UPLOAD_LOCKED"
+
+	// Consent
+	ConsentRequired     Code = "CONSENT_REQUIRED"
+	ConsentInvalidJSON  Code = "CONSENT_INVALID_JSON"
+	ConsentFieldMissing Code = "CONSENT_FIELD_MISSING"
+	ConsentVersion      Code = "CONSENT_VERSION_NOT_ACCEPTED"
+	ConsentFutureTime   Code = "CONSENT_TIMESTAMP_IN_FUTURE"
+
+	// Scanning
+	OCRUnavailable    Code = "OCR_UNAVAILABLE"
+	OCRBusy           Code = "OCR_BUSY"
+	ProcessingTimeout Code = "PROCESSING_TIMEOUT"
+	ScanFailed        Code = "SCAN_FAILED"
+	ImageQualityLow   Code = "IMAGE_QUALITY_TOO_LOW"
+	ImageTooLarge     Code = "IMAGE_DIMENSIONS_TOO_LARGE"
+	MRZUnreadable     Code = "MRZ_UNREADABLE"
+	CardExpired       Code = "CARD_EXPIRED"
+	PDFPageNotFound   Code = "PDF_PAGE_NOT_FOUND"
+	QueueFull         Code = "QUEUE_FULL"
+	ScanNotFound      Code = "SCAN_NOT_FOUND"
+	ReviewNotPending  Code = "REVIEW_NOT_PENDING"
+	InvalidCitizenID  Code = "INVALID_CITIZEN_ID"
+	InvalidLaserCode  Code = "INVALID_LASER_CODE"
+	InvalidBirthDate  Code = "INVALID_BIRTH_DATE"
+	VerifyDisabled    Code = "VERIFICATION_DISABLED"
+	VerifyUnavailable Code = "VERIFICATION_UNAVAILABLE"
+	FaceDisabled      Code = "FACE_MATCH_DISABLED"
+	FaceUnavailable   Code = "FACE_MATCH_UNAVAILABLE"
+	LivenessFailed    Code = "LIVENESS_UNAVAILABLE"
+	LiveIncomplete    Code = "LIVE_SCAN_INCOMPLETE"
+
+	// Access
+	APIKeyMissing    Code = "API_KEY_MISSING"
+	APIKeyInvalid    Code = "API_KEY_INVALID"
+	PermissionDenied Code = "PERMISSION_DENIED"
+	TenantDisabled   Code = "TENANT_DISABLED"
+	RateLimited      Code = "RATE_LIMITED"
+	QuotaExceeded    Code = "QUOTA_EXCEEDED"
+
+	// Idempotency
+	IdempotencyKeyTooLong  Code = "IDEMPOTENCY_KEY_TOO_LONG"
+	IdempotencyInProgress  Code = "IDEMPOTENCY_IN_PROGRESS"
+	IdempotencyKeyMismatch Code = "IDEMPOTENCY_KEY_MISMATCH"
+
+	// Administration
+	UnknownRole    Code = "UNKNOWN_ROLE"
+	UnknownTenant  Code = "UNKNOWN_TENANT"
+	AdminKeyTenant Code = "ADMIN_KEY_WITH_TENANT"
+	InvalidGrace   Code = "INVALID_GRACE"
+	KeyNotFound    Code = "KEY_NOT_FOUND"
+	KeyRevoked     Code = "KEY_REVOKED"
+)
+
+var messages = map[Code]string{
+	InvalidBody:    "request body is invalid",
+	InvalidQuery:   "{parameter} must be {expected}",
+	InvalidFields:  "request failed validation",
+	RouteNotFound:  "route not found",
+	InternalError:  "internal server error",
+	ShuttingDown:   "server is shutting down",
+	StorageOff:     "scan storage is disabled",
+	ImageStoreOff:  "image storage is disabled",
+	ImageLoadError: "failed to load image",
+	NotWebSocket:   "this endpoint requires a websocket connection",
+
+	FileMissing:          "no file uploaded",
+	FileUnreadable:       "failed to read file",
+	FileTooLarge:         "file too large",
+	MultipartInvalid:     "failed to parse multipart form",
+	BatchTooLarge:        "batch too large",
+	TooManyFiles:         "too many files",
+	UnsupportedMediaType: "unsupported media type",
+	InvalidBase64:        "image is not valid base64",
+	URLForbidden:         "image url is not allowed",
+	DownloadFailed:       "failed to download image",
+	InvalidCallbackURL:   "callback url is not allowed",
+	TusVersion:           "only tus version {supported} is supported",
+	UploadNotFound:       "upload not found or expired",
+	UploadOffset:         "Upload-Offset must be {offset}",
+	UploadLocked:         "the upload is receiving another request",
+
+	ConsentRequired:     "consent is required",
+	ConsentInvalidJSON:  "consent is not valid json",
+	ConsentFieldMissing: "consent {field} is required",
+	ConsentVersion:      "consent version is not accepted",
+	ConsentFutureTime:   "consent timestamp is in the future",
+
+	OCRUnavailable:    "ocr service unavailable",
+	OCRBusy:           "ocr service is busy, try again later",
+	ProcessingTimeout: "scan did not finish within {timeout}",
+	ScanFailed:        "failed to scan image",
+	ImageQualityLow:   "image quality too low",
+	ImageTooLarge:     "image is over {max_pixels} pixels",
+	MRZUnreadable:     "could not read passport mrz",
+	CardExpired:       "card has expired",
+	PDFPageNotFound:   "pdf page not found",
+	QueueFull:         "scan queue is full",
+	ScanNotFound:      "scan not found",
+	ReviewNotPending:  "scan is {status}, not awaiting review",
+	InvalidCitizenID:  "id_number fails checksum",
+	InvalidLaserCode:  "laser_code is invalid",
+	InvalidBirthDate:  "birth_date is invalid",
+	VerifyDisabled:    "verification is not configured",
+	VerifyUnavailable: "verification service unavailable",
+	FaceDisabled:      "face match is not configured",
+	FaceUnavailable:   "face match service unavailable",
+	LivenessFailed:    "liveness service unavailable",
+	LiveIncomplete:    "no frame was read confidently in {attempts} attempts",
+
+	APIKeyMissing:    "missing api key",
+	APIKeyInvalid:    "invalid api key",
+	PermissionDenied: "forbidden",
+	TenantDisabled:   "tenant is disabled",
+	RateLimited:      "rate limit exceeded",
+	QuotaExceeded:    "{period} scan quota exceeded",
+
+	IdempotencyKeyTooLong:  "idempotency key is too long",
+	IdempotencyInProgress:  "a request with this idempotency key is in progress",
+	IdempotencyKeyMismatch: "idempotency key was used with a different request",
+
+	UnknownRole:    "unknown role {role}",
+	UnknownTenant:  "unknown tenant",
+	AdminKeyTenant: "admin keys cannot belong to a tenant",
+	InvalidGrace:   "grace must be a duration such as 24h",
+	KeyNotFound:    "api key not found",
+	KeyRevoked:     "api key is revoked",
+}
+
+// Message returns the English catalog message for c with {name}
+// placeholders filled from details.
+func (c Code) Message(details map[string]any) string {
+	return c.MessageIn(language.English, details)
+}
+
+// MessageIn is Message in lang, falling back to English for codes that
+// have not been translated.
+func (c Code) MessageIn(lang language.Tag, details map[string]any) string {
+	m, ok := catalogs[lang][c]
+	if !ok {
+		m, ok = messages[c]
+	}
+	if !ok {
+		return string(c)
+	}
+	for k, v := range details {
+		m = strings.ReplaceAll(m, "{"+k+"}", fmt.Sprint(v))
+	}
+	return m
+}
+
+// Codes returns a copy of the catalog, for documentation.
+func Codes() map[Code]string {
+	return maps.Clone(messages)
+}
@@ -0,0 +1,26 @@
+package apierr
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+var (
+	languages = []language.Tag{language.English, language.Thai}
+	matcher   = language.NewMatcher(languages)
+	catalogs  = map[language.Tag]map[Code]string{
+		language.English: messages,
+		language.Thai:    messagesTH,
+	}
+)
+
+// Language picks the error message language from Accept-Language, falling
+// back to English.
+func Language(c *gin.Context) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return language.English
+	}
+	_, i, _ := matcher.Match(tags...)
+	return languages[i]
+}
@@ -0,0 +1,76 @@
+package apierr
+
+var messagesTH = map[Code]string{
+	InvalidBody:    "ข้อมูลที่ส่งมาไม่ถูกต้อง",
+	InvalidQuery:   "พารามิเตอร์ {parameter} ไม่ถูกต้อง ต้องเป็น {expected}",
+	InvalidFields:  "ข้อมูลบางช่องไม่ผ่านการตรวจสอบ",
+	RouteNotFound:  "ไม่พบเส้นทางที่เรียก",
+	InternalError:  "เกิดข้อผิดพลาดภายในระบบ",
+	ShuttingDown:   "ระบบกำลังปิดให้บริการชั่วคราว",
+	StorageOff:     "ไม่ได้เปิดใช้การจัดเก็บผลการสแกน",
+	ImageStoreOff:  "ไม่ได้เปิดใช้การจัดเก็บรูปภาพ",
+	ImageLoadError: "โหลดรูปภาพไม่สำเร็จ",
+	NotWebSocket:   "ต้องเชื่อมต่อผ่าน WebSocket",
+
+	FileMissing:          "ไม่พบไฟล์ที่อัปโหลด",
+	FileUnreadable:       "อ่านไฟล์ไม่สำเร็จ",
+	FileTooLarge:         "ไฟล์มีขนาดใหญ่เกินไป",
+	MultipartInvalid:     "รูปแบบข้อมูลที่อัปโหลดไม่ถูกต้อง",
+	BatchTooLarge:        "ไฟล์ทั้งหมดมีขนาดรวมใหญ่เกินไป",
+	TooManyFiles:         "จำนวนไฟล์มากเกินไป",
+	UnsupportedMediaType: "ไม่รองรับประเภทไฟล์นี้",
+	InvalidBase64:        "รูปภาพไม่ได้เข้ารหัส base64 อย่างถูกต้อง",
+	URLForbidden:         "ไม่อนุญาตให้ดาวน์โหลดรูปภาพจาก URL นี้",
+	DownloadFailed:       "ดาวน์โหลดรูปภาพไม่สำเร็จ",
+	InvalidCallbackURL:   "ไม่อนุญาตให้ใช้ callback URL นี้",
+	TusVersion:           "รองรับเฉพาะ tus เวอร์ชัน {supported}",
+	UploadNotFound:       "ไม่พบการอัปโหลดหรือหมดอายุแล้ว",
+	UploadOffset:         "Upload-Offset ต้องเป็น {offset}",
+	UploadLocked:         "การอัปโหลดนี้กำลังรับข้อมูลจากคำขออื่น",
+
+	ConsentRequired:     "ต้องระบุความยินยอม",
+	ConsentInvalidJSON:  "ข้อมูลความยินยอมไม่ใช่ JSON ที่ถูกต้อง",
+	ConsentFieldMissing: "ต้องระบุ {field} ของความยินยอม",
+	ConsentVersion:      "ไม่รับความยินยอมฉบับนี้",
+	ConsentFutureTime:   "เวลาที่ให้ความยินยอมอยู่ในอนาคต",
+
+	OCRUnavailable:    "บริการอ่านข้อความจากภาพไม่พร้อมใช้งาน",
+	OCRBusy:           "บริการอ่านข้อความจากภาพมีงานมากเกินไป กรุณาลองใหม่ภายหลัง",
+	ProcessingTimeout: "สแกนไม่เสร็จภายใน {timeout}",
+	ScanFailed:        "สแกนรูปภาพไม่สำเร็จ",
+	ImageQualityLow:   "รูปภาพมีคุณภาพต่ำเกินไป",
+	ImageTooLarge:     "รูปภาพมีจำนวนพิกเซลเกิน {max_pixels}",
+	MRZUnreadable:     "อ่านแถบ MRZ ของหนังสือเดินทางไม่ได้",
+	CardExpired:       "บัตรหมดอายุแล้ว",
+	PDFPageNotFound:   "ไม่พบหน้าที่ระบุในไฟล์ PDF",
+	QueueFull:         "คิวการสแกนเต็ม กรุณาลองใหม่ภายหลัง",
+	ScanNotFound:      "ไม่พบผลการสแกน",
+	ReviewNotPending:  "ผลการสแกนไม่ได้รอการตรวจสอบ (สถานะ {status})",
+	InvalidCitizenID:  "เลขประจำตัวประชาชนไม่ถูกต้อง",
+	InvalidLaserCode:  "รหัสหลังบัตร (laser code) ไม่ถูกต้อง",
+	InvalidBirthDate:  "วันเกิดไม่ถูกต้อง",
+	VerifyDisabled:    "ไม่ได้เปิดใช้การตรวจสอบกับกรมการปกครอง",
+	VerifyUnavailable: "บริการตรวจสอบกับกรมการปกครองไม่พร้อมใช้งาน",
+	FaceDisabled:      "ไม่ได้เปิดใช้การเปรียบเทียบใบหน้า",
+	FaceUnavailable:   "บริการเปรียบเทียบใบหน้าไม่พร้อมใช้งาน",
+	LivenessFailed:    "บริการตรวจสอบความมีชีวิตของภาพใบหน้าไม่พร้อมใช้งาน",
+	LiveIncomplete:    "อ่านบัตรจากกล้องไม่ชัดเจนภายใน {attempts} ครั้ง",
+
+	APIKeyMissing:    "ไม่พบ API key",
+	APIKeyInvalid:    "API key ไม่ถูกต้อง",
+	PermissionDenied: "ไม่มีสิทธิ์เข้าถึง",
+	TenantDisabled:   "บัญชีผู้ใช้บริการถูกระงับ",
+	RateLimited:      "เรียกใช้บ่อยเกินไป กรุณาลองใหม่ภายหลัง",
+	QuotaExceeded:    "ใช้โควตาการสแกน ({period}) ครบแล้ว",
+
+	IdempotencyKeyTooLong:  "idempotency key ยาวเกินไป",
+	IdempotencyInProgress:  "คำขอที่ใช้ idempotency key นี้กำลังดำเนินการอยู่",
+	IdempotencyKeyMismatch: "idempotency key นี้ถูกใช้กับคำขออื่นแล้ว",
+
+	UnknownRole:    "ไม่รู้จักบทบาท {role}",
+	UnknownTenant:  "ไม่พบผู้ใช้บริการนี้",
+	AdminKeyTenant: "API key ของผู้ดูแลระบบต้องไม่ผูกกับผู้ใช้บริการ",
+	InvalidGrace:   "ระยะผ่อนผันต้องเป็นช่วงเวลา เช่น 24h",
+	KeyNotFound:    "ไม่พบ API key",
+	KeyRevoked:     "API key ถูกยกเลิกแล้ว",
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"golang-backend/config"
+)
+
+// Cache stores opaque values by key. Misses and backend errors are both
+// reported as misses so a cache outage never fails a scan.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte)
+	// Delete removes key, reporting backend errors so erasure can be
+	// retried.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the backend selected by cfg, or nil when caching is disabled.
+func New(cfg config.CacheConfig) (Cache, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	switch cfg.Backend {
+	case "", "memory":
+		return NewLRU(cfg.Size, cfg.TTL), nil
+	case "redis":
+		return NewRedis(cfg.RedisURL, cfg.RedisPrefix, cfg.TTL)
+	}
+	return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// LRU is an in-process cache evicting the least recently used entry once
+// size entries are held.
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List
+	items map[string]*list.Element
+}
+
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{size: size, ttl: ttl, order: list.New(), items: map[string]*list.Element{}}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if c.ttl > 0 && time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value = &entry{key: key, value: value, expires: time.Now().Add(c.ttl)}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: time.Now().Add(c.ttl)})
+	for c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+}
+
+func (c *LRU) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// Redis shares cached values between replicas.
+type Redis struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func NewRedis(url, prefix string, ttl time.Duration) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("redis url: %w", err)
+	}
+	return &Redis{client: redis.NewClient(opts), prefix: prefix, ttl: ttl}, nil
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool) {
+	b, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	return b, err == nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte) {
+	c.client.Set(ctx, c.prefix+key, value, c.ttl)
+}
+
+func (c *Redis) Delete(ctx context.Context, key string) error {
+	return c.client.Del(ctx, c.prefix+key).Err()
+}
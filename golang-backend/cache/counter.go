@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Counter keeps integer counts that expire ttl after they were first
+// incremented, such as quota usage for a period.
+type Counter interface {
+	Get(ctx context.Context, key string) (int64, error)
+	Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+}
+
+// NewCounter returns the memory or redis Counter.
+func NewCounter(backend, redisURL, prefix string) (Counter, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemoryCounter(), nil
+	case "redis":
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("redis url: %w", err)
+		}
+		return &RedisCounter{client: redis.NewClient(opts), prefix: prefix}, nil
+	}
+	return nil, fmt.Errorf("unknown counter backend %q", backend)
+}
+
+type count struct {
+	n       int64
+	expires time.Time
+}
+
+// MemoryCounter is an in-process Counter. Expired counts are dropped when a
+// new key is added.
+type MemoryCounter struct {
+	mu     sync.Mutex
+	counts map[string]count
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{counts: map[string]count{}}
+}
+
+func (m *MemoryCounter) Get(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.counts[key]
+	if !ok || time.Now().After(c.expires) {
+		return 0, nil
+	}
+	return c.n, nil
+}
+
+func (m *MemoryCounter) Incr(_ context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	c, ok := m.counts[key]
+	if !ok || now.After(c.expires) {
+		for k, old := range m.counts {
+			if now.After(old.expires) {
+				delete(m.counts, k)
+			}
+		}
+		c = count{expires: now.Add(ttl)}
+	}
+	c.n += n
+	m.counts[key] = c
+	return c.n, nil
+}
+
+// RedisCounter shares counts between replicas.
+type RedisCounter struct {
+	client *redis.Client
+	prefix string
+}
+
+func (r *RedisCounter) Get(ctx context.Context, key string) (int64, error) {
+	n, err := r.client.Get(ctx, r.prefix+key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (r *RedisCounter) Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	incr := pipe.IncrBy(ctx, r.prefix+key, n)
+	pipe.ExpireNX(ctx, r.prefix+key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
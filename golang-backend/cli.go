@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+	"golang-backend/service"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := rootCommand().ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// rootCommand runs the server when no subcommand is given, so existing
+// deployments keep working unchanged.
+func rootCommand() *cobra.Command {
+	var (
+		configFile string
+		mockOCR    bool
+	)
+	root := &cobra.Command{
+		Use:          "backend",
+		Short:        "Thai ID card scanning service",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			if configFile != "" {
+				if err := os.Setenv("CONFIG_FILE", configFile); err != nil {
+					return err
+				}
+			}
+			if mockOCR {
+				return os.Setenv("OCR_PROVIDER", "mock")
+			}
+			return nil
+		},
+		Run: func(*cobra.Command, []string) { serve() },
+	}
+	root.PersistentFlags().StringVar(&configFile, "config", "", "YAML config file (default $CONFIG_FILE or ./config.yaml)")
+	root.PersistentFlags().BoolVar(&mockOCR, "mock-ocr", false, "answer scans with made-up data instead of calling OCR (sets OCR_PROVIDER=mock)")
+	root.AddCommand(&cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP and gRPC servers (the default)",
+		Args:  cobra.NoArgs,
+		Run:   func(*cobra.Command, []string) { serve() },
+	}, scanCommand(), scanDirCommand())
+	return root
+}
+
+// documentScanner scans one kind of document; result is the struct type
+// its results point to.
+type documentScanner struct {
+	scan   func(context.Context, io.Reader) (any, error)
+	result reflect.Type
+}
+
+var documentScanners = map[service.Document]documentScanner{
+	service.DocumentFront:             scanAs(service.Scan),
+	service.DocumentBack:              scanAs(service.ScanBack),
+	service.DocumentPassport:          scanAs(service.ScanPassport),
+	service.DocumentDriverLicense:     scanAs(service.ScanDriverLicense),
+	service.DocumentHouseRegistration: scanAs(service.ScanHouseRegistration),
+}
+
+func scanAs[T any](scan func(context.Context, io.Reader) (*T, error)) documentScanner {
+	return documentScanner{
+		scan: func(ctx context.Context, r io.Reader) (any, error) {
+			return scan(ctx, r)
+		},
+		result: reflect.TypeFor[T](),
+	}
+}
+
+type scanOptions struct {
+	document string
+	page     int
+	minAge   int
+	timeout  time.Duration
+}
+
+func (o *scanOptions) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.document, "document", string(service.DocumentFront), "front, back, passport, driver_license or house_registration")
+	cmd.Flags().IntVar(&o.page, "page", 0, "page of a PDF to scan (default the first)")
+	cmd.Flags().IntVar(&o.minAge, "min-age", -1, "report whether the ID card holder is at least this old (default no check)")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 2*time.Minute, "give up on a file after this long")
+}
+
+// scanner returns the scan function for the chosen document and the type
+// of its results.
+func (o *scanOptions) scanner() (func(context.Context, io.Reader) (any, error), reflect.Type, error) {
+	s, ok := documentScanners[service.Document(o.document)]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown document %q", o.document)
+	}
+	return func(ctx context.Context, r io.Reader) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+		if o.page > 0 {
+			ctx = service.WithPDFPage(ctx, o.page)
+		}
+		if o.minAge >= 0 {
+			ctx = service.WithMinAge(ctx, o.minAge)
+		}
+		return s.scan(ctx, r)
+	}, s.result, nil
+}
+
+func scanCommand() *cobra.Command {
+	var (
+		opts   scanOptions
+		asJSON bool
+	)
+	cmd := &cobra.Command{
+		Use:   "scan FILE",
+		Short: "Scan one image with the configured OCR provider and print the result",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scan, _, err := opts.scanner()
+			if err != nil {
+				return err
+			}
+			if err := setupScanning(); err != nil {
+				return err
+			}
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			result, err := scan(cmd.Context(), f)
+			if err != nil {
+				return fmt.Errorf("scan %s: %w", args[0], err)
+			}
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(result)
+			}
+			return printFields(cmd.OutOrStdout(), result)
+		},
+	}
+	opts.register(cmd)
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the result as JSON")
+	return cmd
+}
+
+// setupScanning loads the configuration and OCR provider for commands that
+// scan without serving. Logs go to stderr, leaving stdout for results.
+func setupScanning() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	logging.SetupTo(cfg.Log, os.Stderr)
+	service.Configure(cfg)
+	provider, err := service.NewProvider(cfg.OCR)
+	if err != nil {
+		return fmt.Errorf("ocr provider: %w", err)
+	}
+	service.SetProvider(provider)
+	if err := service.LoadAddressDataset(cfg.Address.Dataset); err != nil {
+		return fmt.Errorf("address dataset: %w", err)
+	}
+	return nil
+}
+
+// printFields writes the non-empty top-level fields of result, one per
+// line, with nested values as compact JSON.
+func printFields(w io.Writer, result any) error {
+	v := reflect.Indirect(reflect.ValueOf(result))
+	names := fieldNames(v.Type())
+	values, err := fieldValues(v)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, name := range names {
+		if values[i] != "" && values[i] != "false" {
+			fmt.Fprintf(tw, "%s:\t%s\n", name, values[i])
+		}
+	}
+	return tw.Flush()
+}
+
+// fieldNames lists the JSON names of t's top-level fields.
+func fieldNames(t reflect.Type) []string {
+	var names []string
+	for _, f := range reflect.VisibleFields(t) {
+		if name, ok := jsonName(f); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fieldValues formats the fields named by fieldNames: scalars as text,
+// anything else as compact JSON, and zero values as "".
+func fieldValues(v reflect.Value) ([]string, error) {
+	var values []string
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		if _, ok := jsonName(f); !ok {
+			continue
+		}
+		field := v.FieldByIndex(f.Index)
+		switch {
+		case field.IsZero() && field.Kind() != reflect.Bool:
+			values = append(values, "")
+		case field.Kind() == reflect.String, field.Kind() == reflect.Bool, field.CanInt(), field.CanFloat():
+			values = append(values, fmt.Sprint(field.Interface()))
+		default:
+			b, err := json.Marshal(field.Interface())
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, string(b))
+		}
+	}
+	return values, nil
+}
+
+func jsonName(f reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if !f.IsExported() || f.Anonymous || name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
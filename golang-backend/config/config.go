@@ -0,0 +1,954 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultFile = "config.yaml"
+
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
+	OCR         OCRConfig         `yaml:"ocr"`
+	HTTPClient  HTTPClientConfig  `yaml:"http_client"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Upload      UploadConfig      `yaml:"upload"`
+	Log         LogConfig         `yaml:"log"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Jobs        JobsConfig        `yaml:"jobs"`
+	Webhook     WebhookConfig     `yaml:"webhook"`
+	Fetch       FetchConfig       `yaml:"fetch"`
+	PDF         PDFConfig         `yaml:"pdf"`
+	HEIC        HEICConfig        `yaml:"heic"`
+	Image       ImageConfig       `yaml:"image"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Address     AddressConfig     `yaml:"address"`
+	Card        CardConfig        `yaml:"card"`
+	DOPA        DOPAConfig        `yaml:"dopa"`
+	Face        FaceConfig        `yaml:"face"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Consent     ConsentConfig     `yaml:"consent"`
+	Quota       QuotaConfig       `yaml:"quota"`
+	Docs        DocsConfig        `yaml:"docs"`
+	API         APIConfig         `yaml:"api"`
+	LiveScan    LiveScanConfig    `yaml:"live_scan"`
+	Resumable   ResumableConfig   `yaml:"resumable"`
+	Events      EventsConfig      `yaml:"events"`
+	Report      ReportConfig      `yaml:"report"`
+	Export      ExportConfig      `yaml:"export"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+}
+
+// ConsentConfig governs the PDPA consent sent with uploads. Versions, when
+// set, lists the consent text versions still accepted.
+type ConsentConfig struct {
+	Require  bool     `yaml:"require_consent" env:"REQUIRE_CONSENT"`
+	Versions []string `yaml:"versions" env:"CONSENT_VERSIONS"`
+}
+
+type ServerConfig struct {
+	Addr         string        `yaml:"addr" env:"SERVER_ADDR"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	// On SIGTERM /readyz fails for DrainDelay before the listener closes,
+	// then in-flight requests and queued jobs get ShutdownTimeout to finish.
+	DrainDelay      time.Duration `yaml:"drain_delay" env:"SERVER_DRAIN_DELAY"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	TLS             TLSConfig     `yaml:"tls"`
+	// TrustedProxies lists the addresses or CIDRs whose X-Forwarded-For is
+	// believed for the client IP used by rate limits and audit logs. Empty
+	// trusts none and uses the connection's address.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+}
+
+// TLSConfig serves HTTPS from CertFile and KeyFile, or from certificates
+// obtained from Let's Encrypt for AutocertDomains. HTTPAddr, when set with
+// autocert, serves ACME challenges and redirects to HTTPS.
+type TLSConfig struct {
+	CertFile         string   `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile          string   `yaml:"key_file" env:"TLS_KEY_FILE"`
+	AutocertDomains  []string `yaml:"autocert_domains" env:"TLS_AUTOCERT_DOMAINS"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir" env:"TLS_AUTOCERT_CACHE_DIR"`
+	AutocertEmail    string   `yaml:"autocert_email" env:"TLS_AUTOCERT_EMAIL"`
+	HTTPAddr         string   `yaml:"http_addr" env:"TLS_HTTP_ADDR"`
+}
+
+// GRPCConfig serves the scan API over gRPC on Addr; empty disables it. It
+// uses the server certificate files when they are configured.
+type GRPCConfig struct {
+	Addr string `yaml:"addr" env:"GRPC_ADDR"`
+}
+
+type OCRConfig struct {
+	// Provider is python (default), google, textract, azure, tesseract or
+	// mock.
+	Provider string `yaml:"provider" env:"OCR_PROVIDER"`
+	// Fallback names a provider, usually tesseract, used while Provider
+	// is unreachable.
+	Fallback             string `yaml:"fallback" env:"OCR_FALLBACK"`
+	URL                  string `yaml:"url" env:"OCR_URL"`
+	BackURL              string `yaml:"back_url" env:"OCR_BACK_URL"`
+	PassportURL          string `yaml:"passport_url" env:"OCR_PASSPORT_URL"`
+	DriverLicenseURL     string `yaml:"driver_license_url" env:"OCR_DRIVER_LICENSE_URL"`
+	HouseRegistrationURL string `yaml:"house_registration_url" env:"OCR_HOUSE_REGISTRATION_URL"`
+	// Instances are base URLs of Python OCR replicas; the paths of the URLs
+	// above are requested on each. Balance is round_robin or least_pending.
+	Instances      []string           `yaml:"instances" env:"OCR_INSTANCES"`
+	Balance        string             `yaml:"balance" env:"OCR_BALANCE"`
+	HealthInterval time.Duration      `yaml:"health_interval" env:"OCR_HEALTH_INTERVAL"`
+	Timeout        time.Duration      `yaml:"timeout" env:"OCR_TIMEOUT"`
+	HealthTimeout  time.Duration      `yaml:"health_timeout" env:"OCR_HEALTH_TIMEOUT"`
+	Retry          RetryConfig        `yaml:"retry"`
+	Breaker        BreakerConfig      `yaml:"breaker"`
+	Concurrency    ConcurrencyConfig  `yaml:"concurrency"`
+	TLS            OCRTLSConfig       `yaml:"tls"`
+	Google         GoogleVisionConfig `yaml:"google"`
+	Textract       TextractConfig     `yaml:"textract"`
+	Azure          AzureConfig        `yaml:"azure"`
+	Tesseract      TesseractConfig    `yaml:"tesseract"`
+	Mock           MockOCRConfig      `yaml:"mock"`
+	// MaxDeadline caps the processing timeout a client may ask for with
+	// X-Processing-Timeout or ?timeout=.
+	MaxDeadline time.Duration `yaml:"max_deadline" env:"OCR_MAX_DEADLINE"`
+}
+
+type GoogleVisionConfig struct {
+	APIKey   string `yaml:"api_key" env:"GOOGLE_VISION_API_KEY"`
+	Endpoint string `yaml:"endpoint" env:"GOOGLE_VISION_ENDPOINT"`
+}
+
+// TextractConfig uses static credentials; Endpoint defaults to the regional
+// AWS endpoint.
+type TextractConfig struct {
+	Region          string `yaml:"region" env:"AWS_REGION"`
+	AccessKeyID     string `yaml:"access_key_id" env:"AWS_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"AWS_SECRET_ACCESS_KEY"`
+	SessionToken    string `yaml:"session_token" env:"AWS_SESSION_TOKEN"`
+	Endpoint        string `yaml:"endpoint" env:"TEXTRACT_ENDPOINT"`
+}
+
+type AzureConfig struct {
+	Endpoint     string        `yaml:"endpoint" env:"AZURE_FORM_RECOGNIZER_ENDPOINT"`
+	Key          string        `yaml:"key" env:"AZURE_FORM_RECOGNIZER_KEY"`
+	Model        string        `yaml:"model" env:"AZURE_FORM_RECOGNIZER_MODEL"`
+	APIVersion   string        `yaml:"api_version" env:"AZURE_FORM_RECOGNIZER_API_VERSION"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"AZURE_FORM_RECOGNIZER_POLL_INTERVAL"`
+}
+
+// TesseractConfig applies to builds with the tesseract tag, which need
+// libtesseract and its language data installed.
+type TesseractConfig struct {
+	Languages string `yaml:"languages" env:"TESSERACT_LANGUAGES"`
+	DataPath  string `yaml:"data_path" env:"TESSDATA_PREFIX"`
+}
+
+// MockOCRConfig configures the mock provider, which makes up well-formed
+// document data without calling any engine. Latency delays each call.
+type MockOCRConfig struct {
+	// PerImage derives the data from a hash of the image, so each image
+	// gets its own stable result rather than all sharing one.
+	PerImage bool          `yaml:"per_image" env:"OCR_MOCK_PER_IMAGE"`
+	Latency  time.Duration `yaml:"latency" env:"OCR_MOCK_LATENCY"`
+}
+
+// BreakerConfig opens the OCR circuit after Failures consecutive
+// unavailable errors for Cooldown.
+type BreakerConfig struct {
+	Enabled  bool          `yaml:"enabled" env:"OCR_BREAKER_ENABLED"`
+	Failures int           `yaml:"failures" env:"OCR_BREAKER_FAILURES"`
+	Cooldown time.Duration `yaml:"cooldown" env:"OCR_BREAKER_COOLDOWN"`
+}
+
+// ConcurrencyConfig caps simultaneous calls to the OCR provider at
+// MaxInFlight, zero meaning no cap. Calls over it wait up to QueueTimeout
+// for a slot before failing as busy; once MaxQueued are waiting, further
+// calls are rejected at once. Zero MaxQueued lets any number wait.
+type ConcurrencyConfig struct {
+	MaxInFlight  int           `yaml:"max_in_flight" env:"OCR_MAX_IN_FLIGHT"`
+	QueueTimeout time.Duration `yaml:"queue_timeout" env:"OCR_QUEUE_TIMEOUT"`
+	MaxQueued    int           `yaml:"max_queued" env:"OCR_MAX_QUEUED"`
+}
+
+// HTTPClientConfig tunes the connection pool shared by calls to the OCR
+// and verification backends. Zero MaxConnsPerHost means unlimited.
+type HTTPClientConfig struct {
+	MaxIdleConns          int           `yaml:"max_idle_conns" env:"HTTP_CLIENT_MAX_IDLE_CONNS"`
+	MaxIdleConnsPerHost   int           `yaml:"max_idle_conns_per_host" env:"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST"`
+	MaxConnsPerHost       int           `yaml:"max_conns_per_host" env:"HTTP_CLIENT_MAX_CONNS_PER_HOST"`
+	IdleConnTimeout       time.Duration `yaml:"idle_conn_timeout" env:"HTTP_CLIENT_IDLE_CONN_TIMEOUT"`
+	DialTimeout           time.Duration `yaml:"dial_timeout" env:"HTTP_CLIENT_DIAL_TIMEOUT"`
+	KeepAlive             time.Duration `yaml:"keep_alive" env:"HTTP_CLIENT_KEEP_ALIVE"`
+	TLSHandshakeTimeout   time.Duration `yaml:"tls_handshake_timeout" env:"HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT"`
+	ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout" env:"HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT"`
+}
+
+// OCRTLSConfig secures the connection to the Python OCR service. CAFile
+// replaces the system roots trusted for its certificate; CertFile and
+// KeyFile present a client certificate for mutual TLS. RequireHTTPS
+// refuses http:// OCR URLs.
+type OCRTLSConfig struct {
+	CAFile       string `yaml:"ca_file" env:"OCR_TLS_CA_FILE"`
+	CertFile     string `yaml:"cert_file" env:"OCR_TLS_CERT_FILE"`
+	KeyFile      string `yaml:"key_file" env:"OCR_TLS_KEY_FILE"`
+	ServerName   string `yaml:"server_name" env:"OCR_TLS_SERVER_NAME"`
+	RequireHTTPS bool   `yaml:"require_https" env:"OCR_TLS_REQUIRE_HTTPS"`
+}
+
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts" env:"OCR_RETRY_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"OCR_RETRY_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"OCR_RETRY_MAX_BACKOFF"`
+	Jitter         float64       `yaml:"jitter" env:"OCR_RETRY_JITTER"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API. Origins may
+// use a "*" wildcard, e.g. "https://*.example.com".
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+}
+
+type UploadConfig struct {
+	MaxBytes         int64 `yaml:"max_bytes" env:"UPLOAD_MAX_BYTES"`
+	MaxBatchFiles    int   `yaml:"max_batch_files" env:"UPLOAD_MAX_BATCH_FILES"`
+	BatchConcurrency int   `yaml:"batch_concurrency" env:"UPLOAD_BATCH_CONCURRENCY"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// AuthConfig.APIKeys act with APIKeyRoles and AdminKeys with the admin
+// role. Roles are scanner, reviewer and admin.
+type AuthConfig struct {
+	Enabled     bool           `yaml:"enabled" env:"AUTH_ENABLED"`
+	APIKeys     []string       `yaml:"api_keys" env:"AUTH_API_KEYS"`
+	APIKeyRoles []string       `yaml:"api_key_roles" env:"AUTH_API_KEY_ROLES"`
+	AdminKeys   []string       `yaml:"admin_keys" env:"AUTH_ADMIN_KEYS"`
+	Tenants     []TenantConfig `yaml:"tenants"`
+	JWT         JWTConfig      `yaml:"jwt"`
+}
+
+// TenantConfig groups the API keys of one business unit. Keys act with
+// Roles, or APIKeyRoles when empty. A zero quota is unlimited.
+type TenantConfig struct {
+	ID           string   `yaml:"id"`
+	Keys         []string `yaml:"keys"`
+	Roles        []string `yaml:"roles"`
+	DailyQuota   int64    `yaml:"daily_quota"`
+	MonthlyQuota int64    `yaml:"monthly_quota"`
+	// WebhookSecret signs the tenant's webhook deliveries in place of
+	// webhook.secret.
+	WebhookSecret string `yaml:"webhook_secret"`
+}
+
+// QuotaConfig selects where tenant scan counts are kept. Memory counts are
+// lost on restart; use redis when running more than one replica.
+type QuotaConfig struct {
+	Backend  string `yaml:"backend" env:"QUOTA_BACKEND"`
+	RedisURL string `yaml:"redis_url" env:"QUOTA_REDIS_URL"`
+}
+
+// APIConfig keeps the unversioned routes as deprecated aliases of /v1.
+// Deprecation and Sunset are YYYY-MM-DD dates for the headers of the same
+// name; an empty Deprecation sends "Deprecation: true".
+type APIConfig struct {
+	LegacyRoutes bool   `yaml:"legacy_routes" env:"API_LEGACY_ROUTES"`
+	Deprecation  string `yaml:"deprecation" env:"API_DEPRECATION"`
+	Sunset       string `yaml:"sunset" env:"API_SUNSET"`
+}
+
+// LiveScanConfig limits GET /ws/scan sessions. At most one frame per
+// FrameInterval is checked, and a session ends after MaxAttempts OCR calls
+// or MaxDuration.
+type LiveScanConfig struct {
+	FrameInterval time.Duration `yaml:"frame_interval" env:"LIVE_SCAN_FRAME_INTERVAL"`
+	MaxFrameBytes int64         `yaml:"max_frame_bytes" env:"LIVE_SCAN_MAX_FRAME_BYTES"`
+	MaxAttempts   int           `yaml:"max_attempts" env:"LIVE_SCAN_MAX_ATTEMPTS"`
+	MaxDuration   time.Duration `yaml:"max_duration" env:"LIVE_SCAN_MAX_DURATION"`
+}
+
+// DocsConfig serves the OpenAPI document at /openapi.json and Swagger UI at
+// /docs. Both are public.
+type DocsConfig struct {
+	Enabled bool `yaml:"enabled" env:"DOCS_ENABLED"`
+}
+
+// JWTConfig accepts bearer tokens signed by a key from JWKSURL; an empty
+// JWKSURL disables JWT. Roles are read from the RolesClaim claim and the
+// tenant from TenantClaim.
+type JWTConfig struct {
+	JWKSURL         string        `yaml:"jwks_url" env:"JWT_JWKS_URL"`
+	Issuer          string        `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience        string        `yaml:"audience" env:"JWT_AUDIENCE"`
+	RolesClaim      string        `yaml:"roles_claim" env:"JWT_ROLES_CLAIM"`
+	TenantClaim     string        `yaml:"tenant_claim" env:"JWT_TENANT_CLAIM"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"JWT_REFRESH_INTERVAL"`
+}
+
+type RateLimitConfig struct {
+	Enabled   bool    `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	PerSecond float64 `yaml:"per_second" env:"RATE_LIMIT_PER_SECOND"`
+	Burst     int     `yaml:"burst" env:"RATE_LIMIT_BURST"`
+	// PerIPSecond and PerIPBurst limit every API request by client IP
+	// before its key is checked, so guessing keys is slowed down as well.
+	// Zero PerIPSecond turns the per-IP limit off.
+	PerIPSecond float64 `yaml:"per_ip_second" env:"RATE_LIMIT_PER_IP_SECOND"`
+	PerIPBurst  int     `yaml:"per_ip_burst" env:"RATE_LIMIT_PER_IP_BURST"`
+}
+
+type JobsConfig struct {
+	Workers   int           `yaml:"workers" env:"JOBS_WORKERS"`
+	QueueSize int           `yaml:"queue_size" env:"JOBS_QUEUE_SIZE"`
+	ResultTTL time.Duration `yaml:"result_ttl" env:"JOBS_RESULT_TTL"`
+	// Backend is memory or redis. Redis keeps queued jobs across restarts
+	// and lets replicas sharing RedisPrefix work on the same queue.
+	Backend     string `yaml:"backend" env:"JOBS_BACKEND"`
+	RedisURL    string `yaml:"redis_url" env:"JOBS_REDIS_URL"`
+	RedisPrefix string `yaml:"redis_prefix" env:"JOBS_REDIS_PREFIX"`
+}
+
+// EventsConfig publishes scan.completed and scan.failed events to the
+// NATS or Kafka Broker; an empty Broker disables them. Topic is the Kafka
+// topic, or the NATS subject prefix. Events wait in a buffer of BufferSize
+// and are dropped when it is full.
+type EventsConfig struct {
+	Broker        string        `yaml:"broker" env:"EVENTS_BROKER"`
+	Topic         string        `yaml:"topic" env:"EVENTS_TOPIC"`
+	NATSURL       string        `yaml:"nats_url" env:"EVENTS_NATS_URL"`
+	KafkaBrokers  []string      `yaml:"kafka_brokers" env:"EVENTS_KAFKA_BROKERS"`
+	IncludeResult bool          `yaml:"include_result" env:"EVENTS_INCLUDE_RESULT"`
+	BufferSize    int           `yaml:"buffer_size" env:"EVENTS_BUFFER_SIZE"`
+	Timeout       time.Duration `yaml:"timeout" env:"EVENTS_TIMEOUT"`
+}
+
+// ReportConfig styles GET /scans/{id}/report.pdf. Font is a TrueType file
+// with Thai glyphs, such as Sarabun or Noto Sans Thai; the built-in font
+// only covers Latin text.
+type ReportConfig struct {
+	Font  string `yaml:"font" env:"REPORT_FONT"`
+	Title string `yaml:"title" env:"REPORT_TITLE"`
+}
+
+// ExportConfig shapes GET /scans/export. Columns are scan fields such as
+// created_at or status, or top-level result fields such as id_number; those
+// in Mask are written with all but their last four characters starred.
+type ExportConfig struct {
+	Columns []string `yaml:"columns" env:"EXPORT_COLUMNS"`
+	Mask    []string `yaml:"mask" env:"EXPORT_MASK"`
+}
+
+// TracingConfig exports OpenTelemetry spans for requests and OCR calls to
+// an OTLP collector at Endpoint over Protocol, grpc or http. SampleRatio is
+// the share of new traces kept; requests arriving with a sampled trace are
+// always kept.
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"TRACING_ENABLED"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	Protocol    string  `yaml:"protocol" env:"TRACING_PROTOCOL"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// ResumableConfig stores tus uploads under Dir until they complete or
+// Expiry passes. An empty Dir uses the system temporary directory.
+type ResumableConfig struct {
+	Dir    string        `yaml:"dir" env:"RESUMABLE_DIR"`
+	Expiry time.Duration `yaml:"expiry" env:"RESUMABLE_EXPIRY"`
+}
+
+type WebhookConfig struct {
+	Secret      string        `yaml:"secret" env:"WEBHOOK_SECRET"`
+	Timeout     time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT"`
+	MaxAttempts int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	Backoff     time.Duration `yaml:"backoff" env:"WEBHOOK_BACKOFF"`
+	// KeyURLs maps an API key ID to the callback used when a request from
+	// that key does not supply its own callback_url.
+	KeyURLs map[string]string `yaml:"key_urls"`
+	// AllowPrivate lets deliveries reach private, loopback and link-local
+	// addresses, which callback_url could otherwise use to probe the
+	// internal network.
+	AllowPrivate bool `yaml:"allow_private" env:"WEBHOOK_ALLOW_PRIVATE"`
+}
+
+// FetchConfig controls server-side downloads for scan-by-URL.
+type FetchConfig struct {
+	Timeout      time.Duration `yaml:"timeout" env:"FETCH_TIMEOUT"`
+	AllowedHosts []string      `yaml:"allowed_hosts" env:"FETCH_ALLOWED_HOSTS"`
+	AllowHTTP    bool          `yaml:"allow_http" env:"FETCH_ALLOW_HTTP"`
+	AllowPrivate bool          `yaml:"allow_private" env:"FETCH_ALLOW_PRIVATE"`
+}
+
+type PDFConfig struct {
+	Enabled  bool          `yaml:"enabled" env:"PDF_ENABLED"`
+	Renderer string        `yaml:"renderer" env:"PDF_RENDERER"`
+	DPI      int           `yaml:"dpi" env:"PDF_DPI"`
+	Timeout  time.Duration `yaml:"timeout" env:"PDF_TIMEOUT"`
+	// Info counts the pages so a page past the end is rejected before
+	// rendering; empty skips the count.
+	Info string `yaml:"info" env:"PDF_INFO"`
+}
+
+type HEICConfig struct {
+	Enabled   bool          `yaml:"enabled" env:"HEIC_ENABLED"`
+	Converter string        `yaml:"converter" env:"HEIC_CONVERTER"`
+	Quality   int           `yaml:"quality" env:"HEIC_QUALITY"`
+	Timeout   time.Duration `yaml:"timeout" env:"HEIC_TIMEOUT"`
+}
+
+// ImageConfig controls the Go-side image processing applied before OCR.
+type ImageConfig struct {
+	AutoOrient    bool                `yaml:"auto_orient" env:"IMAGE_AUTO_ORIENT"`
+	JPEGQuality   int                 `yaml:"jpeg_quality" env:"IMAGE_JPEG_QUALITY"`
+	Preprocess    PreprocessConfig    `yaml:"preprocess"`
+	CardDetection CardDetectionConfig `yaml:"card_detection"`
+	Quality       QualityConfig       `yaml:"quality"`
+	Glare         GlareConfig         `yaml:"glare"`
+	Recapture     RecaptureConfig     `yaml:"recapture"`
+	// Photo includes the holder portrait as base64 JPEG in scan results.
+	Photo bool `yaml:"photo" env:"IMAGE_PHOTO"`
+	// Images still over ReencodeAbove bytes after preprocessing are sent to
+	// OCR re-encoded as JPEG at JPEGQuality, whatever their format.
+	ReencodeAbove int64 `yaml:"reencode_above" env:"IMAGE_REENCODE_ABOVE"`
+	// Images with more than MaxPixels pixels are rejected from their header,
+	// before they are decoded. Zero allows any size.
+	MaxPixels int64 `yaml:"max_pixels" env:"IMAGE_MAX_PIXELS"`
+}
+
+type CardDetectionConfig struct {
+	Enabled      bool    `yaml:"enabled" env:"CARD_DETECTION_ENABLED"`
+	OutputWidth  int     `yaml:"output_width" env:"CARD_DETECTION_OUTPUT_WIDTH"`
+	MinAreaRatio float64 `yaml:"min_area_ratio" env:"CARD_DETECTION_MIN_AREA_RATIO"`
+}
+
+// QualityConfig rejects images before OCR. Sharpness is the variance of the
+// Laplacian and brightness the mean luma (0-255), both measured at 1000px.
+type QualityConfig struct {
+	Enabled       bool    `yaml:"enabled" env:"QUALITY_ENABLED"`
+	MinShortEdge  int     `yaml:"min_short_edge" env:"QUALITY_MIN_SHORT_EDGE"`
+	MinBrightness float64 `yaml:"min_brightness" env:"QUALITY_MIN_BRIGHTNESS"`
+	MinSharpness  float64 `yaml:"min_sharpness" env:"QUALITY_MIN_SHARPNESS"`
+}
+
+// GlareConfig ratios are the share of specular pixels on the card; a zero
+// RejectRatio only warns.
+type GlareConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"GLARE_ENABLED"`
+	WarnRatio   float64 `yaml:"warn_ratio" env:"GLARE_WARN_RATIO"`
+	RejectRatio float64 `yaml:"reject_ratio" env:"GLARE_REJECT_RATIO"`
+}
+
+// RecaptureConfig tunes the check for photos of screens and photocopies
+// submitted as ID card fronts. MoireThreshold is the gradient
+// autocorrelation, from 0 to 1, at which a frame counts as a screen;
+// frames with a mean saturation below MinSaturation count as photocopies.
+// Reject refuses them instead of only reporting capture_type.
+type RecaptureConfig struct {
+	Enabled        bool    `yaml:"enabled" env:"RECAPTURE_ENABLED"`
+	MoireThreshold float64 `yaml:"moire_threshold" env:"RECAPTURE_MOIRE_THRESHOLD"`
+	MinSaturation  float64 `yaml:"min_saturation" env:"RECAPTURE_MIN_SATURATION"`
+	Reject         bool    `yaml:"reject" env:"RECAPTURE_REJECT"`
+}
+
+// CacheConfig caches OCR results by image hash, in process or in Redis.
+type CacheConfig struct {
+	Enabled     bool          `yaml:"enabled" env:"CACHE_ENABLED"`
+	Backend     string        `yaml:"backend" env:"CACHE_BACKEND"`
+	Size        int           `yaml:"size" env:"CACHE_SIZE"`
+	TTL         time.Duration `yaml:"ttl" env:"CACHE_TTL"`
+	RedisURL    string        `yaml:"redis_url" env:"CACHE_REDIS_URL"`
+	RedisPrefix string        `yaml:"redis_prefix" env:"CACHE_REDIS_PREFIX"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are kept.
+// Use the redis backend when running more than one replica.
+type IdempotencyConfig struct {
+	Enabled  bool          `yaml:"enabled" env:"IDEMPOTENCY_ENABLED"`
+	Backend  string        `yaml:"backend" env:"IDEMPOTENCY_BACKEND"`
+	Size     int           `yaml:"size" env:"IDEMPOTENCY_SIZE"`
+	TTL      time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
+	RedisURL string        `yaml:"redis_url" env:"IDEMPOTENCY_REDIS_URL"`
+}
+
+// StorageConfig selects where scan results are persisted. An empty Driver
+// disables persistence.
+type StorageConfig struct {
+	Driver       string `yaml:"driver" env:"STORAGE_DRIVER"`
+	DSN          string `yaml:"dsn" env:"STORAGE_DSN"`
+	MaxOpenConns int    `yaml:"max_open_conns" env:"STORAGE_MAX_OPEN_CONNS"`
+	// IDHashKey keys the citizen ID hash used for lookups and must be at
+	// least 32 bytes; changing it orphans the hashes already stored.
+	IDHashKey string `yaml:"id_hash_key" env:"STORAGE_ID_HASH_KEY"`
+	// Scans and their images older than Retention are deleted every
+	// PurgeInterval; zero Retention keeps them forever.
+	Retention     time.Duration    `yaml:"retention" env:"STORAGE_RETENTION"`
+	PurgeInterval time.Duration    `yaml:"purge_interval" env:"STORAGE_PURGE_INTERVAL"`
+	Images        ImageStoreConfig `yaml:"images"`
+	Encryption    EncryptionConfig `yaml:"encryption"`
+}
+
+// EncryptionConfig turns on AES-256-GCM encryption of stored scans, images
+// and other copies of results kept outside the database. Keys are "id:base64key" entries; the first one
+// encrypts new data and the rest only decrypt, so rotating means prepending
+// a new key. With KMS.Region set the base64 values are KMS ciphertext blobs
+// decrypted at startup.
+type EncryptionConfig struct {
+	Keys []string  `yaml:"keys" env:"STORAGE_ENCRYPTION_KEYS"`
+	KMS  KMSConfig `yaml:"kms"`
+}
+
+type KMSConfig struct {
+	Region          string `yaml:"region" env:"KMS_REGION"`
+	Endpoint        string `yaml:"endpoint" env:"KMS_ENDPOINT"`
+	AccessKeyID     string `yaml:"access_key_id" env:"KMS_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"KMS_SECRET_ACCESS_KEY"`
+	SessionToken    string `yaml:"session_token" env:"KMS_SESSION_TOKEN"`
+}
+
+// ImageStoreConfig keeps source images in an S3-compatible bucket; an empty
+// Bucket disables it. Set PathStyle for MinIO. SSE is AES256 or aws:kms,
+// and ExpireDays > 0 installs a bucket lifecycle rule expiring Prefix.
+type ImageStoreConfig struct {
+	Endpoint        string        `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Region          string        `yaml:"region" env:"S3_REGION"`
+	Bucket          string        `yaml:"bucket" env:"S3_BUCKET"`
+	Prefix          string        `yaml:"prefix" env:"S3_PREFIX"`
+	AccessKeyID     string        `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string        `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY"`
+	SessionToken    string        `yaml:"session_token" env:"S3_SESSION_TOKEN"`
+	PathStyle       bool          `yaml:"path_style" env:"S3_PATH_STYLE"`
+	SSE             string        `yaml:"sse" env:"S3_SSE"`
+	KMSKeyID        string        `yaml:"kms_key_id" env:"S3_KMS_KEY_ID"`
+	ExpireDays      int           `yaml:"expire_days" env:"S3_EXPIRE_DAYS"`
+	Cropped         bool          `yaml:"cropped" env:"S3_STORE_CROPPED"`
+	Timeout         time.Duration `yaml:"timeout" env:"S3_TIMEOUT"`
+}
+
+// CardConfig holds checks applied to the fields read off an ID card.
+type CardConfig struct {
+	RejectExpired bool `yaml:"reject_expired" env:"CARD_REJECT_EXPIRED"`
+	// Fields scoring below MinConfidence, or their FieldMinConfidence
+	// override, mark the scan as needs_review.
+	MinConfidence      float64            `yaml:"min_confidence" env:"CARD_MIN_CONFIDENCE"`
+	FieldMinConfidence map[string]float64 `yaml:"field_min_confidence"`
+	// Scans whose mean confidence over the fields with a threshold is below
+	// MinOverallConfidence need review as well.
+	MinOverallConfidence float64 `yaml:"min_overall_confidence" env:"CARD_MIN_OVERALL_CONFIDENCE"`
+}
+
+// DOPAConfig points at the DOPA CheckCardByLaser SOAP service. Enabled
+// verifies every combined scan; POST /verify only needs URL.
+type DOPAConfig struct {
+	Enabled bool          `yaml:"enabled" env:"DOPA_ENABLED"`
+	URL     string        `yaml:"url" env:"DOPA_URL"`
+	Timeout time.Duration `yaml:"timeout" env:"DOPA_TIMEOUT"`
+}
+
+// FaceConfig points at the face comparison service behind POST
+// /verify/face. It is posted the multipart files "card" and "selfie" and
+// answers {"similarity": 0.93}, from 0 to 1; faces at or above Threshold
+// match. APIKey, when set, is sent as a bearer token.
+type FaceConfig struct {
+	URL       string        `yaml:"url" env:"FACE_URL"`
+	APIKey    string        `yaml:"api_key" env:"FACE_API_KEY"`
+	Threshold float64       `yaml:"threshold" env:"FACE_THRESHOLD"`
+	Timeout   time.Duration `yaml:"timeout" env:"FACE_TIMEOUT"`
+	// Liveness scores the selfie for signs it is a photo of a photo or a
+	// screen.
+	Liveness LivenessConfig `yaml:"liveness"`
+}
+
+// LivenessConfig picks the passive liveness provider: "" for none or
+// "http", which is posted the multipart file "selfie" and answers
+// {"score": 0.97}, from 0 to 1; selfies at or above Threshold are live.
+type LivenessConfig struct {
+	Provider  string        `yaml:"provider" env:"LIVENESS_PROVIDER"`
+	URL       string        `yaml:"url" env:"LIVENESS_URL"`
+	APIKey    string        `yaml:"api_key" env:"LIVENESS_API_KEY"`
+	Threshold float64       `yaml:"threshold" env:"LIVENESS_THRESHOLD"`
+	Timeout   time.Duration `yaml:"timeout" env:"LIVENESS_TIMEOUT"`
+}
+
+// AddressConfig.Dataset is an optional province,district,subdistrict,postcode
+// CSV merged into the embedded address dataset.
+type AddressConfig struct {
+	Dataset string `yaml:"dataset" env:"ADDRESS_DATASET"`
+}
+
+// PreprocessConfig toggles each preprocessing step; MaxLongEdge 0 disables
+// downscaling.
+type PreprocessConfig struct {
+	MaxLongEdge int  `yaml:"max_long_edge" env:"PREPROCESS_MAX_LONG_EDGE"`
+	Grayscale   bool `yaml:"grayscale" env:"PREPROCESS_GRAYSCALE"`
+	Contrast    bool `yaml:"contrast" env:"PREPROCESS_CONTRAST"`
+	Deskew      bool `yaml:"deskew" env:"PREPROCESS_DESKEW"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			TLS: TLSConfig{
+				AutocertCacheDir: "autocert",
+				HTTPAddr:         ":80",
+			},
+		},
+		OCR: OCRConfig{
+			Provider:             "python",
+			URL:                  "http://127.0.0.1:5000/ocr/thai-id/",
+			BackURL:              "http://127.0.0.1:5000/ocr/thai-id/back/",
+			PassportURL:          "http://127.0.0.1:5000/ocr/passport/",
+			DriverLicenseURL:     "http://127.0.0.1:5000/ocr/driver-license/",
+			HouseRegistrationURL: "http://127.0.0.1:5000/ocr/house-registration/",
+			Timeout:              30 * time.Second,
+			HealthTimeout:        2 * time.Second,
+			Balance:              "round_robin",
+			HealthInterval:       10 * time.Second,
+			MaxDeadline:          60 * time.Second,
+			Retry: RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: 200 * time.Millisecond,
+				MaxBackoff:     2 * time.Second,
+				Jitter:         0.2,
+			},
+			Breaker: BreakerConfig{
+				Enabled:  true,
+				Failures: 5,
+				Cooldown: 30 * time.Second,
+			},
+			Concurrency: ConcurrencyConfig{
+				MaxInFlight:  4,
+				QueueTimeout: 10 * time.Second,
+				MaxQueued:    32,
+			},
+			Google: GoogleVisionConfig{
+				Endpoint: "https://vision.googleapis.com/v1/images:annotate",
+			},
+			Tesseract: TesseractConfig{
+				Languages: "tha+eng",
+			},
+			Azure: AzureConfig{
+				Model:        "prebuilt-read",
+				APIVersion:   "2023-07-31",
+				PollInterval: time.Second,
+			},
+		},
+		HTTPClient: HTTPClientConfig{
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+			DialTimeout:           5 * time.Second,
+			KeepAlive:             30 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:5173"},
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID", "Idempotency-Key",
+				"Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", "X-Processing-Timeout"},
+			ExposedHeaders: []string{"X-Request-ID", "X-Scan-ID", "Idempotent-Replayed", "Retry-After",
+				"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+				"X-Quota-Daily-Limit", "X-Quota-Daily-Remaining", "X-Quota-Daily-Reset",
+				"X-Quota-Monthly-Limit", "X-Quota-Monthly-Remaining", "X-Quota-Monthly-Reset",
+				"Location", "Tus-Resumable", "Tus-Version", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires"},
+			MaxAge: 10 * time.Minute,
+		},
+		Upload: UploadConfig{
+			MaxBytes:         10 << 20,
+			MaxBatchFiles:    20,
+			BatchConcurrency: 4,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Auth: AuthConfig{
+			APIKeyRoles: []string{"scanner"},
+			JWT: JWTConfig{
+				RolesClaim:      "roles",
+				TenantClaim:     "tenant",
+				RefreshInterval: time.Hour,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:     true,
+			PerSecond:   1,
+			Burst:       5,
+			PerIPSecond: 10,
+			PerIPBurst:  20,
+		},
+		Jobs: JobsConfig{
+			Workers:     2,
+			QueueSize:   100,
+			ResultTTL:   time.Hour,
+			Backend:     "memory",
+			RedisURL:    "redis://127.0.0.1:6379/0",
+			RedisPrefix: "jobs:",
+		},
+		Webhook: WebhookConfig{
+			Timeout:     10 * time.Second,
+			MaxAttempts: 5,
+			Backoff:     5 * time.Second,
+		},
+		Fetch: FetchConfig{
+			Timeout: 15 * time.Second,
+		},
+		PDF: PDFConfig{
+			Enabled:  true,
+			Renderer: "pdftoppm",
+			DPI:      200,
+			Timeout:  20 * time.Second,
+			Info:     "pdfinfo",
+		},
+		HEIC: HEICConfig{
+			Converter: "heif-convert",
+			Quality:   90,
+			Timeout:   20 * time.Second,
+		},
+		Image: ImageConfig{
+			AutoOrient:  true,
+			JPEGQuality: 92,
+			MaxPixels:   50_000_000,
+			CardDetection: CardDetectionConfig{
+				OutputWidth:  1012,
+				MinAreaRatio: 0.2,
+			},
+			Quality: QualityConfig{
+				Enabled:       true,
+				MinShortEdge:  400,
+				MinBrightness: 50,
+				MinSharpness:  60,
+			},
+			Glare: GlareConfig{
+				Enabled:   true,
+				WarnRatio: 0.02,
+			},
+			Recapture: RecaptureConfig{
+				Enabled:        true,
+				MoireThreshold: 0.3,
+				MinSaturation:  0.04,
+			},
+		},
+		Cache: CacheConfig{
+			Enabled:     true,
+			Backend:     "memory",
+			Size:        1000,
+			TTL:         time.Hour,
+			RedisURL:    "redis://127.0.0.1:6379/0",
+			RedisPrefix: "ocr:",
+		},
+		Quota: QuotaConfig{
+			Backend:  "memory",
+			RedisURL: "redis://127.0.0.1:6379/0",
+		},
+		Docs: DocsConfig{Enabled: true},
+		API:  APIConfig{LegacyRoutes: true},
+		LiveScan: LiveScanConfig{
+			FrameInterval: 500 * time.Millisecond,
+			MaxFrameBytes: 2 << 20,
+			MaxAttempts:   10,
+			MaxDuration:   2 * time.Minute,
+		},
+		Resumable: ResumableConfig{Expiry: 24 * time.Hour},
+		Report:    ReportConfig{Title: "Identity verification report"},
+		Export: ExportConfig{
+			Columns: []string{"id", "created_at", "tenant", "key_id", "route", "document", "status", "error",
+				"id_number", "name_th", "name_en", "birth_date", "expiry_date", "overall_confidence", "review_decision", "reviewer"},
+			Mask: []string{"id_number", "name_th", "name_en", "birth_date"},
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "localhost:4317",
+			Protocol:    "grpc",
+			ServiceName: "thai-id-backend",
+			SampleRatio: 1,
+		},
+		Events: EventsConfig{
+			Topic:         "thai-id.scans",
+			NATSURL:       "nats://127.0.0.1:4222",
+			KafkaBrokers:  []string{"127.0.0.1:9092"},
+			IncludeResult: true,
+			BufferSize:    1000,
+			Timeout:       5 * time.Second,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:  true,
+			Backend:  "memory",
+			Size:     10000,
+			TTL:      24 * time.Hour,
+			RedisURL: "redis://127.0.0.1:6379/0",
+		},
+		DOPA: DOPAConfig{
+			Timeout: 10 * time.Second,
+		},
+		Face: FaceConfig{
+			Threshold: 0.8,
+			Timeout:   10 * time.Second,
+			Liveness: LivenessConfig{
+				Threshold: 0.5,
+				Timeout:   10 * time.Second,
+			},
+		},
+		Storage: StorageConfig{
+			MaxOpenConns:  10,
+			PurgeInterval: time.Hour,
+			Images: ImageStoreConfig{
+				Region:  "us-east-1",
+				Prefix:  "scans/",
+				SSE:     "AES256",
+				Cropped: true,
+				Timeout: 30 * time.Second,
+			},
+		},
+		Card: CardConfig{
+			MinConfidence:        0.6,
+			FieldMinConfidence:   map[string]float64{"id_number": 0.9, "issue_date": 0},
+			MinOverallConfidence: 0.7,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, then the YAML file named by
+// CONFIG_FILE (or ./config.yaml when present), then environment variables.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = defaultFile
+	}
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = yaml.Unmarshal(b, cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	case explicit || !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	if err = applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	if err = cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate rejects settings the server would misbehave with rather than
+// refuse, such as batches that can hold no file or a rate limit that lets
+// nothing through.
+func (c *Config) validate() error {
+	var errs []error
+	if c.Upload.MaxBatchFiles < 1 {
+		errs = append(errs, errors.New("upload.max_batch_files must be at least 1"))
+	}
+	if c.RateLimit.Enabled {
+		if c.RateLimit.PerSecond <= 0 || c.RateLimit.Burst <= 0 {
+			errs = append(errs, errors.New("rate_limit.per_second and rate_limit.burst must be positive"))
+		}
+		if c.RateLimit.PerIPSecond < 0 || c.RateLimit.PerIPSecond > 0 && c.RateLimit.PerIPBurst <= 0 {
+			errs = append(errs, errors.New("rate_limit.per_ip_second must not be negative, and rate_limit.per_ip_burst must be positive with it"))
+		}
+	}
+	if c.Storage.Retention < 0 {
+		errs = append(errs, errors.New("storage.retention must not be negative"))
+	}
+	if c.Storage.Retention > 0 && c.Storage.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("storage.purge_interval must be positive when storage.retention is set"))
+	}
+	return errors.Join(errs...)
+}
+
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() == reflect.Struct {
+			if err := applyEnv(f); err != nil {
+				return err
+			}
+			continue
+		}
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(f, s); err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(f reflect.Value, s string) error {
+	switch f.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	case []string:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.Set(reflect.ValueOf(list))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadValidates(t *testing.T) {
+	tests := []struct {
+		name, yaml string
+		env        map[string]string
+		err        string
+	}{
+		{"defaults", ``, nil, ""},
+		{"example", "", nil, ""},
+		{"no batch files", "upload:\n  max_batch_files: 0\n", nil, "upload.max_batch_files"},
+		{"zero rate", "rate_limit:\n  per_second: 0\n", nil, "rate_limit.per_second"},
+		{"negative burst", "rate_limit:\n  burst: -1\n", nil, "rate_limit.burst"},
+		{"rate limit off", "rate_limit:\n  enabled: false\n  per_second: 0\n  burst: 0\n", nil, ""},
+		{"per-IP limit off", "rate_limit:\n  per_ip_second: 0\n  per_ip_burst: 0\n", nil, ""},
+		{"per-IP limit without burst", "rate_limit:\n  per_ip_burst: 0\n", nil, "rate_limit.per_ip_burst"},
+		{"negative retention", "storage:\n  retention: -1h\n", nil, "storage.retention"},
+		{"retention without interval", "storage:\n  retention: 720h\n  purge_interval: 0s\n", nil, "storage.purge_interval"},
+		{"from env", ``, map[string]string{"RATE_LIMIT_BURST": "0"}, "rate_limit.burst"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if tt.name == "example" {
+				path = "../config.example.yaml"
+			} else if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("CONFIG_FILE", path)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load()
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("Load = %v, want error mentioning %q", err, tt.err)
+			}
+		})
+	}
+}
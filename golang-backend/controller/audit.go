@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAuditHandler pages through the audit log, newest first, filtered by
+// from, to, actor, action and scan_id.
+func ListAuditHandler(c *gin.Context) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return
+	}
+	f := storage.AuditFilter{Actor: c.Query("actor"), Action: c.Query("action"), ScanID: c.Query("scan_id")}
+	var err error
+	if f.Limit, f.Offset, err = page(c); err == nil {
+		if f.From, err = queryTime(c, "from"); err == nil {
+			f.To, err = queryTime(c, "to")
+		}
+	}
+	if err != nil {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(err, apierr.InvalidQuery))
+		return
+	}
+	limit := f.Limit
+	f.Limit++
+	events, err := scanStore.ListAudit(c.Request.Context(), f)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("list audit events failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+
+	resp := gin.H{"limit": limit, "offset": f.Offset}
+	if len(events) > limit {
+		events = events[:limit]
+		resp["next_offset"] = f.Offset + limit
+	}
+	resp["events"] = events
+	c.JSON(http.StatusOK, resp)
+}
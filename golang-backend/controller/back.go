@@ -0,0 +1,12 @@
+package controller
+
+import (
+	"golang-backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackUploadHandler scans the back of an ID card for its laser code.
+func BackUploadHandler(c *gin.Context) {
+	handleUpload(c, service.ScanBack, false)
+}
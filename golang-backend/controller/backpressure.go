@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"golang-backend/apierr"
+	"golang-backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShedOCR turns a synchronous scan away before its upload is read when the
+// OCR wait queue is already full.
+func ShedOCR(c *gin.Context) {
+	if depth, capacity := service.OCRQueue(); capacity > 0 && depth >= capacity {
+		respondQueueFull(c, depth, capacity)
+		return
+	}
+	c.Next()
+}
+
+// ShedJobs is ShedOCR for scans queued as background jobs.
+func ShedJobs(c *gin.Context) {
+	if depth, capacity := scanJobs.Depth(); capacity > 0 && depth >= capacity {
+		respondQueueFull(c, depth, capacity)
+		return
+	}
+	c.Next()
+}
+
+// respondQueueFull answers 429 with the depth of the saturated queue, so
+// clients and load balancers can see how far behind the server is.
+func respondQueueFull(c *gin.Context, depth, capacity int) {
+	c.Header("Retry-After", "5")
+	c.Header("X-Queue-Depth", strconv.Itoa(depth))
+	c.Header("X-Queue-Capacity", strconv.Itoa(capacity))
+	apierr.Abort(c, http.StatusTooManyRequests, apierr.QueueFull, gin.H{"depth": depth, "capacity": capacity})
+}
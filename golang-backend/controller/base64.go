@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"golang-backend/apierr"
+	"golang-backend/metrics"
+	"golang-backend/service"
+	"golang-backend/storage"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type base64Upload struct {
+	Image    string           `json:"image" binding:"required"`
+	Filename string           `json:"filename"`
+	Consent  *storage.Consent `json:"consent"`
+}
+
+// Base64UploadHandler scans an image sent as base64 in a JSON body. A data URI
+// prefix such as "data:image/jpeg;base64," is accepted and ignored.
+func Base64UploadHandler(c *gin.Context) {
+	// base64 inflates the payload by a third
+	limitBody(c, maxUploadBytes*4/3+1024)
+	var req base64Upload
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if !bindConsent(c, req.Consent) {
+		return
+	}
+
+	image, err := decodeBase64Image(req.Image)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidBase64, nil)
+		return
+	}
+	if int64(len(image)) > maxUploadBytes {
+		respondTooLarge(c)
+		return
+	}
+	metrics.ObserveUpload(int64(len(image)))
+	if err = checkImageBytes(image); err != nil {
+		respondOpenError(c, err)
+		return
+	}
+
+	ctx, record := trackScan(c, scanContext(c))
+	result, err := service.Scan(ctx, bytes.NewReader(image))
+	id := record(result, err)
+	if d, late := pastDeadline(c, err); late {
+		respondPastDeadline(c, d, bytes.NewReader(image))
+		return
+	}
+	setScanID(c, id)
+	if err != nil {
+		respondScanError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, result)
+}
+
+func decodeBase64Image(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "data:") {
+		if i := strings.Index(s, ","); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	s = strings.TrimSpace(s)
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/metrics"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"mime/multipart"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type batchItem struct {
+	Filename string              `json:"filename"`
+	ScanID   string              `json:"scan_id,omitempty"`
+	Result   *service.ThaiIDCard `json:"result,omitempty"`
+	Error    string              `json:"error,omitempty"`
+	Reason   string              `json:"reason,omitempty"`
+}
+
+// BatchUploadHandler scans every file sent under the "files" (or "file") form
+// field, running at most batchConcurrency OCR calls at a time.
+func BatchUploadHandler(c *gin.Context) {
+	limitBody(c, maxUploadBytes*int64(maxBatchFiles))
+	form, err := c.MultipartForm()
+	if err != nil {
+		if isTooLarge(err) {
+			apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.BatchTooLarge, gin.H{
+				"max_bytes": maxUploadBytes * int64(maxBatchFiles),
+			})
+			return
+		}
+		apierr.Abort(c, http.StatusBadRequest, apierr.MultipartInvalid, nil)
+		return
+	}
+
+	files := append(form.File["files"], form.File["file"]...)
+	if len(files) == 0 {
+		apierr.Abort(c, http.StatusBadRequest, apierr.FileMissing, gin.H{"field": "files"})
+		return
+	}
+	if len(files) > maxBatchFiles {
+		apierr.Abort(c, http.StatusBadRequest, apierr.TooManyFiles, gin.H{"max_files": maxBatchFiles})
+		return
+	}
+	if !middleware.ReserveScans(c, len(files)) {
+		return
+	}
+	if !formConsent(c) {
+		return
+	}
+
+	middleware.SetScanCount(c, len(files))
+	results := scanAll(c, files)
+	respond(c, http.StatusOK, gin.H{"results": results})
+}
+
+func scanAll(c *gin.Context, files []*multipart.FileHeader) []batchItem {
+	results := make([]batchItem, len(files))
+	sem := make(chan struct{}, max(batchConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, fh := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, fh *multipart.FileHeader) {
+			defer func() { <-sem; wg.Done() }()
+			var err error
+			defer func() {
+				if err != nil {
+					results[i] = batchItem{Filename: fh.Filename, Error: "internal error"}
+				}
+			}()
+			defer service.Recover(c.Request.Context(), &err)
+			results[i] = scanFile(c, fh)
+		}(i, fh)
+	}
+	wg.Wait()
+	return results
+}
+
+func scanFile(c *gin.Context, fh *multipart.FileHeader) batchItem {
+	item := batchItem{Filename: fh.Filename}
+	metrics.ObserveUpload(fh.Size)
+	if fh.Size > maxUploadBytes {
+		item.Error = "file too large"
+		return item
+	}
+
+	image, err := openImage(fh)
+	if err != nil {
+		var unsupported *unsupportedMediaError
+		if errors.As(err, &unsupported) {
+			item.Error = unsupported.Error()
+		} else {
+			item.Error = "failed to read file"
+		}
+		return item
+	}
+	defer image.Close()
+
+	ctx, record := trackScan(c, scanContext(c))
+	result, err := service.Scan(ctx, image)
+	item.ScanID = record(result, err)
+	var (
+		quality *service.QualityError
+		pixels  *service.PixelLimitError
+	)
+	if errors.As(err, &quality) {
+		item.Error, item.Reason = "image quality too low", quality.Reason
+		return item
+	}
+	if errors.As(err, &pixels) {
+		item.Error = pixels.Error()
+		return item
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("scan failed", "filename", fh.Filename, "error", err)
+		item.Error = "failed to scan image"
+		return item
+	}
+	item.Result = result
+	return item
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"golang-backend/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CombinedUploadHandler scans the "front" and "back" images of one card sent
+// in a single multipart request.
+func CombinedUploadHandler(c *gin.Context) {
+	limitBody(c, 2*maxUploadBytes)
+	front, ok := formImage(c, "front")
+	if !ok {
+		return
+	}
+	defer front.Close()
+	back, ok := formImage(c, "back")
+	if !ok {
+		return
+	}
+	defer back.Close()
+	if !formConsent(c) {
+		return
+	}
+
+	ctx, record := trackScan(c, scanContext(c))
+	result, err := service.ScanFull(ctx, front, back)
+	setScanID(c, record(result, err))
+	if err != nil {
+		respondScanError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, result)
+}
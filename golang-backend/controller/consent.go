@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"golang-backend/apierr"
+	"golang-backend/storage"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const consentKey = "consent"
+
+// consentClockSkew is how far in the future a consent timestamp may be.
+const consentClockSkew = 5 * time.Minute
+
+var (
+	requireConsent  bool
+	consentVersions []string
+)
+
+// formConsent reads the consent JSON sent in the multipart "consent" field.
+// It writes the error response itself when it returns false.
+func formConsent(c *gin.Context) bool {
+	return rawConsent(c, c.PostForm("consent"))
+}
+
+// queryConsent is formConsent for the "consent" query parameter, used where
+// there is no body, such as a websocket handshake.
+func queryConsent(c *gin.Context) bool {
+	return rawConsent(c, c.Query("consent"))
+}
+
+func rawConsent(c *gin.Context, raw string) bool {
+	if raw == "" {
+		return bindConsent(c, nil)
+	}
+	var consent storage.Consent
+	if err := json.Unmarshal([]byte(raw), &consent); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ConsentInvalidJSON, nil)
+		return false
+	}
+	return bindConsent(c, &consent)
+}
+
+// bindConsent validates consent and keeps it for the scan record.
+func bindConsent(c *gin.Context, consent *storage.Consent) bool {
+	if err := ValidateConsent(consent); err != nil {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(err, apierr.InvalidBody))
+		return false
+	}
+	if consent != nil {
+		c.Set(consentKey, consent)
+	}
+	return true
+}
+
+// ValidateConsent checks consent against the configured requirements. A nil
+// consent is valid unless consent is required. Failures are *apierr.Error.
+func ValidateConsent(consent *storage.Consent) error {
+	missing := func(field string) error {
+		return apierr.New(apierr.ConsentFieldMissing, map[string]any{"field": field})
+	}
+	switch {
+	case consent == nil && requireConsent:
+		return apierr.New(apierr.ConsentRequired, nil)
+	case consent == nil:
+		return nil
+	case consent.Purpose == "":
+		return missing("purpose")
+	case consent.Version == "":
+		return missing("version")
+	case len(consentVersions) > 0 && !slices.Contains(consentVersions, consent.Version):
+		return apierr.New(apierr.ConsentVersion, map[string]any{"version": consent.Version, "accepted": consentVersions})
+	case consent.Channel == "":
+		return missing("channel")
+	case consent.Timestamp.IsZero():
+		return missing("timestamp")
+	case consent.Timestamp.After(time.Now().Add(consentClockSkew)):
+		return apierr.New(apierr.ConsentFutureTime, nil)
+	}
+	return nil
+}
@@ -1,26 +1,221 @@
 package controller
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/config"
+	"golang-backend/logging"
+	"golang-backend/metrics"
 	"golang-backend/service"
+	"io"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	maxUploadBytes   = config.Default().Upload.MaxBytes
+	maxBatchFiles    = config.Default().Upload.MaxBatchFiles
+	batchConcurrency = config.Default().Upload.BatchConcurrency
+)
+
+func Configure(cfg *config.Config) {
+	maxUploadBytes = cfg.Upload.MaxBytes
+	maxBatchFiles = cfg.Upload.MaxBatchFiles
+	maxDeadline = cfg.OCR.MaxDeadline
+	batchConcurrency = cfg.Upload.BatchConcurrency
+	requireConsent = cfg.Consent.Require
+	consentVersions = cfg.Consent.Versions
+	configTenants = cfg.Auth.Tenants
+	defaultKeyRoles = cfg.Auth.APIKeyRoles
+	allowedImageTypes["application/pdf"] = cfg.PDF.Enabled
+	allowedImageTypes["image/heic"] = cfg.HEIC.Enabled
+	liveScan = cfg.LiveScan
+	corsOrigins = cfg.CORS.AllowedOrigins
+	reportTitle = cfg.Report.Title
+	exportColumns, exportMask = cfg.Export.Columns, maskSet(cfg.Export.Mask)
+}
+
+// UploadHandler scans the front of the card uploaded as "file". Several
+// "file" parts are scanned concurrently as by BatchUploadHandler, answering
+// with a result per filename.
 func UploadHandler(c *gin.Context) {
-	image, _, err := c.Request.FormFile("file")
-	if err != nil {
-		c.String(http.StatusBadRequest, "failed to get file")
+	limitBody(c, maxUploadBytes*int64(maxBatchFiles))
+	if form, err := c.MultipartForm(); err == nil && len(form.File["file"]) > 1 {
+		BatchUploadHandler(c)
+		return
+	}
+	handleUpload(c, service.Scan, true)
+}
+
+// handleUpload runs scan on the single image uploaded as "file" and writes
+// the result in the negotiated format. With queueable, a front scan that
+// misses its processing deadline is handed to the job queue.
+func handleUpload[T any](c *gin.Context, scan func(context.Context, io.Reader) (T, error), queueable bool) {
+	limitBody(c, maxUploadBytes)
+	image, ok := formImage(c, "file")
+	if !ok {
+		return
+	}
+	defer image.Close()
+	if !formConsent(c) {
 		return
 	}
 
-	result, err := service.Scan(image)
+	ctx, record := trackScan(c, scanContext(c))
+	result, err := scan(ctx, image)
+	id := record(result, err)
+	if d, late := pastDeadline(c, err); late && queueable {
+		respondPastDeadline(c, d, image)
+		return
+	}
+	setScanID(c, id)
 	if err != nil {
-		fmt.Println("Error scanning image:", err)
-		c.String(http.StatusInternalServerError, "failed to scan image")
+		respondScanError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, result)
+}
+
+// scanContext carries per-request scan options from the query string.
+func scanContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if page, err := strconv.Atoi(c.Query("page")); err == nil {
+		ctx = service.WithPDFPage(ctx, page)
+	}
+	if age, err := strconv.Atoi(c.Query("min_age")); err == nil {
+		ctx = service.WithMinAge(ctx, age)
+	}
+	return ctx
+}
+
+// CheckScanQuery rejects scan options scanContext would otherwise ignore,
+// so a mistyped min_age is not read as no age check.
+func CheckScanQuery(c *gin.Context) {
+	for _, name := range []string{"page", "min_age"} {
+		if v, ok := c.GetQuery(name); ok {
+			if n, err := strconv.Atoi(v); err != nil || n < 0 {
+				apierr.Write(c, http.StatusBadRequest, apierr.From(invalidQuery(name, "a whole number"), apierr.InvalidQuery))
+				return
+			}
+		}
+	}
+	c.Next()
+}
+
+func respondScanError(c *gin.Context, err error) {
+	var (
+		quality *service.QualityError
+		pixels  *service.PixelLimitError
+		circuit *service.CircuitOpenError
+		busy    *service.OCRBusyError
+		full    *service.OCRQueueFullError
+	)
+	if d, late := pastDeadline(c, err); late {
+		respondPastDeadline(c, d, nil)
+		return
+	}
+	switch {
+	case errors.As(err, &circuit):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(circuit.RetryAfter.Seconds()))))
+		apierr.Abort(c, http.StatusServiceUnavailable, apierr.OCRUnavailable, nil)
+		return
+	case errors.As(err, &busy):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(busy.RetryAfter.Seconds()))))
+		apierr.Abort(c, http.StatusServiceUnavailable, apierr.OCRBusy, nil)
+		return
+	case errors.As(err, &full):
+		respondQueueFull(c, full.Depth, full.Capacity)
+		return
+	case errors.As(err, &quality):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.ImageQualityLow, gin.H{
+			"reason":    quality.Reason,
+			"value":     quality.Value,
+			"threshold": quality.Threshold,
+		})
+		return
+	case errors.As(err, &pixels):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.ImageTooLarge, gin.H{
+			"width":      pixels.Width,
+			"height":     pixels.Height,
+			"max_pixels": pixels.Max,
+		})
+		return
+	case errors.Is(err, service.ErrInvalidMRZ):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.MRZUnreadable, nil)
+		return
+	case errors.Is(err, service.ErrCardExpired):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.CardExpired, nil)
+		return
+	case errors.Is(err, service.ErrPDFPage):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.PDFPageNotFound, nil)
 		return
 	}
+	logging.FromContext(c.Request.Context()).Error("scan failed", "path", c.FullPath(), "error", err)
+	apierr.Abort(c, http.StatusInternalServerError, apierr.ScanFailed, nil)
+}
+
+const bodyLimitKey = "body_limit"
 
-	c.String(http.StatusOK, *result)
+// limitBody caps the request body at n bytes. Validation middleware and
+// handlers both call it, and the first cap counts for the whole request:
+// per-file sizes are checked on each file instead.
+func limitBody(c *gin.Context, n int64) {
+	if _, ok := c.Get(bodyLimitKey); ok {
+		return
+	}
+	c.Set(bodyLimitKey, n)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+}
+
+// formImage reads and validates the image uploaded under field, writing the
+// error response itself when it returns false.
+func formImage(c *gin.Context, field string) (multipart.File, bool) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		if isTooLarge(err) {
+			respondTooLarge(c)
+			return nil, false
+		}
+		apierr.Abort(c, http.StatusBadRequest, apierr.FileMissing, gin.H{"field": field})
+		return nil, false
+	}
+	metrics.ObserveUpload(header.Size)
+	if header.Size > maxUploadBytes {
+		respondTooLarge(c)
+		return nil, false
+	}
+
+	image, err := openImage(header)
+	if err != nil {
+		respondOpenError(c, err)
+		return nil, false
+	}
+	return image, true
+}
+
+func isTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+func respondTooLarge(c *gin.Context) {
+	apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.FileTooLarge, gin.H{"max_bytes": maxUploadBytes})
+}
+
+func respondOpenError(c *gin.Context, err error) {
+	var unsupported *unsupportedMediaError
+	if errors.As(err, &unsupported) {
+		apierr.Abort(c, http.StatusUnsupportedMediaType, apierr.UnsupportedMediaType, gin.H{
+			"content_type": unsupported.contentType,
+			"allowed":      allowedTypeList(),
+		})
+		return
+	}
+	apierr.Abort(c, http.StatusBadRequest, apierr.FileUnreadable, nil)
 }
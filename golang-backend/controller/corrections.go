@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type correctionBody struct {
+	// Fields replaces result fields by name.
+	Fields map[string]string `json:"fields"`
+	Note   string            `json:"note" binding:"max=1000"`
+}
+
+// PatchScanHandler corrects parsed fields of a stored scan, which must be
+// existing text fields of its result. The result then holds the corrected
+// values, with the fields derived from them recomputed, while the raw OCR
+// output is kept and each change is recorded in the scan's corrections with
+// the value OCR read.
+func PatchScanHandler(c *gin.Context) {
+	var body correctionBody
+	if !bindJSON(c, &body) {
+		return
+	}
+	if len(body.Fields) == 0 {
+		validationFailed(c, []FieldError{{Field: "fields", Rule: "required"}})
+		return
+	}
+	s, ok := loadScan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	result := resultFields(s)
+	if invalid := correctFields(c, s, result, body.Fields, body.Note); len(invalid) > 0 {
+		validationFailed(c, invalid)
+		return
+	}
+	s.Result, _ = json.Marshal(result)
+
+	if err := scanStore.Save(c.Request.Context(), s); err != nil {
+		logging.FromContext(c.Request.Context()).Error("save correction failed", "scan_id", s.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	respond(c, http.StatusOK, storedScan{Scan: s})
+}
+
+func resultFields(s *storage.Scan) map[string]json.RawMessage {
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(s.Result, &result); err != nil || result == nil {
+		result = map[string]json.RawMessage{}
+	}
+	return result
+}
+
+// correctFields sets each of fields in result, recomputing what is derived
+// from it, and appends a correction for it to s, unless one of them is not
+// a text field of result or has derived fields that cannot be recomputed,
+// in which case nothing changes and the invalid fields are returned.
+func correctFields(c *gin.Context, s *storage.Scan, result map[string]json.RawMessage, fields map[string]string, note string) []FieldError {
+	var invalid []FieldError
+	names := make([]string, 0, len(fields))
+	current := make(map[string]string, len(fields))
+	for name := range fields {
+		var old string
+		if json.Unmarshal(result[name], &old) != nil {
+			invalid = append(invalid, FieldError{Field: "fields." + name, Rule: "text_field"})
+			continue
+		}
+		if !service.CanRederive(result, name) {
+			invalid = append(invalid, FieldError{Field: "fields." + name, Rule: "derived_fields"})
+			continue
+		}
+		current[name] = old
+		names = append(names, name)
+	}
+	if len(invalid) > 0 {
+		return invalid
+	}
+
+	original := make(map[string]string)
+	for _, prior := range s.Corrections {
+		if _, ok := original[prior.Field]; !ok {
+			original[prior.Field] = prior.Original
+		}
+	}
+	p, _ := middleware.PrincipalFrom(c)
+	now := time.Now().UTC()
+	sort.Strings(names)
+	for _, name := range names {
+		value := fields[name]
+		if value == current[name] {
+			continue
+		}
+		first, ok := original[name]
+		if !ok {
+			first = current[name]
+		}
+		s.Corrections = append(s.Corrections, storage.Correction{
+			Field: name, Original: first, Value: value, Editor: p.KeyID, Note: note, EditedAt: now,
+		})
+		result[name], _ = json.Marshal(value)
+		service.Rederive(result, name, value, now)
+		if name == "id_number" {
+			s.IDHash = storage.HashCitizenID(value)
+		}
+	}
+	return nil
+}
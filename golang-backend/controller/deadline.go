@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/config"
+	"golang-backend/jobs"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DeadlineHeader asks for a scan to be answered within a duration such
+	// as "5s", or a whole number of seconds.
+	DeadlineHeader = "X-Processing-Timeout"
+	deadlineKey    = "processing_deadline"
+)
+
+var maxDeadline = config.Default().OCR.MaxDeadline
+
+// ProcessingDeadline gives the scan the deadline asked for with
+// X-Processing-Timeout or ?timeout=, capped at the configured maximum, so a
+// kiosk can stop waiting long before OCR's own timeout.
+func ProcessingDeadline(c *gin.Context) {
+	name, v := DeadlineHeader, c.GetHeader(DeadlineHeader)
+	if q, ok := c.GetQuery("timeout"); ok {
+		name, v = "timeout", q
+	}
+	if v == "" {
+		c.Next()
+		return
+	}
+	d, ok := parseDeadline(v)
+	if !ok {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(invalidQuery(name, `a positive duration such as "5s"`), apierr.InvalidQuery))
+		return
+	}
+	if maxDeadline > 0 && d > maxDeadline {
+		d = maxDeadline
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	c.Set(deadlineKey, d)
+	c.Next()
+}
+
+func parseDeadline(v string) (time.Duration, bool) {
+	if n, err := strconv.Atoi(v); err == nil {
+		return time.Duration(n) * time.Second, n > 0
+	}
+	d, err := time.ParseDuration(v)
+	return d, err == nil && d > 0
+}
+
+// pastDeadline reports whether err is the scan running out the deadline
+// ProcessingDeadline set, rather than OCR's own timeout.
+func pastDeadline(c *gin.Context, err error) (time.Duration, bool) {
+	d, ok := c.Get(deadlineKey)
+	if !ok || !errors.Is(err, context.DeadlineExceeded) || !errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return 0, false
+	}
+	return d.(time.Duration), true
+}
+
+// respondPastDeadline answers 504 for a scan that missed its deadline. When
+// image is set and jobs are enabled it is queued to finish in the
+// background, and the job's ID and Location are sent for the client to
+// poll.
+func respondPastDeadline(c *gin.Context, d time.Duration, image io.ReadSeeker) {
+	details := gin.H{"timeout": d.String()}
+	if image != nil && scanJobs != nil {
+		ctx := context.WithoutCancel(c.Request.Context())
+		_, err := image.Seek(0, io.SeekStart)
+		var b []byte
+		if err == nil {
+			b, err = io.ReadAll(image)
+		}
+		callbackURL, _ := callbackFor(c, "")
+		if err == nil {
+			var job jobs.Job
+			if job, err = scanJobs.Submit(ctx, b, callbackURL, newScanRecord(c), middleware.Can(c, middleware.PermReadAll)); err == nil {
+				location := middleware.VersionPath(c, "/scans/"+job.ID)
+				c.Header("Location", location)
+				details["job_id"], details["status_url"] = job.ID, location
+			}
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("queue scan past deadline failed", "error", err)
+		}
+	}
+	apierr.Abort(c, http.StatusGatewayTimeout, apierr.ProcessingTimeout, details)
+}
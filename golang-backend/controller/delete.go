@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang-backend/apierr"
+	"golang-backend/cache"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	resultCache      cache.Cache
+	idempotencyStore cache.Cache
+)
+
+// SetResultCache sets the OCR result cache purged scans are removed from.
+func SetResultCache(c cache.Cache) {
+	resultCache = c
+}
+
+// SetIdempotencyStore sets the store of idempotent responses purged scans
+// are removed from.
+func SetIdempotencyStore(c cache.Cache) {
+	idempotencyStore = c
+}
+
+// DeleteScanHandler soft-deletes a scan: it stays stored, for audits or
+// until retention removes it, but is no longer returned by the API. POST
+// /scans/{id}/purge removes it for good.
+func DeleteScanHandler(c *gin.Context) {
+	s, ok := loadScan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	p, _ := middleware.PrincipalFrom(c)
+	now := time.Now().UTC()
+	s.DeletedAt, s.DeletedBy = &now, p.KeyID
+	if err := scanStore.Save(c.Request.Context(), s); err != nil {
+		logging.FromContext(c.Request.Context()).Error("delete scan failed", "scan_id", s.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PurgeScanHandler erases a scan for a PDPA deletion request, whether or
+// not it was soft-deleted first: its stored images, its record and the
+// cached OCR output of its image. The audit log keeps only the scan ID.
+func PurgeScanHandler(c *gin.Context) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return
+	}
+	ctx := c.Request.Context()
+	s, err := scanStore.Get(ctx, c.Param("id"))
+	p, _ := middleware.PrincipalFrom(c)
+	if errors.Is(err, storage.ErrNotFound) || err == nil && p.Tenant != "" && s.Tenant != p.Tenant {
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("load scan failed", "scan_id", c.Param("id"), "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+
+	// The record goes last: if clearing a copy fails, the purge can be
+	// retried because the scan can still be found.
+	cached, replayable, err := forgetCopies(ctx, s)
+	if err != nil {
+		logging.FromContext(ctx).Error("purge cached copies failed", "scan_id", s.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	if err := scanStore.Delete(ctx, s.ID); err != nil {
+		logging.FromContext(ctx).Error("purge scan failed", "scan_id", s.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	middleware.SetAuditDetail(c, fmt.Sprintf("images=%d cache_entries=%d idempotent_response=%t", len(s.Images), cached, replayable))
+	c.Status(http.StatusNoContent)
+}
+
+// ForgetCopies drops the cached OCR output and idempotent response of s,
+// for retention to call before deleting it.
+func ForgetCopies(ctx context.Context, s *storage.Scan) error {
+	_, _, err := forgetCopies(ctx, s)
+	return err
+}
+
+// forgetCopies drops the copies of s kept outside its record, returning
+// how many result cache keys it tried and whether a stored idempotent
+// response was removed.
+func forgetCopies(ctx context.Context, s *storage.Scan) (int, bool, error) {
+	cached, err := forgetScan(ctx, s)
+	if err != nil || idempotencyStore == nil {
+		return cached, false, err
+	}
+	replayable, err := middleware.ForgetScan(ctx, idempotencyStore, s.ID)
+	return cached, replayable, err
+}
+
+// forgetScan drops the cached OCR output of s's image under each of its
+// document types, returning how many keys it tried.
+func forgetScan(ctx context.Context, s *storage.Scan) (int, error) {
+	if resultCache == nil || s.ImageSHA256 == "" {
+		return 0, nil
+	}
+	docs := strings.Split(s.Document, ",")
+	for _, doc := range docs {
+		if err := resultCache.Delete(ctx, service.CacheKey(service.Document(doc), s.ImageSHA256)); err != nil {
+			return 0, err
+		}
+	}
+	return len(docs), nil
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/jobs"
+	"golang-backend/logging"
+	"golang-backend/storage"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseKeepalive is how often a comment is sent on an idle event stream so
+// proxies do not time it out.
+const sseKeepalive = 15 * time.Second
+
+// ScanEventsHandler streams the progress of a queued scan as server-sent
+// events named after its stage, each carrying the job: queued,
+// preprocessing, ocr, parsing, then done or failed, after which the stream
+// ends. A scan no longer queued gets a single done or failed event with the
+// stored scan.
+func ScanEventsHandler(c *gin.Context) {
+	id := c.Param("id")
+	job, updates, stop, err := scanJobs.Watch(id)
+	if err != nil {
+		storedScanEvent(c, id)
+		return
+	}
+	defer stop()
+	if !ownsJob(c, job) {
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return
+	}
+
+	startEvents(c)
+	c.SSEvent(job.Stage, maskJob(c, job))
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case j, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent(j.Stage, maskJob(c, j))
+			return true
+		case <-keepalive.C:
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func storedScanEvent(c *gin.Context, id string) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return
+	}
+	s, err := scanStore.Get(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, storage.ErrNotFound), err == nil && !ownsScan(c, s):
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return
+	case err != nil:
+		logging.FromContext(c.Request.Context()).Error("load scan failed", "scan_id", id, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	maskScan(c, s)
+	event := string(jobs.StatusDone)
+	if s.Status == storage.StatusFailed {
+		event = string(jobs.StatusFailed)
+	}
+	startEvents(c)
+	c.SSEvent(event, s)
+}
+
+func startEvents(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	// Stop nginx from buffering the stream.
+	c.Header("X-Accel-Buffering", "no")
+	// The stream may outlive server.write_timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/service"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FaceMatchHandler compares the "selfie" upload with the card uploaded as
+// "file", or with the card image stored for the scan named by "scan_id".
+// The selfie's liveness is checked too when a provider is configured, and
+// kept on the named scan.
+func FaceMatchHandler(c *gin.Context) {
+	limitBody(c, 2*maxUploadBytes)
+	var (
+		scan *storage.Scan
+		card []byte
+	)
+	if id := c.PostForm("scan_id"); id != "" {
+		var ok bool
+		if scan, card, ok = storedCardImage(c, id); !ok {
+			return
+		}
+	} else {
+		image, ok := formImage(c, "file")
+		if !ok {
+			return
+		}
+		defer image.Close()
+		if card, ok = readUpload(c, image); !ok {
+			return
+		}
+	}
+	selfieFile, ok := formImage(c, "selfie")
+	if !ok {
+		return
+	}
+	defer selfieFile.Close()
+	selfie, ok := readUpload(c, selfieFile)
+	if !ok || !formConsent(c) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := service.CompareFaces(ctx, card, selfie)
+	switch {
+	case errors.Is(err, service.ErrFaceMatchDisabled):
+		apierr.Abort(c, http.StatusServiceUnavailable, apierr.FaceDisabled, nil)
+		return
+	case err != nil:
+		logging.FromContext(ctx).Error("face match failed", "error", err)
+		apierr.Abort(c, http.StatusBadGateway, apierr.FaceUnavailable, nil)
+		return
+	}
+	if result.Liveness, err = service.CheckLiveness(ctx, selfie); err != nil {
+		logging.FromContext(ctx).Error("liveness check failed", "error", err)
+		apierr.Abort(c, http.StatusBadGateway, apierr.LivenessFailed, nil)
+		return
+	}
+	if scan != nil && result.Liveness != nil {
+		scan.Liveness, _ = json.Marshal(result.Liveness)
+		if err := scanStore.Save(context.WithoutCancel(ctx), scan); err != nil {
+			logging.FromContext(ctx).Error("save liveness failed", "scan_id", scan.ID, "error", err)
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func readUpload(c *gin.Context, r io.Reader) ([]byte, bool) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.FileUnreadable, nil)
+		return nil, false
+	}
+	return b, true
+}
+
+// storedCardImage loads a scan the caller owns and the card image kept for
+// it, preferring the cropped front.
+func storedCardImage(c *gin.Context, id string) (*storage.Scan, []byte, bool) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return nil, nil, false
+	}
+	if imageStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.ImageStoreOff, nil)
+		return nil, nil, false
+	}
+	ctx := c.Request.Context()
+	s, err := scanStore.Get(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) || err == nil && !ownsScan(c, s) {
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return nil, nil, false
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("load scan failed", "scan_id", id, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return nil, nil, false
+	}
+	for _, name := range []string{"front_cropped", "front", "file"} {
+		key, ok := s.Images[name]
+		if !ok {
+			continue
+		}
+		b, err := imageStore.Get(ctx, key)
+		if err != nil {
+			logging.FromContext(ctx).Error("load scan image failed", "scan_id", id, "key", key, "error", err)
+			apierr.Abort(c, http.StatusBadGateway, apierr.ImageLoadError, nil)
+			return nil, nil, false
+		}
+		return s, b, true
+	}
+	apierr.Abort(c, http.StatusNotFound, apierr.ImageLoadError, gin.H{"scan_id": id})
+	return nil, nil, false
+}
@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"golang-backend/apierr"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const formatKey = "response_format"
+
+var formatTypes = map[string]string{
+	"application/json": "json",
+	"application/xml":  "xml",
+	"text/xml":         "xml",
+	"text/csv":         "csv",
+}
+
+// Negotiate picks the response format from ?format= (json, xml or csv) or
+// else the Accept header, defaulting to JSON. It runs before the handler
+// so an unknown format is refused before any scan is made. Errors are
+// always JSON.
+func Negotiate(c *gin.Context) {
+	c.Writer.Header().Add("Vary", "Accept")
+	format := strings.ToLower(c.Query("format"))
+	switch format {
+	case "json", "xml", "csv":
+	case "":
+		format = formatTypes[c.NegotiateFormat("application/json", "application/xml", "text/xml", "text/csv")]
+	default:
+		apierr.Write(c, http.StatusBadRequest, apierr.From(invalidQuery("format", "json, xml or csv"), apierr.InvalidQuery))
+		return
+	}
+	c.Set(formatKey, format)
+	c.Next()
+}
+
+// respond writes v with status in the format chosen by Negotiate. XML and
+// CSV are rendered from v's JSON encoding, so they carry the same field
+// names: XML nests an element per field under <response>, with list items
+// as <item>. CSV writes a row per item of the first list of objects in v,
+// or a single row, with nested fields as dotted columns.
+func respond(c *gin.Context, status int, v any) {
+	format := c.GetString(formatKey)
+	if format == "" || format == "json" {
+		c.JSON(status, v)
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	root, err := parseNode(json.NewDecoder(bytes.NewReader(b)))
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/xml; charset=utf-8"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+		err = root.writeCSV(&buf)
+	} else {
+		buf.WriteString(xml.Header)
+		enc := xml.NewEncoder(&buf)
+		enc.Indent("", "  ")
+		if err = root.writeXML(enc, "response"); err == nil {
+			err = enc.Flush()
+		}
+	}
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, contentType, buf.Bytes())
+}
+
+// node is a decoded JSON value that keeps object keys in their order.
+type node struct {
+	object, array bool
+	keys          []string
+	children      []*node
+	scalar        string
+	str, null     bool
+}
+
+func parseNode(dec *json.Decoder) (*node, error) {
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	n := &node{}
+	switch t := tok.(type) {
+	case json.Delim:
+		n.object, n.array = t == '{', t == '['
+		for dec.More() {
+			if n.object {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			child, err := parseNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case nil:
+		n.null = true
+	case string:
+		n.scalar, n.str = t, true
+	default:
+		n.scalar = fmt.Sprint(t)
+	}
+	return n, nil
+}
+
+func jsonText(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// writeXML writes n as an element called name. Keys that are not valid
+// element names become <field name="...">.
+func (n *node) writeXML(enc *xml.Encoder, name string) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlNamePattern.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		start = xml.StartElement{Name: xml.Name{Local: "field"}, Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}}}
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for i, child := range n.children {
+		childName := "item"
+		if n.object {
+			childName = n.keys[i]
+		}
+		if err := child.writeXML(enc, childName); err != nil {
+			return err
+		}
+	}
+	if !n.object && !n.array && !n.null {
+		if err := enc.EncodeToken(xml.CharData(n.scalar)); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// writeCSV writes a row per object in n's first list of objects, or n as
+// the only row. An empty list writes nothing.
+func (n *node) writeCSV(w io.Writer) error {
+	rows := []*node{n}
+	switch {
+	case n.array:
+		rows = n.children
+	case n.object:
+		for _, child := range n.children {
+			if child.array && (len(child.children) == 0 || child.children[0].object) {
+				rows = child.children
+				break
+			}
+		}
+	}
+
+	var (
+		columns []string
+		index   = map[string]int{}
+		records []map[string]string
+	)
+	for _, row := range rows {
+		record := map[string]string{}
+		row.flatten("", func(key, value string) {
+			if _, ok := index[key]; !ok {
+				index[key] = len(columns)
+				columns = append(columns, key)
+			}
+			record[key] = value
+		})
+		records = append(records, record)
+	}
+
+	cw := csv.NewWriter(w)
+	if len(columns) > 0 {
+		cw.Write(columns)
+	}
+	for _, record := range records {
+		line := make([]string, len(columns))
+		for i, col := range columns {
+			line[i] = record[col]
+		}
+		cw.Write(line)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// flatten calls emit for each scalar under n, keyed by its dotted path.
+// Lists are emitted whole as JSON.
+func (n *node) flatten(prefix string, emit func(key, value string)) {
+	switch {
+	case n.object:
+		for i, child := range n.children {
+			key := n.keys[i]
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			child.flatten(key, emit)
+		}
+	case n.array:
+		var b strings.Builder
+		n.writeJSON(&b)
+		emit(prefix, b.String())
+	case n.null:
+		emit(prefix, "")
+	default:
+		emit(prefix, n.scalar)
+	}
+}
+
+func (n *node) writeJSON(b *strings.Builder) {
+	switch {
+	case n.object || n.array:
+		open, end := "[", "]"
+		if n.object {
+			open, end = "{", "}"
+		}
+		b.WriteString(open)
+		for i, child := range n.children {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			if n.object {
+				b.WriteString(jsonText(n.keys[i]) + ":")
+			}
+			child.writeJSON(b)
+		}
+		b.WriteString(end)
+	case n.null:
+		b.WriteString("null")
+	case n.str:
+		b.WriteString(jsonText(n.scalar))
+	default:
+		b.WriteString(n.scalar)
+	}
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"golang-backend/service"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+func HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+var draining atomic.Bool
+
+// StartDraining makes /readyz fail so load balancers stop routing new
+// requests here before the server shuts down.
+func StartDraining() {
+	draining.Store(true)
+}
+
+func ReadyzHandler(c *gin.Context) {
+	if draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+	checks := map[string]dependencyStatus{
+		"ocr": checkDependency(func() error { return service.PingOCR(c.Request.Context()) }),
+	}
+
+	status, code := "ready", http.StatusOK
+	for _, check := range checks {
+		if check.Status != "up" {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+func checkDependency(ping func() error) dependencyStatus {
+	start := time.Now()
+	err := ping()
+	s := dependencyStatus{Status: "up", LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		s.Status, s.Error = "down", err.Error()
+	}
+	return s
+}
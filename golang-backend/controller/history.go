@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"golang-backend/events"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	scanStore  storage.Repository
+	imageStore storage.ImageStore
+)
+
+func SetScanStore(r storage.Repository, images storage.ImageStore) {
+	scanStore, imageStore = r, images
+}
+
+// newScanRecord starts a storage record with the request's metadata.
+func newScanRecord(c *gin.Context) *storage.Scan {
+	s := &storage.Scan{
+		RequestID: logging.RequestID(c.Request.Context()),
+		ClientIP:  c.ClientIP(),
+		Route:     c.FullPath(),
+	}
+	if p, ok := middleware.PrincipalFrom(c); ok {
+		s.KeyID = p.KeyID
+		s.Tenant = p.Tenant
+	}
+	if v, ok := c.Get(consentKey); ok {
+		s.Consent = v.(*storage.Consent)
+	}
+	return s
+}
+
+// trackScan wraps ctx so the scan's OCR output is captured, and returns a
+// function that persists and publishes the outcome and reports the stored
+// scan ID. Storage errors are logged and yield an empty ID.
+func trackScan(c *gin.Context, ctx context.Context) (context.Context, func(result any, err error) string) {
+	ctx, capture := service.WithCapture(ctx)
+	s := newScanRecord(c)
+	return ctx, func(result any, err error) string {
+		s.SetResult(result, err)
+		s.SetCapture(capture)
+		defer events.Publish(s)
+		if scanStore == nil {
+			return ""
+		}
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err == nil {
+			if err := storage.CheckDuplicate(ctx, scanStore, s, result, middleware.Can(c, middleware.PermReadAll)); err != nil {
+				logging.FromContext(ctx).Error("duplicate check failed", "error", err)
+			}
+		}
+		if err := scanStore.Save(ctx, s); err != nil {
+			logging.FromContext(ctx).Error("save scan failed", "error", err)
+			s.ID = ""
+			return ""
+		}
+		middleware.NoteScan(c, s.ID)
+		return s.ID
+	}
+}
+
+func setScanID(c *gin.Context, id string) {
+	if id != "" {
+		c.Header("X-Scan-ID", id)
+	}
+}
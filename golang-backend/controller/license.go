@@ -0,0 +1,11 @@
+package controller
+
+import (
+	"golang-backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DriverLicenseUploadHandler(c *gin.Context) {
+	handleUpload(c, service.ScanDriverLicense, false)
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/config"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+var (
+	liveScan    = config.Default().LiveScan
+	corsOrigins = config.Default().CORS.AllowedOrigins
+)
+
+// liveMessage is sent for each frame the server looks at. Type is:
+//   - "rejected": the frame was unusable; Reason says why, such as "blurry"
+//   - "partial": OCR read the card but some fields need a better frame
+//   - "result": a confident read; the session ends
+//   - "error": the session ends with Error
+type liveMessage struct {
+	Type    string                 `json:"type"`
+	Reason  string                 `json:"reason,omitempty"`
+	Quality *service.QualityReport `json:"quality,omitempty"`
+	Result  *service.ThaiIDCard    `json:"result,omitempty"`
+	ScanID  string                 `json:"scan_id,omitempty"`
+	Error   *apierr.Error          `json:"error,omitempty"`
+}
+
+// LiveScanHandler upgrades to a websocket over which the client sends
+// camera frames as binary JPEG messages. Frames are throttled to one per
+// live_scan.frame_interval, the newest winning, and quality checked before
+// OCR. The session ends with the first read whose fields all pass the
+// confidence thresholds, which is the only one stored. Consent, when
+// required, is sent as JSON in the "consent" query parameter.
+func LiveScanHandler(c *gin.Context) {
+	if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		apierr.Abort(c, http.StatusUpgradeRequired, apierr.NotWebSocket, nil)
+		return
+	}
+	if origin := c.GetHeader("Origin"); origin != "" && !middleware.OriginAllowed(corsOrigins, origin) {
+		apierr.Abort(c, http.StatusForbidden, apierr.PermissionDenied, gin.H{"origin": origin})
+		return
+	}
+	if !queryConsent(c) {
+		return
+	}
+
+	var attempts int
+	server := websocket.Server{
+		// Origin was checked above; clients without one are not browsers.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			attempts = runLiveScan(c, ws)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+	middleware.SetScanCount(c, attempts)
+}
+
+// runLiveScan serves one session and returns how many frames went to OCR.
+func runLiveScan(c *gin.Context, ws *websocket.Conn) int {
+	ctx, cancel := context.WithTimeout(scanContext(c), liveScan.MaxDuration)
+	defer cancel()
+	ws.MaxPayloadBytes = int(liveScan.MaxFrameBytes)
+	ws.SetDeadline(time.Now().Add(liveScan.MaxDuration))
+	send := func(m liveMessage) {
+		if err := websocket.JSON.Send(ws, m); err != nil {
+			cancel()
+		}
+	}
+
+	frames := make(chan []byte, 1)
+	go func() {
+		defer close(frames)
+		for ctx.Err() == nil {
+			var frame []byte
+			err := websocket.Message.Receive(ws, &frame)
+			if errors.Is(err, websocket.ErrFrameTooLarge) {
+				send(liveMessage{Type: "rejected", Reason: "too_large"})
+				continue
+			}
+			if err != nil {
+				cancel()
+				return
+			}
+			// Keep only the newest frame; older ones are stale by now.
+			select {
+			case <-frames:
+			default:
+			}
+			frames <- frame
+		}
+	}()
+
+	var (
+		attempts int
+		last     time.Time
+	)
+	for frame := range frames {
+		if wait := liveScan.FrameInterval - time.Since(last); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return attempts
+			}
+			select {
+			case newer, ok := <-frames:
+				if ok {
+					frame = newer
+				}
+			default:
+			}
+		}
+		last = time.Now()
+
+		report, err := service.CheckFrame(frame)
+		if err != nil {
+			send(rejected(report, err, "unreadable"))
+			continue
+		}
+		if period := middleware.TryReserveScans(c, attempts+1); period != "" {
+			send(liveError(c, apierr.New(apierr.QuotaExceeded, map[string]any{"period": period})))
+			return attempts
+		}
+		attempts++
+		scanCtx, record := trackScan(c, ctx)
+		card, err := service.Scan(scanCtx, bytes.NewReader(frame))
+		var (
+			circuit *service.CircuitOpenError
+			busy    *service.OCRBusyError
+			full    *service.OCRQueueFullError
+		)
+		switch {
+		case errors.As(err, &circuit):
+			send(liveError(c, apierr.New(apierr.OCRUnavailable, nil)))
+			return attempts
+		case errors.As(err, &busy), errors.As(err, &full):
+			// The frame never reached OCR; the next may find a free slot.
+			attempts--
+			send(liveMessage{Type: "rejected", Reason: "busy"})
+			continue
+		case err != nil && ctx.Err() != nil:
+			return attempts
+		case err != nil:
+			logging.FromContext(ctx).Warn("live scan frame failed", "error", err)
+			send(rejected(report, err, "scan_failed"))
+		case card.Status == service.StatusOK && card.IDValid:
+			send(liveMessage{Type: "result", Result: card, ScanID: record(card, nil)})
+			return attempts
+		default:
+			send(liveMessage{Type: "partial", Result: card})
+		}
+		if attempts >= liveScan.MaxAttempts {
+			send(liveError(c, apierr.New(apierr.LiveIncomplete, map[string]any{"attempts": attempts})))
+			return attempts
+		}
+	}
+	return attempts
+}
+
+func rejected(report *service.QualityReport, err error, reason string) liveMessage {
+	m := liveMessage{Type: "rejected", Quality: report, Reason: reason}
+	var quality *service.QualityError
+	if errors.As(err, &quality) {
+		m.Reason = quality.Reason
+	}
+	return m
+}
+
+func liveError(c *gin.Context, e *apierr.Error) liveMessage {
+	return liveMessage{Type: "error", Error: apierr.Localize(c, e)}
+}
@@ -0,0 +1,421 @@
+package controller
+
+import (
+	"fmt"
+	"golang-backend/apierr"
+	"golang-backend/jobs"
+	"golang-backend/openapi"
+	"golang-backend/service"
+	"golang-backend/storage"
+	"slices"
+	"strings"
+)
+
+// The list and usage responses are built with gin.H; these types only
+// describe them.
+type (
+	scanPage struct {
+		Limit      int           `json:"limit"`
+		Offset     int           `json:"offset"`
+		NextOffset int           `json:"next_offset,omitempty"`
+		Scans      []scanSummary `json:"scans"`
+	}
+	reviewPage struct {
+		Limit      int          `json:"limit"`
+		Offset     int          `json:"offset"`
+		NextOffset int          `json:"next_offset,omitempty"`
+		Scans      []reviewItem `json:"scans"`
+	}
+	searchPage struct {
+		Limit int         `json:"limit"`
+		Scans []searchHit `json:"scans"`
+	}
+	auditPage struct {
+		Limit      int                   `json:"limit"`
+		Offset     int                   `json:"offset"`
+		NextOffset int                   `json:"next_offset,omitempty"`
+		Events     []*storage.AuditEvent `json:"events"`
+	}
+	usageResponse struct {
+		From  string      `json:"from,omitempty"`
+		To    string      `json:"to,omitempty"`
+		Usage usageReport `json:"usage"`
+	}
+	adminUsageResponse struct {
+		From    string        `json:"from,omitempty"`
+		To      string        `json:"to,omitempty"`
+		Total   usageReport   `json:"total"`
+		Tenants []tenantUsage `json:"tenants"`
+	}
+	batchResponse struct {
+		Results []batchItem `json:"results"`
+	}
+	tenantList struct {
+		Tenants []*storage.Tenant `json:"tenants"`
+	}
+	keyList struct {
+		Keys []*storage.APIKey `json:"keys"`
+	}
+	healthResponse struct {
+		Status string                      `json:"status"`
+		Checks map[string]dependencyStatus `json:"checks,omitempty"`
+	}
+	rotateBody struct {
+		Grace string `json:"grace,omitempty"`
+	}
+)
+
+// DescribeAPI documents every route registered in main. Routes missing
+// from here are logged at startup.
+func DescribeAPI(spec *openapi.Spec) {
+	const v1 = "/v1"
+	spec.Describe("Reads Thai ID cards, passports, driver licenses and house registrations. " +
+		"Errors carry a stable code from the catalog below; match on it rather than on the message, " +
+		"which is in Thai when Accept-Language prefers th.\n\n" + errorCatalog(spec))
+	errorBody := spec.Schema(apierr.Error{})
+	jsonError := func(description string) *openapi.Response {
+		return &openapi.Response{Description: description, Content: map[string]openapi.MediaType{
+			"application/json": {Schema: errorBody},
+		}}
+	}
+	var (
+		badRequest    = spec.Response("BadRequest", jsonError("The request is malformed or fails validation. VALIDATION_FAILED lists each rejected field, rule and param in details.fields."))
+		unauthorized  = spec.Response("Unauthorized", jsonError("The API key or token is missing or invalid."))
+		forbidden     = spec.Response("Forbidden", jsonError("The caller's role lacks the permission, or its tenant is disabled."))
+		notFound      = spec.Response("NotFound", jsonError("The resource does not exist, or scan storage is disabled."))
+		tooLarge      = spec.Response("TooLarge", jsonError("The upload exceeds upload.max_bytes; max_bytes is returned, or a max_bytes rule in details.fields."))
+		unsupported   = spec.Response("UnsupportedMediaType", jsonError("The body is not multipart or an image type is not accepted; the allowed types are returned."))
+		unprocessable = spec.Response("Unprocessable", jsonError("The image was read but is unusable: quality too low, unreadable MRZ, expired card or missing PDF page."))
+		tooMany       = spec.Response("TooManyRequests", jsonError("The rate limit or tenant quota is exhausted, or the scan queue is full. Retry-After says when to try again."))
+		internal      = spec.Response("Internal", jsonError("The scan or storage failed."))
+		unavailable   = spec.Response("Unavailable", jsonError("The OCR service is unavailable or busy, the queue is full, or the server is shutting down."))
+	)
+	ok := func(schema *openapi.Schema) *openapi.Response {
+		return &openapi.Response{Description: "OK", Content: map[string]openapi.MediaType{"application/json": {Schema: schema}}}
+	}
+	jsonBody := func(v any) *openapi.RequestBody {
+		return &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{"application/json": {Schema: spec.Schema(v)}}}
+	}
+	multipart := func(files ...string) *openapi.RequestBody {
+		props := map[string]*openapi.Schema{
+			"consent": {Type: "string", Description: "PDPA consent as JSON: purpose, version, timestamp and channel."},
+		}
+		for _, f := range files {
+			props[f] = openapi.Binary()
+		}
+		return &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+			"multipart/form-data": {Schema: openapi.Object(props, "consent")},
+		}}
+	}
+	query := func(name, description string) openapi.Parameter {
+		return openapi.Parameter{Name: name, In: "query", Description: description, Schema: openapi.String()}
+	}
+	pdfPage := query("page", "Page of a PDF upload to scan, starting at 1.")
+	minAge := openapi.Parameter{Name: "min_age", In: "query", Schema: openapi.Integer(),
+		Description: "Adds age_check_passed to ID card results: whether the holder is at least this many years old."}
+	paging := []openapi.Parameter{
+		{Name: "limit", In: "query", Description: "Page size, at most 200.", Schema: openapi.Integer()},
+		{Name: "offset", In: "query", Schema: openapi.Integer()},
+		query("from", "RFC 3339 or YYYY-MM-DD, inclusive."),
+		query("to", "RFC 3339 or YYYY-MM-DD, exclusive."),
+	}
+	deadline := openapi.Parameter{Name: DeadlineHeader, In: "header", Schema: openapi.String(),
+		Description: "Longest the scan may take, e.g. 5s, capped at ocr.max_deadline. ?timeout= does the same."}
+	pastDeadline := jsonError("The scan missed its processing deadline. For a card front, details.job_id and Location point at a job finishing it.")
+	scanErrors := map[string]*openapi.Response{
+		"400": badRequest, "401": unauthorized, "403": forbidden, "413": tooLarge, "415": unsupported,
+		"422": unprocessable, "429": tooMany, "500": internal, "503": unavailable, "504": pastDeadline,
+	}
+	scanOp := func(summary string, body *openapi.RequestBody, result any) openapi.Operation {
+		responses := map[string]*openapi.Response{"200": ok(spec.Schema(result))}
+		for code, r := range scanErrors {
+			responses[code] = r
+		}
+		return openapi.Operation{Summary: summary, Tags: []string{"scan"}, Parameters: []openapi.Parameter{pdfPage, minAge, deadline},
+			RequestBody: body, Responses: responses}
+	}
+	with := func(r map[string]*openapi.Response, codes ...string) map[string]*openapi.Response {
+		all := map[string]*openapi.Response{"400": badRequest, "401": unauthorized, "403": forbidden, "404": notFound, "500": internal}
+		for _, code := range codes {
+			r[code] = all[code]
+		}
+		return r
+	}
+	// negotiated documents Negotiate on an operation answering 200.
+	format := query("format", "json, xml or csv; the Accept header decides when absent.")
+	negotiated := func(op openapi.Operation) openapi.Operation {
+		op.Parameters = append(op.Parameters[:len(op.Parameters):len(op.Parameters)], format)
+		okResponse := *op.Responses["200"]
+		okResponse.Content = map[string]openapi.MediaType{
+			"application/json": okResponse.Content["application/json"],
+			"application/xml":  {Schema: openapi.String()},
+			"text/csv":         {Schema: openapi.String()},
+		}
+		op.Responses["200"] = &okResponse
+		return op
+	}
+
+	health := openapi.Operation{Tags: []string{"health"}, Public: true}
+	health.Summary, health.Responses = "Liveness check", map[string]*openapi.Response{"200": ok(spec.Schema(healthResponse{}))}
+	spec.Add("GET", "/healthz", health)
+	health.Summary = "Readiness check, including the OCR service"
+	health.Responses = map[string]*openapi.Response{"200": ok(spec.Schema(healthResponse{})),
+		"503": {Description: "Not ready or draining.", Content: map[string]openapi.MediaType{"application/json": {Schema: spec.Schema(healthResponse{})}}}}
+	spec.Add("GET", "/readyz", health)
+	spec.Add("GET", "/metrics", openapi.Operation{Summary: "Prometheus metrics", Tags: []string{"health"}, Public: true,
+		Responses: map[string]*openapi.Response{"200": {Description: "Metrics in the Prometheus text format.",
+			Content: map[string]openapi.MediaType{"text/plain": {Schema: openapi.String()}}}}})
+
+	upload := scanOp("Scan the front of a Thai ID card", multipart("file"), service.ThaiIDCard{})
+	upload.Description = "Several file parts are scanned as by /upload/batch and answered with its results, one per filename."
+	spec.Add("POST", v1+"/upload", negotiated(upload))
+	spec.Add("POST", v1+"/upload/back", negotiated(scanOp("Scan the back of a Thai ID card", multipart("file"), service.ThaiIDCardBack{})))
+	spec.Add("POST", v1+"/upload/combined", negotiated(scanOp("Scan both sides of a Thai ID card", multipart("front", "back"), service.ThaiIDCardFull{})))
+	spec.Add("POST", v1+"/upload/passport", negotiated(scanOp("Scan a passport data page", multipart("file"), service.Passport{})))
+	spec.Add("POST", v1+"/upload/driver-license", negotiated(scanOp("Scan a Thai driver license", multipart("file"), service.DriverLicense{})))
+	spec.Add("POST", v1+"/upload/house-registration", negotiated(scanOp("Scan a house registration book", multipart("file"), service.HouseRegistration{})))
+	spec.Add("POST", v1+"/upload/base64", negotiated(scanOp("Scan an ID card image sent as base64", jsonBody(base64Upload{}), service.ThaiIDCard{})))
+	fetch := scanOp("Download and scan an ID card image", jsonBody(urlUpload{}), service.ThaiIDCard{})
+	fetch.Responses["502"] = jsonError("The image could not be downloaded.")
+	spec.Add("POST", v1+"/upload/url", negotiated(fetch))
+	batch := scanOp("Scan several ID cards; each file succeeds or fails on its own", multipart(), batchResponse{})
+	batch.RequestBody.Content["multipart/form-data"].Schema.Properties["files"] = openapi.ArrayOf(openapi.Binary())
+	spec.Add("POST", v1+"/upload/batch", negotiated(batch))
+
+	async := scanOp("Queue an ID card scan", multipart("file"), jobs.Job{})
+	async.Description = "Returns at once with a job to poll at /scans/{id}. callback_url, or the key's webhook, receives the result."
+	async.RequestBody.Content["multipart/form-data"].Schema.Properties["callback_url"] = openapi.String()
+	async.Responses["202"] = &openapi.Response{Description: "Queued. Location points at the scan.",
+		Headers: map[string]openapi.Header{"Location": {Schema: openapi.String()}},
+		Content: map[string]openapi.MediaType{"application/json": {Schema: spec.Schema(jobs.Job{})}}}
+	async.Parameters = async.Parameters[:2]
+	delete(async.Responses, "200")
+	delete(async.Responses, "504")
+	spec.Add("POST", v1+"/scans", async)
+
+	spec.Add("GET", v1+"/ws/scan", openapi.Operation{
+		Summary: "Scan a card held in front of the camera", Tags: []string{"scan"},
+		Description: "Upgrades to a websocket. Send camera frames as binary JPEG messages; each frame looked at is answered " +
+			"with a JSON message of type rejected (with reason and quality), partial or result (with result and scan_id), " +
+			"or error, which ends the session like result does. At most one frame per live_scan.frame_interval is checked.",
+		Parameters: []openapi.Parameter{query("consent", "PDPA consent as JSON: purpose, version, timestamp and channel.")},
+		Responses: map[string]*openapi.Response{
+			"101": {Description: "Switched to the websocket protocol.", Content: map[string]openapi.MediaType{
+				"application/json": {Schema: spec.Schema(liveMessage{})}}},
+			"400": badRequest, "401": unauthorized, "403": forbidden, "426": jsonError("The request is not a websocket upgrade."),
+			"429": tooMany,
+		},
+	})
+
+	header := func(name, description string) openapi.Parameter {
+		return openapi.Parameter{Name: name, In: "header", Required: true, Description: description, Schema: openapi.String()}
+	}
+	tusVersionHeader := header("Tus-Resumable", "Must be "+tusVersion+".")
+	offsetHeaders := map[string]openapi.Header{
+		"Upload-Offset":  {Schema: openapi.Integer()},
+		"Upload-Length":  {Schema: openapi.Integer()},
+		"Upload-Expires": {Schema: openapi.String()},
+		"Location":       {Description: "The scan, once the upload is complete.", Schema: openapi.String()},
+	}
+	tusErrors := func(r map[string]*openapi.Response) map[string]*openapi.Response {
+		r["400"], r["401"], r["403"], r["404"] = badRequest, unauthorized, forbidden, notFound
+		r["412"] = jsonError("Tus-Resumable is missing or not " + tusVersion + ".")
+		r["500"] = internal
+		return r
+	}
+	spec.Add("OPTIONS", v1+"/uploads", openapi.Operation{
+		Summary: "Tus protocol versions and extensions", Tags: []string{"uploads"}, Public: true,
+		Responses: map[string]*openapi.Response{"204": {Description: "Tus-Version, Tus-Extension and Tus-Max-Size are set."}},
+	})
+	spec.Add("POST", v1+"/uploads", openapi.Operation{
+		Summary: "Start a resumable upload", Tags: []string{"uploads"},
+		Description: "Tus creation. Upload-Metadata may carry consent and callback_url as for POST /scans; " +
+			"the scan is queued when the last byte arrives. Uploads expire after resumable.expiry.",
+		Parameters: []openapi.Parameter{tusVersionHeader, header("Upload-Length", "Size of the whole file."),
+			{Name: "Upload-Metadata", In: "header", Description: "Comma-separated keys with base64 values.", Schema: openapi.String()}},
+		Responses: tusErrors(map[string]*openapi.Response{
+			"201": {Description: "Created. Location points at the upload.", Headers: map[string]openapi.Header{
+				"Location": {Schema: openapi.String()}, "Upload-Expires": {Schema: openapi.String()}}},
+			"413": tooLarge, "429": tooMany,
+		}),
+	})
+	spec.Add("HEAD", v1+"/uploads/:id", openapi.Operation{
+		Summary: "Get the offset to resume an upload at", Tags: []string{"uploads"},
+		Parameters: []openapi.Parameter{tusVersionHeader},
+		Responses:  tusErrors(map[string]*openapi.Response{"200": {Description: "OK", Headers: offsetHeaders}}),
+	})
+	spec.Add("PATCH", v1+"/uploads/:id", openapi.Operation{
+		Summary: "Append to a resumable upload", Tags: []string{"uploads"},
+		Description: "Bytes received before a dropped connection are kept. The request completing the upload queues its scan; " +
+			"if that fails, a PATCH with no body at the final offset retries it.",
+		Parameters: []openapi.Parameter{tusVersionHeader, header("Upload-Offset", "The upload's current offset.")},
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+			"application/offset+octet-stream": {Schema: openapi.Binary()}}},
+		Responses: tusErrors(map[string]*openapi.Response{
+			"204": {Description: "Stored.", Headers: offsetHeaders},
+			"409": jsonError("Upload-Offset is not the upload's offset, which is returned."),
+			"413": tooLarge, "415": unsupported,
+			"423": jsonError("Another request is appending to the upload."),
+			"429": tooMany, "503": unavailable,
+		}),
+	})
+	spec.Add("DELETE", v1+"/uploads/:id", openapi.Operation{
+		Summary: "Abandon a resumable upload", Tags: []string{"uploads"},
+		Parameters: []openapi.Parameter{tusVersionHeader},
+		Responses:  tusErrors(map[string]*openapi.Response{"204": {Description: "Deleted."}}),
+	})
+
+	verify := scanOp("Check card details against the DOPA register", jsonBody(verifyBody{}), service.Verification{})
+	verify.Parameters = nil
+	verify.Responses["502"] = jsonError("The DOPA service failed.")
+	spec.Add("POST", v1+"/verify", verify)
+
+	face := &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+		"multipart/form-data": {Schema: openapi.Object(map[string]*openapi.Schema{
+			"file":    openapi.Binary(),
+			"scan_id": {Type: "string", Description: "A stored scan whose card image is compared instead of file."},
+			"selfie":  openapi.Binary(),
+			"consent": {Type: "string", Description: "PDPA consent as JSON: purpose, version, timestamp and channel."},
+		}, "file", "scan_id", "consent")},
+	}}
+	faceMatch := scanOp("Compare a selfie with the portrait on a card", face, service.FaceMatch{})
+	faceMatch.Parameters = nil
+	faceMatch.Description = "Send the card as file or name a stored scan as scan_id. match is true when similarity reaches face.threshold. " +
+		"With a liveness provider configured, the selfie's liveness is returned and kept on the scan named by scan_id."
+	faceMatch.Responses["404"] = notFound
+	faceMatch.Responses["502"] = jsonError("The face comparison or liveness service failed, or a stored image could not be loaded.")
+	spec.Add("POST", v1+"/verify/face", faceMatch)
+
+	spec.Add("GET", v1+"/scans", negotiated(openapi.Operation{
+		Summary: "List stored scans, newest first", Tags: []string{"scans"},
+		Parameters: append(paging,
+			query("status", "Comma-separated statuses."),
+			query("citizen_id", "Scans of this citizen ID. Needs the reviewer role."),
+			query("id_hash", "Scans whose citizen ID has this keyed hash. Needs the reviewer role."),
+			query("include", "\"result\" returns the parsed fields instead of summaries."),
+		),
+		Responses: with(map[string]*openapi.Response{"200": ok(spec.Schema(scanPage{}))}, "400", "401", "403", "404", "500"),
+	}))
+	spec.Add("GET", v1+"/scans/search", negotiated(openapi.Operation{
+		Summary: "Find the scans of a person by citizen ID or name", Tags: []string{"scans"},
+		Description: "Give citizen_id for an exact match on its keyed hash, or name for a fuzzy match on the Thai or English name, " +
+			"best first with its score. Needs the reviewer role; every search is audited with the scans found.",
+		Parameters: []openapi.Parameter{paging[0], paging[2], paging[3],
+			query("citizen_id", "Citizen ID to match exactly."),
+			query("name", "Name to match, with or without title."),
+		},
+		Responses: with(map[string]*openapi.Response{"200": ok(spec.Schema(searchPage{}))}, "400", "401", "403", "404", "500"),
+	}))
+	spec.Add("GET", v1+"/scans/export", openapi.Operation{
+		Summary: "Stream stored scans as CSV, oldest first", Tags: []string{"scans"},
+		Description: "Same filters as GET /scans without paging. The columns, and those masked to their last four " +
+			"characters, are configured under export; callers without the reviewer role get personal data redacted.",
+		Parameters: append(paging[2:],
+			query("format", "Only csv."),
+			query("status", "Comma-separated statuses."),
+			query("citizen_id", "Scans of this citizen ID. Needs the reviewer role."),
+			query("id_hash", "Scans whose citizen ID has this keyed hash. Needs the reviewer role."),
+		),
+		Responses: with(map[string]*openapi.Response{"200": {Description: "OK",
+			Content: map[string]openapi.MediaType{"text/csv": {Schema: openapi.Binary()}}}}, "400", "401", "403", "404", "500"),
+	})
+	spec.Add("GET", v1+"/scans/:id", negotiated(openapi.Operation{
+		Summary: "Get a stored scan or a queued job", Tags: []string{"scans"},
+		Parameters: []openapi.Parameter{query("image", "\"true\" includes the stored images as base64; needs the reviewer role.")},
+		Responses: with(map[string]*openapi.Response{"200": ok(spec.Schema(storedScan{})),
+			"502": jsonError("A stored image could not be loaded.")}, "400", "401", "403", "404", "500"),
+	}))
+	spec.Add("PATCH", v1+"/scans/:id", negotiated(openapi.Operation{
+		Summary: "Correct parsed fields of a stored scan", Tags: []string{"scans"},
+		Description: "Replaces the text fields named in fields. The raw OCR output is kept and each change is added to " +
+			"corrections with the value OCR read, the editor's key and the time; needs the reviewer role.",
+		RequestBody: jsonBody(correctionBody{}),
+		Responses:   with(map[string]*openapi.Response{"200": ok(spec.Schema(storedScan{}))}, "400", "401", "403", "404", "500"),
+	}))
+	spec.Add("DELETE", v1+"/scans/:id", openapi.Operation{
+		Summary: "Soft-delete a stored scan", Tags: []string{"scans"},
+		Description: "The scan is kept for audits but no longer returned; POST /scans/{id}/purge erases it. Needs the admin role.",
+		Responses:   with(map[string]*openapi.Response{"204": {Description: "Deleted."}}, "401", "403", "404", "500"),
+	})
+	spec.Add("POST", v1+"/scans/:id/purge", openapi.Operation{
+		Summary: "Erase a scan for a data subject deletion request", Tags: []string{"scans"},
+		Description: "Irreversibly removes the record, its stored images and its cached OCR output, soft-deleted or not. " +
+			"Only the scan ID stays, in the audit log. Needs the admin role.",
+		Responses: with(map[string]*openapi.Response{"204": {Description: "Purged."}}, "401", "403", "404", "500"),
+	})
+	spec.Add("GET", v1+"/scans/:id/report.pdf", openapi.Operation{
+		Summary: "Render a stored scan as a PDF verification report", Tags: []string{"scans"},
+		Description: "Parsed fields, checks, confidence, consent and the operator's key, for filing. " +
+			"The card image is included for callers with the reviewer role; others get personal data masked.",
+		Responses: with(map[string]*openapi.Response{"200": {Description: "OK",
+			Content: map[string]openapi.MediaType{"application/pdf": {Schema: openapi.Binary()}}}}, "401", "403", "404", "500"),
+	})
+	spec.Add("GET", v1+"/scans/:id/events", openapi.Operation{
+		Summary: "Stream the progress of a queued scan", Tags: []string{"scans"},
+		Description: "Server-sent events named queued, preprocessing, ocr, parsing, then done or failed, each with the job " +
+			"as data; the stream ends after done or failed. A scan that is no longer queued gets one event with the stored scan.",
+		Responses: with(map[string]*openapi.Response{"200": {Description: "An event stream.",
+			Content: map[string]openapi.MediaType{"text/event-stream": {Schema: spec.Schema(jobs.Job{})}}}}, "401", "403", "404", "500"),
+	})
+	spec.Add("GET", v1+"/reviews", negotiated(openapi.Operation{
+		Summary: "List scans awaiting review, oldest first", Tags: []string{"reviews"},
+		Parameters: append(paging, query("citizen_id", ""), query("id_hash", "")),
+		Responses:  with(map[string]*openapi.Response{"200": ok(spec.Schema(reviewPage{}))}, "400", "401", "403", "404", "500"),
+	}))
+	spec.Add("GET", v1+"/reviews/:id", negotiated(openapi.Operation{
+		Summary: "Get a scan with its images and raw OCR output for review", Tags: []string{"reviews"},
+		Responses: with(map[string]*openapi.Response{"200": ok(spec.Schema(storedScan{})),
+			"502": jsonError("A stored image could not be loaded.")}, "401", "403", "404", "500"),
+	}))
+	spec.Add("POST", v1+"/reviews/:id", openapi.Operation{
+		Summary: "Approve, correct or reject a scan awaiting review", Tags: []string{"reviews"},
+		Description: "approve and correct mark the scan ok, correct after replacing the text fields named in fields; reject marks it rejected.",
+		RequestBody: jsonBody(reviewBody{}),
+		Responses: with(map[string]*openapi.Response{"200": ok(spec.Schema(storedScan{})),
+			"409": jsonError("The scan is not awaiting review.")}, "400", "401", "403", "404", "500"),
+	})
+	spec.Add("GET", v1+"/usage", openapi.Operation{
+		Summary: "Scan usage of the calling key", Tags: []string{"usage"}, Parameters: paging[2:],
+		Responses: with(map[string]*openapi.Response{"200": ok(spec.Schema(usageResponse{}))}, "400", "401", "403", "404", "500"),
+	})
+
+	admin := func(summary string, body *openapi.RequestBody, result any, params ...openapi.Parameter) openapi.Operation {
+		return openapi.Operation{Summary: summary, Tags: []string{"admin"}, Parameters: params, RequestBody: body,
+			Responses: with(map[string]*openapi.Response{"200": ok(spec.Schema(result))}, "400", "401", "403", "404", "500")}
+	}
+	spec.Add("GET", v1+"/admin/audit", admin("Query the audit log", nil, auditPage{},
+		append(paging, query("actor", ""), query("action", ""), query("scan_id", ""))...))
+	spec.Add("GET", v1+"/admin/usage", admin("Scan usage per tenant and key", nil, adminUsageResponse{},
+		append(paging[2:], query("tenant", ""), query("key_id", ""))...))
+	spec.Add("GET", v1+"/admin/tenants", admin("List stored tenants", nil, tenantList{}))
+	spec.Add("PUT", v1+"/admin/tenants/:id", admin("Create or update a tenant", jsonBody(tenantBody{}), storage.Tenant{}))
+	spec.Add("GET", v1+"/admin/keys", admin("List issued API keys", nil, keyList{}, query("tenant", "")))
+	create := admin("Issue an API key; the secret is only returned here", jsonBody(keyBody{}), issuedKey{})
+	create.Responses["201"] = &openapi.Response{Description: "Created.",
+		Headers: map[string]openapi.Header{"X-Key-ID": {Schema: openapi.String()}},
+		Content: map[string]openapi.MediaType{"application/json": {Schema: spec.Schema(issuedKey{})}}}
+	delete(create.Responses, "200")
+	spec.Add("POST", v1+"/admin/keys", create)
+	rotate := admin("Rotate a key's secret; the old one keeps working for grace", jsonBody(rotateBody{}), issuedKey{})
+	rotate.Responses["409"] = jsonError("The key is revoked.")
+	spec.Add("POST", v1+"/admin/keys/:id/rotate", rotate)
+	spec.Add("DELETE", v1+"/admin/keys/:id", admin("Revoke a key", nil, storage.APIKey{}))
+}
+
+// errorCatalog lists the error codes with their messages as markdown and
+// declares them on the spec.
+func errorCatalog(spec *openapi.Spec) string {
+	catalog := apierr.Codes()
+	codes := make([]string, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, string(code))
+	}
+	slices.Sort(codes)
+	spec.Enum(apierr.Code(""), codes)
+
+	var b strings.Builder
+	for _, code := range codes {
+		fmt.Fprintf(&b, "- `%s`: %s\n", code, catalog[apierr.Code(code)])
+	}
+	return b.String()
+}
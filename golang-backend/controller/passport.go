@@ -0,0 +1,11 @@
+package controller
+
+import (
+	"golang-backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PassportUploadHandler(c *gin.Context) {
+	handleUpload(c, service.ScanPassport, false)
+}
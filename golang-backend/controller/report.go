@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-backend/apierr"
+	"golang-backend/config"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"golang-backend/storage"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+var (
+	reportFont  = goregular.TTF
+	reportTitle = config.Default().Report.Title
+)
+
+// reportZone is Thai time, which has no daylight saving.
+var reportZone = time.FixedZone("ICT", 7*60*60)
+
+const reportTimeLayout = "2006-01-02 15:04:05 MST"
+
+// reportSkip leaves out result fields repeated elsewhere in the report or
+// of no use on paper.
+var reportSkip = map[string]bool{
+	"dates": true, "quality": true, "card_region": true, "confidence": true, "warnings": true,
+	"name_th_parts": true, "name_en_parts": true, "address_parts": true,
+}
+
+// LoadReportFont reads the TrueType font reports are set in. The built-in
+// font has no Thai glyphs; an empty path keeps it.
+func LoadReportFont(path string) error {
+	if path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	reportFont = b
+	return nil
+}
+
+// ScanReportHandler renders a stored scan as a PDF for filing: the parsed
+// fields, confidence, checks, consent and who made the scan, with the card
+// image for callers allowed to see personal data. Others get it masked as
+// for GET /scans/{id}.
+func ScanReportHandler(c *gin.Context) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return
+	}
+	ctx := c.Request.Context()
+	s, err := scanStore.Get(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) || err == nil && !ownsScan(c, s) {
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("load scan failed", "scan_id", c.Param("id"), "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+
+	var thumbnail []byte
+	if middleware.Can(c, middleware.PermPII) && imageStore != nil {
+		for _, name := range []string{"front_cropped", "front", "file"} {
+			if key, ok := s.Images[name]; ok {
+				if thumbnail, err = reportThumbnail(c, key); err != nil {
+					logging.FromContext(ctx).Warn("report image unavailable", "scan_id", s.ID, "key", key, "error", err)
+				}
+				break
+			}
+		}
+	}
+	maskScan(c, s)
+	p, _ := middleware.PrincipalFrom(c)
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, s, thumbnail, p.KeyID); err != nil {
+		logging.FromContext(ctx).Error("render report failed", "scan_id", s.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="scan-%s.pdf"`, s.ID))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// reportThumbnail loads a stored image and shrinks it to a JPEG at most
+// 600 pixels wide.
+func reportThumbnail(c *gin.Context, key string) ([]byte, error) {
+	b, err := imageStore.Get(c.Request.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	src, err := service.DecodeImage(b)
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > 600 {
+		w, h = 600, h*600/w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	var out bytes.Buffer
+	err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80})
+	return out.Bytes(), err
+}
+
+func writeReport(w io.Writer, s *storage.Scan, thumbnail []byte, generatedBy string) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(reportTitle+" "+s.ID, true)
+	pdf.AddUTF8FontFromBytes("report", "", reportFont)
+	pdf.SetMargins(18, 18, 18)
+	generated := time.Now().In(reportZone).Format(reportTimeLayout)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-14)
+		pdf.SetFont("report", "", 8)
+		pdf.SetTextColor(110, 110, 110)
+		footer := "Generated " + generated
+		if generatedBy != "" {
+			footer += " by " + generatedBy
+		}
+		pdf.CellFormat(0, 5, footer, "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("report", "", 16)
+	pdf.CellFormat(0, 9, reportTitle, "", 1, "L", false, 0, "")
+	pdf.SetFont("report", "", 9)
+	pdf.SetTextColor(90, 90, 90)
+	pdf.CellFormat(0, 5, "Scan "+s.ID, "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	if thumbnail != nil {
+		opts := fpdf.ImageOptions{ImageType: "JPG"}
+		pdf.RegisterImageOptionsReader("card", opts, bytes.NewReader(thumbnail))
+		pdf.ImageOptions("card", 18, pdf.GetY(), 70, 0, true, opts, 0, "")
+		pdf.Ln(4)
+	}
+
+	var result map[string]json.RawMessage
+	json.Unmarshal(s.Result, &result)
+	var details, checks [][2]string
+	if root, err := parseNode(json.NewDecoder(bytes.NewReader(s.Result))); err == nil && root.object {
+		for i, key := range root.keys {
+			if reportSkip[key] {
+				continue
+			}
+			root.children[i].flatten(key, func(key, value string) {
+				switch {
+				case value == "":
+				case strings.HasSuffix(key, "_valid") || key == "expired" || key == "days_until_expiry" || key == "lifetime" || key == "age_check_passed" || key == "status":
+					checks = append(checks, [2]string{key, value})
+				default:
+					details = append(details, [2]string{key, value})
+				}
+			})
+		}
+	}
+
+	status := s.Status
+	if s.Error != "" {
+		status += ": " + s.Error
+	}
+	reportSection(pdf, "Document", append([][2]string{{"document", s.Document}, {"scan status", status}}, details...))
+	reportSection(pdf, "Checks", checks)
+
+	confidence := s.Confidence
+	if len(confidence) == 0 {
+		confidence = result["confidence"]
+	}
+	var scores map[string]float64
+	if json.Unmarshal(confidence, &scores) == nil && len(scores) > 0 {
+		var rows [][2]string
+		if root, err := parseNode(json.NewDecoder(bytes.NewReader(confidence))); err == nil {
+			for _, key := range root.keys {
+				rows = append(rows, [2]string{key, fmt.Sprintf("%.0f%%", scores[key]*100)})
+			}
+		}
+		reportSection(pdf, "Confidence", rows)
+	}
+
+	record := [][2]string{
+		{"scanned at", s.CreatedAt.In(reportZone).Format(reportTimeLayout)},
+		{"operator", s.KeyID},
+		{"tenant", s.Tenant},
+		{"route", s.Route},
+		{"request id", s.RequestID},
+		{"image sha256", s.ImageSHA256},
+	}
+	if s.Consent != nil {
+		record = append(record,
+			[2]string{"consent purpose", s.Consent.Purpose},
+			[2]string{"consent version", s.Consent.Version},
+			[2]string{"consent given", s.Consent.Timestamp.In(reportZone).Format(reportTimeLayout)},
+			[2]string{"consent channel", s.Consent.Channel})
+	}
+	reportSection(pdf, "Record", record)
+
+	return pdf.Output(w)
+}
+
+// reportSection writes a heading and a two-column table of rows, leaving
+// out empty values.
+func reportSection(pdf *fpdf.Fpdf, title string, rows [][2]string) {
+	if len(rows) == 0 {
+		return
+	}
+	pdf.SetFont("report", "", 12)
+	pdf.SetFillColor(235, 238, 242)
+	pdf.CellFormat(0, 7, title, "", 1, "L", true, 0, "")
+	pdf.SetFont("report", "", 10)
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+		pdf.SetTextColor(90, 90, 90)
+		pdf.CellFormat(50, 6, strings.ReplaceAll(row[0], "_", " "), "", 0, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(0, 6, row[1], "", "L", false)
+	}
+	pdf.Ln(3)
+}
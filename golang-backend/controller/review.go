@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ReviewApprove = "approve"
+	ReviewCorrect = "correct"
+	ReviewReject  = "reject"
+)
+
+// reviewItem is a queued scan with what made it need review.
+type reviewItem struct {
+	scanSummary
+	ReviewFields      []string `json:"review_fields,omitempty"`
+	OverallConfidence *float64 `json:"overall_confidence,omitempty"`
+}
+
+// ListReviewsHandler pages through the needs_review scans, oldest first,
+// with the same filters as GET /scans apart from status.
+func ListReviewsHandler(c *gin.Context) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return
+	}
+	if !canFilterByPerson(c) {
+		return
+	}
+	f, err := scanFilter(c)
+	if err != nil {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(err, apierr.InvalidQuery))
+		return
+	}
+	f.Status, f.OldestFirst = []string{storage.StatusNeedsReview}, true
+	limit := f.Limit
+	f.Limit++
+	scans, err := scanStore.List(c.Request.Context(), f)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("list reviews failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+
+	resp := gin.H{"limit": limit, "offset": f.Offset}
+	if len(scans) > limit {
+		scans = scans[:limit]
+		resp["next_offset"] = f.Offset + limit
+	}
+	items := make([]reviewItem, len(scans))
+	for i, summary := range summarize(scans) {
+		var result struct {
+			ReviewFields      []string `json:"review_fields"`
+			OverallConfidence *float64 `json:"overall_confidence"`
+		}
+		json.Unmarshal(scans[i].Result, &result)
+		items[i] = reviewItem{summary, result.ReviewFields, result.OverallConfidence}
+	}
+	resp["scans"] = items
+	respond(c, http.StatusOK, resp)
+}
+
+// GetReviewHandler returns a scan with its stored images, parsed result and
+// raw OCR output together, for a reviewer to compare. Callers without
+// PermPII get it masked and without images.
+func GetReviewHandler(c *gin.Context) {
+	s, ok := loadScan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	maskScan(c, s)
+	resp := storedScan{Scan: s}
+	if len(s.Images) > 0 && middleware.Can(c, middleware.PermPII) {
+		if resp.ImageData, ok = scanImageData(c, s); !ok {
+			return
+		}
+	}
+	respond(c, http.StatusOK, resp)
+}
+
+type reviewBody struct {
+	Decision string `json:"decision" binding:"required,oneof=approve correct reject"`
+	// Fields replaces result fields by name when correcting.
+	Fields map[string]string `json:"fields"`
+	Note   string            `json:"note" binding:"max=1000"`
+}
+
+// ReviewScanHandler records a reviewer's decision on a needs_review scan.
+// Approving or correcting it marks it ok; correcting first replaces the
+// named result fields as PATCH /scans/{id} does. Rejecting marks it
+// rejected.
+func ReviewScanHandler(c *gin.Context) {
+	var body reviewBody
+	if !bindJSON(c, &body) {
+		return
+	}
+	if body.Decision == ReviewCorrect && len(body.Fields) == 0 {
+		validationFailed(c, []FieldError{{Field: "fields", Rule: "required"}})
+		return
+	}
+	s, ok := loadScan(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if s.Status != storage.StatusNeedsReview {
+		apierr.Abort(c, http.StatusConflict, apierr.ReviewNotPending, gin.H{"status": s.Status})
+		return
+	}
+
+	result := resultFields(s)
+	if body.Decision == ReviewCorrect {
+		if invalid := correctFields(c, s, result, body.Fields, body.Note); len(invalid) > 0 {
+			validationFailed(c, invalid)
+			return
+		}
+	}
+
+	s.Status = storage.StatusOK
+	if body.Decision == ReviewReject {
+		s.Status = storage.StatusRejected
+	}
+	result["status"], _ = json.Marshal(s.Status)
+	s.Result, _ = json.Marshal(result)
+	p, _ := middleware.PrincipalFrom(c)
+	s.Review = &storage.Review{Decision: body.Decision, Reviewer: p.KeyID, Note: body.Note, ReviewedAt: time.Now().UTC()}
+
+	if err := scanStore.Save(c.Request.Context(), s); err != nil {
+		logging.FromContext(c.Request.Context()).Error("save review failed", "scan_id", s.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	respond(c, http.StatusOK, storedScan{Scan: s})
+}
+
+// loadScan returns the stored scan id when the caller owns it. It writes
+// the error response itself when it returns false.
+func loadScan(c *gin.Context, id string) (*storage.Scan, bool) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return nil, false
+	}
+	s, err := scanStore.Get(c.Request.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) || err == nil && !ownsScan(c, s) {
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return nil, false
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("load scan failed", "scan_id", id, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return nil, false
+	}
+	return s, true
+}
@@ -0,0 +1,371 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/jobs"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/storage"
+	"golang-backend/webhook"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var scanJobs *jobs.Queue
+
+func SetJobQueue(q *jobs.Queue) {
+	scanJobs = q
+}
+
+// CreateScanHandler accepts an upload and queues it for background scanning,
+// returning the job ID immediately.
+func CreateScanHandler(c *gin.Context) {
+	limitBody(c, maxUploadBytes)
+	image, ok := formImage(c, "file")
+	if !ok {
+		return
+	}
+	defer image.Close()
+	if !formConsent(c) {
+		return
+	}
+
+	b, err := io.ReadAll(image)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.FileUnreadable, nil)
+		return
+	}
+
+	callbackURL, ok := callbackFor(c, c.PostForm("callback_url"))
+	if !ok {
+		return
+	}
+	job, ok := submitScan(c, b, callbackURL)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// callbackFor validates the callback URL sent with a scan, defaulting to
+// the caller's key webhook. It writes the error response itself when it
+// returns false.
+func callbackFor(c *gin.Context, callbackURL string) (string, bool) {
+	if callbackURL == "" {
+		if p, ok := middleware.PrincipalFrom(c); ok {
+			callbackURL = webhook.URLForKey(p.KeyID)
+		}
+	} else if err := webhook.ValidateURL(callbackURL); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidCallbackURL, gin.H{"reason": err.Error()})
+		return "", false
+	}
+	return callbackURL, true
+}
+
+// submitScan queues image and points Location at the job. It writes the
+// error response itself when it returns false.
+func submitScan(c *gin.Context, image []byte, callbackURL string) (jobs.Job, bool) {
+	job, err := scanJobs.Submit(c.Request.Context(), image, callbackURL, newScanRecord(c), middleware.Can(c, middleware.PermReadAll))
+	if err != nil {
+		if errors.Is(err, jobs.ErrQueueFull) {
+			depth, capacity := scanJobs.Depth()
+			respondQueueFull(c, depth, capacity)
+			return job, false
+		}
+		if errors.Is(err, jobs.ErrShuttingDown) {
+			apierr.Abort(c, http.StatusServiceUnavailable, apierr.ShuttingDown, nil)
+			return job, false
+		}
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return job, false
+	}
+
+	if scanStore != nil {
+		setScanID(c, job.ID)
+	}
+	c.Header("Location", middleware.VersionPath(c, "/scans/"+job.ID))
+	return job, true
+}
+
+type storedScan struct {
+	*storage.Scan
+	// ImageData holds the stored images as base64 when ?image=true.
+	ImageData map[string]string `json:"image_data,omitempty"`
+}
+
+// GetScanHandler returns a persisted scan, falling back to the job queue for
+// async scans that have not finished yet or when storage is disabled.
+func GetScanHandler(c *gin.Context) {
+	id := c.Param("id")
+	if scanStore != nil {
+		s, err := scanStore.Get(c.Request.Context(), id)
+		switch {
+		case err == nil && ownsScan(c, s):
+			respondStoredScan(c, s)
+			return
+		case err == nil:
+			apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+			return
+		case err != nil && !errors.Is(err, storage.ErrNotFound):
+			logging.FromContext(c.Request.Context()).Error("load scan failed", "scan_id", id, "error", err)
+			apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+			return
+		}
+	}
+
+	job, err := scanJobs.Get(id)
+	if err != nil || !ownsJob(c, job) {
+		apierr.Abort(c, http.StatusNotFound, apierr.ScanNotFound, nil)
+		return
+	}
+	respond(c, http.StatusOK, maskJob(c, job))
+}
+
+// ownsJob is ownsScan for a scan still in the job queue.
+func ownsJob(c *gin.Context, j jobs.Job) bool {
+	return ownsScan(c, &storage.Scan{KeyID: j.KeyID, Tenant: j.Tenant})
+}
+
+// maskedJob is a job with its result redacted by maskJob.
+type maskedJob struct {
+	jobs.Job
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// maskJob redacts the result of j as maskScan does, for callers without
+// PermPII.
+func maskJob(c *gin.Context, j jobs.Job) any {
+	if j.Result == nil || middleware.Can(c, middleware.PermPII) {
+		return j
+	}
+	b, _ := json.Marshal(j.Result)
+	return maskedJob{Job: j, Result: logging.RedactJSON(b)}
+}
+
+// ownsScan hides soft-deleted scans, scans of other tenants, and scans
+// made with another API key from callers that may only read their own.
+func ownsScan(c *gin.Context, s *storage.Scan) bool {
+	if s.DeletedAt != nil {
+		return false
+	}
+	p, _ := middleware.PrincipalFrom(c)
+	if p.Tenant != "" && s.Tenant != p.Tenant {
+		return false
+	}
+	if middleware.Can(c, middleware.PermReadAll) {
+		return true
+	}
+	return s.KeyID == "" || s.KeyID == p.KeyID
+}
+
+// maskScan redacts personal data in s for callers without PermPII.
+func maskScan(c *gin.Context, s *storage.Scan) {
+	if middleware.Can(c, middleware.PermPII) {
+		return
+	}
+	s.Result = logging.RedactJSON(s.Result)
+	s.RawOCR = logging.RedactJSON(s.RawOCR)
+	for i := range s.Corrections {
+		fix := &s.Corrections[i]
+		fix.Original, fix.Value = redactField(fix.Field, fix.Original), redactField(fix.Field, fix.Value)
+	}
+}
+
+// redactField masks value as RedactJSON would in a result holding it
+// under name.
+func redactField(name, value string) string {
+	var masked map[string]string
+	json.Unmarshal(logging.RedactJSON([]byte(jsonText(map[string]string{name: value}))), &masked)
+	return masked[name]
+}
+
+func respondStoredScan(c *gin.Context, s *storage.Scan) {
+	maskScan(c, s)
+	resp := storedScan{Scan: s}
+	if c.Query("image") == "true" && len(s.Images) > 0 {
+		if !middleware.Can(c, middleware.PermPII) {
+			apierr.Abort(c, http.StatusForbidden, apierr.PermissionDenied, gin.H{"permission": middleware.PermPII})
+			return
+		}
+		var ok bool
+		if resp.ImageData, ok = scanImageData(c, s); !ok {
+			return
+		}
+	}
+	respond(c, http.StatusOK, resp)
+}
+
+// scanImageData loads s's stored images as base64. It writes the error
+// response itself when it returns false.
+func scanImageData(c *gin.Context, s *storage.Scan) (map[string]string, bool) {
+	if imageStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.ImageStoreOff, nil)
+		return nil, false
+	}
+	data := make(map[string]string, len(s.Images))
+	for name, key := range s.Images {
+		b, err := imageStore.Get(c.Request.Context(), key)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("load scan image failed", "scan_id", s.ID, "key", key, "error", err)
+			apierr.Abort(c, http.StatusBadGateway, apierr.ImageLoadError, nil)
+			return nil, false
+		}
+		data[name] = base64.StdEncoding.EncodeToString(b)
+	}
+	return data, true
+}
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// scanSummary is a stored scan without its parsed fields or OCR output.
+type scanSummary struct {
+	ID          string           `json:"id"`
+	Route       string           `json:"route"`
+	Document    string           `json:"document"`
+	Status      string           `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	KeyID       string           `json:"key_id,omitempty"`
+	Tenant      string           `json:"tenant,omitempty"`
+	ImageSHA256 string           `json:"image_sha256,omitempty"`
+	IDHash      string           `json:"id_hash,omitempty"`
+	Consent     *storage.Consent `json:"consent,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// ListScansHandler pages through stored scans, newest first. Filters:
+// from and to (RFC 3339 or YYYY-MM-DD, to exclusive), status (comma
+// separated), and citizen_id or id_hash, which need PermSearch. Summaries
+// are returned unless include=result asks for the parsed fields as well.
+func ListScansHandler(c *gin.Context) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return
+	}
+	if !canFilterByPerson(c) {
+		return
+	}
+	f, err := scanFilter(c)
+	if err != nil {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(err, apierr.InvalidQuery))
+		return
+	}
+	limit := f.Limit
+	f.Limit++
+	scans, err := scanStore.List(c.Request.Context(), f)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("list scans failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+
+	resp := gin.H{"limit": limit, "offset": f.Offset}
+	if len(scans) > limit {
+		scans = scans[:limit]
+		resp["next_offset"] = f.Offset + limit
+	}
+	if c.Query("include") == "result" {
+		for _, s := range scans {
+			maskScan(c, s)
+		}
+		resp["scans"] = scans
+	} else {
+		resp["scans"] = summarize(scans)
+	}
+	respond(c, http.StatusOK, resp)
+}
+
+func summarize(scans []*storage.Scan) []scanSummary {
+	summaries := make([]scanSummary, len(scans))
+	for i, s := range scans {
+		summaries[i] = scanSummary{
+			ID: s.ID, Route: s.Route, Document: s.Document, Status: s.Status, Error: s.Error, KeyID: s.KeyID, Tenant: s.Tenant,
+			ImageSHA256: s.ImageSHA256, IDHash: s.IDHash, Consent: s.Consent, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt,
+		}
+	}
+	return summaries
+}
+
+// canFilterByPerson rejects citizen_id and id_hash filters from callers
+// without PermSearch, which would otherwise look people up through the
+// listing. It writes the error response itself when it returns false.
+func canFilterByPerson(c *gin.Context) bool {
+	if (c.Query("citizen_id") != "" || c.Query("id_hash") != "") && !middleware.Can(c, middleware.PermSearch) {
+		apierr.Abort(c, http.StatusForbidden, apierr.PermissionDenied, gin.H{"permission": middleware.PermSearch})
+		return false
+	}
+	return true
+}
+
+func scanFilter(c *gin.Context) (storage.Filter, error) {
+	f := storage.Filter{IDHash: c.Query("id_hash")}
+	var err error
+	if f.Limit, f.Offset, err = page(c); err != nil {
+		return f, err
+	}
+	if f.From, err = queryTime(c, "from"); err != nil {
+		return f, err
+	}
+	if f.To, err = queryTime(c, "to"); err != nil {
+		return f, err
+	}
+	if s := c.Query("status"); s != "" {
+		f.Status = strings.Split(s, ",")
+	}
+	if id := c.Query("citizen_id"); id != "" {
+		f.IDHash = storage.HashCitizenID(id)
+	}
+	if p, ok := middleware.PrincipalFrom(c); ok {
+		f.Tenant = p.Tenant
+		if !p.Can(middleware.PermReadAll) {
+			f.KeyID = p.KeyID
+		}
+	}
+	return f, nil
+}
+
+// page reads the limit and offset query parameters.
+func page(c *gin.Context) (limit, offset int, err error) {
+	limit = defaultPageSize
+	if s := c.Query("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 {
+			return 0, 0, invalidQuery("limit", "a positive integer")
+		}
+		limit = min(limit, maxPageSize)
+	}
+	if s := c.Query("offset"); s != "" {
+		if offset, err = strconv.Atoi(s); err != nil || offset < 0 {
+			return 0, 0, invalidQuery("offset", "a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
+
+func queryTime(c *gin.Context, name string) (time.Time, error) {
+	s := c.Query(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.UTC); err == nil {
+		return t, nil
+	}
+	return time.Time{}, invalidQuery(name, "RFC 3339 or YYYY-MM-DD")
+}
+
+func invalidQuery(parameter, expected string) error {
+	return apierr.New(apierr.InvalidQuery, map[string]any{"parameter": parameter, "expected": expected})
+}
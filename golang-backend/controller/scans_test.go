@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang-backend/middleware"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaskScan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newScan := func() *storage.Scan {
+		return &storage.Scan{
+			Result: []byte(`{"id_number":"1101700230708","id_valid":true,"confidence":{"id_number":0.9}}`),
+			RawOCR: []byte(`{"name_th":"นาย สมชาย ใจดี","side":"front"}`),
+			Corrections: []storage.Correction{
+				{Field: "id_number", Original: "1101700230700", Value: "1101700230708"},
+				{Field: "gender", Original: "F", Value: "M"},
+			},
+		}
+	}
+	tests := []struct {
+		name   string
+		roles  []string
+		masked bool
+	}{
+		{"scanner", []string{middleware.RoleScanner}, true},
+		{"admin", []string{middleware.RoleAdmin}, true},
+		{"reviewer", []string{middleware.RoleReviewer}, false},
+		{"scanner and reviewer", []string{middleware.RoleScanner, middleware.RoleReviewer}, false},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("principal", middleware.Principal{KeyID: "k1", Tenant: "t1", Roles: tt.roles})
+		s := newScan()
+		maskScan(c, s)
+
+		want := newScan()
+		if tt.masked {
+			want.Result = []byte(`{"id_number":"[REDACTED]","id_valid":true,"confidence":{"id_number":0.9}}`)
+			want.RawOCR = []byte(`{"name_th":"[REDACTED]","side":"front"}`)
+			want.Corrections[0].Original, want.Corrections[0].Value = "[REDACTED]", "[REDACTED]"
+		}
+		if string(s.Result) != string(want.Result) || string(s.RawOCR) != string(want.RawOCR) {
+			t.Errorf("%s: result %s raw %s, want %s raw %s", tt.name, s.Result, s.RawOCR, want.Result, want.RawOCR)
+		}
+		for i, fix := range s.Corrections {
+			if w := want.Corrections[i]; fix.Original != w.Original || fix.Value != w.Value {
+				t.Errorf("%s: correction %s = %q -> %q, want %q -> %q", tt.name, fix.Field, fix.Original, fix.Value, w.Original, w.Value)
+			}
+		}
+	}
+}
+
+func TestRedactField(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"id_number", "1101700230708", "[REDACTED]"},
+		{"address", "99 ม.1 ต.บางพูด", "[REDACTED]"},
+		{"birth_date", "28 พ.ย. 2515", "[REDACTED]"},
+		{"gender", "M", "M"},
+		{"note", "called about 1101700230708", "called about [REDACTED]"},
+		{"house_code", "", ""},
+	}
+	for _, tt := range tests {
+		if got := redactField(tt.name, tt.value); got != tt.want {
+			t.Errorf("redactField(%q, %q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCanFilterByPerson(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query string
+		roles []string
+		ok    bool
+	}{
+		{"status=done", []string{middleware.RoleAdmin}, true},
+		{"citizen_id=1101700230708", []string{middleware.RoleAdmin}, false},
+		{"id_hash=abc", []string{middleware.RoleAdmin}, false},
+		{"id_hash=abc", []string{middleware.RoleScanner}, false},
+		{"citizen_id=1101700230708", []string{middleware.RoleReviewer}, true},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/scans?"+tt.query, nil)
+		c.Set("principal", middleware.Principal{KeyID: "k1", Tenant: "t1", Roles: tt.roles})
+		if ok := canFilterByPerson(c); ok != tt.ok || !ok && w.Code != http.StatusForbidden {
+			t.Errorf("%s as %v: allowed %t (status %d), want %t", tt.query, tt.roles, ok, w.Code, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// nameMatchThreshold is the least service.NameSimilarity counted as a
+// match, about one wrong character in five.
+const nameMatchThreshold = 0.8
+
+// searchHit is a scan found by SearchScansHandler. Score is how closely
+// its name matched, and is left out for citizen ID searches.
+type searchHit struct {
+	scanSummary
+	Score float64 `json:"score,omitempty"`
+}
+
+// SearchScansHandler answers whether a person was ever scanned: citizen_id
+// finds the scans whose ID hash matches exactly, while name compares the
+// Thai and English names of every scan in range, best match first. The
+// hash searched for, or that a name was, and the scans found are recorded
+// in the audit log.
+func SearchScansHandler(c *gin.Context) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return
+	}
+	citizenID, name := c.Query("citizen_id"), strings.TrimSpace(c.Query("name"))
+	if (citizenID == "") == (name == "") {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(invalidQuery("citizen_id", "a citizen ID, or name instead"), apierr.InvalidQuery))
+		return
+	}
+	f, err := scanFilter(c)
+	if err != nil {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(err, apierr.InvalidQuery))
+		return
+	}
+	ctx := c.Request.Context()
+
+	hits := []searchHit{}
+	detail := "id_hash=" + f.IDHash
+	if citizenID != "" {
+		scans, err := scanStore.List(ctx, f)
+		if err != nil {
+			logging.FromContext(ctx).Error("search scans failed", "error", err)
+			apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+			return
+		}
+		for _, summary := range summarize(scans) {
+			hits = append(hits, searchHit{scanSummary: summary})
+		}
+	} else {
+		detail = "name"
+		err = scanStore.Walk(ctx, f, func(s *storage.Scan) error {
+			if score := nameScore(s, name); score >= nameMatchThreshold {
+				hits = append(hits, searchHit{summarize([]*storage.Scan{s})[0], score})
+			}
+			return nil
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("search scans failed", "error", err)
+			apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+			return
+		}
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+		hits = hits[:min(len(hits), f.Limit)]
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	middleware.SetAuditDetail(c, detail+" found="+strings.Join(ids, ","))
+	respond(c, http.StatusOK, gin.H{"scans": hits, "limit": f.Limit})
+}
+
+// nameScore is the closest match between name and the names in s's result.
+func nameScore(s *storage.Scan, name string) float64 {
+	var result map[string]json.RawMessage
+	json.Unmarshal(s.Result, &result)
+	var best float64
+	for _, key := range []string{"name_th", "name_en", "name"} {
+		var text string
+		if json.Unmarshal(result[key], &text) == nil {
+			best = max(best, service.NameSimilarity(name, text))
+		}
+	}
+	return best
+}
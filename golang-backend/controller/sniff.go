@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"fmt"
+	"golang-backend/service"
+	"io"
+	"mime/multipart"
+	"sort"
+)
+
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+func allowedTypeList() []string {
+	var list []string
+	for t, ok := range allowedImageTypes {
+		if ok {
+			list = append(list, t)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+type unsupportedMediaError struct {
+	contentType string
+}
+
+func (e *unsupportedMediaError) Error() string {
+	return fmt.Sprintf("unsupported media type %q", e.contentType)
+}
+
+// openImage opens an uploaded file and checks that its content is an allowed
+// image type.
+func openImage(fh *multipart.FileHeader) (multipart.File, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := sniffContentType(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !allowedImageTypes[contentType] {
+		f.Close()
+		return nil, &unsupportedMediaError{contentType: contentType}
+	}
+	return f, nil
+}
+
+// checkImageBytes is the in-memory counterpart of openImage.
+func checkImageBytes(b []byte) error {
+	if contentType := service.DetectContentType(b); !allowedImageTypes[contentType] {
+		return &unsupportedMediaError{contentType: contentType}
+	}
+	return nil
+}
+
+// sniffContentType detects the type of f from its magic bytes and rewinds it.
+func sniffContentType(f multipart.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return service.DetectContentType(head[:n]), nil
+}
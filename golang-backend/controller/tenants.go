@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/config"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/storage"
+	"golang-backend/webhook"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	configTenants   []config.TenantConfig
+	defaultKeyRoles = config.Default().Auth.APIKeyRoles
+)
+
+type tenantBody struct {
+	Name         *string `json:"name"`
+	DailyQuota   *int64  `json:"daily_quota" binding:"omitempty,min=0"`
+	MonthlyQuota *int64  `json:"monthly_quota" binding:"omitempty,min=0"`
+	Disabled     *bool   `json:"disabled"`
+}
+
+// ListTenantsHandler returns the tenants stored through the admin API.
+func ListTenantsHandler(c *gin.Context) {
+	if !adminStore(c) {
+		return
+	}
+	tenants, err := scanStore.ListTenants(c.Request.Context())
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("list tenants failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tenants": append([]*storage.Tenant{}, tenants...)})
+}
+
+// PutTenantHandler creates or updates a tenant. Omitted fields keep their
+// stored value, or the tenant's config entry for a new tenant.
+func PutTenantHandler(c *gin.Context) {
+	if !adminStore(c) {
+		return
+	}
+	var body tenantBody
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	t, err := scanStore.GetTenant(ctx, id)
+	if errors.Is(err, storage.ErrTenantNotFound) {
+		t = &storage.Tenant{ID: id}
+		if i := slices.IndexFunc(configTenants, func(t config.TenantConfig) bool { return t.ID == id }); i >= 0 {
+			t.DailyQuota, t.MonthlyQuota = configTenants[i].DailyQuota, configTenants[i].MonthlyQuota
+		}
+	} else if err != nil {
+		logging.FromContext(ctx).Error("load tenant failed", "tenant", id, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	if body.Name != nil {
+		t.Name = *body.Name
+	}
+	if body.DailyQuota != nil {
+		t.DailyQuota = *body.DailyQuota
+	}
+	if body.MonthlyQuota != nil {
+		t.MonthlyQuota = *body.MonthlyQuota
+	}
+	if body.Disabled != nil {
+		t.Disabled = *body.Disabled
+	}
+	if err = scanStore.SaveTenant(ctx, t); err != nil {
+		logging.FromContext(ctx).Error("save tenant failed", "tenant", id, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+type keyBody struct {
+	Tenant string   `json:"tenant"`
+	Name   string   `json:"name"`
+	Roles  []string `json:"roles"`
+}
+
+// issuedKey carries a new secret, which is shown only once. A new key also
+// carries how its tenant's webhooks are signed.
+type issuedKey struct {
+	*storage.APIKey
+	Secret  string           `json:"secret"`
+	Webhook *webhook.Signing `json:"webhook,omitempty"`
+}
+
+// ListKeysHandler returns the keys issued through the admin API, optionally
+// for one tenant.
+func ListKeysHandler(c *gin.Context) {
+	if !adminStore(c) {
+		return
+	}
+	keys, err := scanStore.ListKeys(c.Request.Context(), c.Query("tenant"))
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("list api keys failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": append([]*storage.APIKey{}, keys...)})
+}
+
+// CreateKeyHandler issues a key. Roles default to auth.api_key_roles.
+func CreateKeyHandler(c *gin.Context) {
+	if !adminStore(c) {
+		return
+	}
+	var body keyBody
+	if !bindJSON(c, &body) {
+		return
+	}
+	if len(body.Roles) == 0 {
+		body.Roles = defaultKeyRoles
+	}
+	for _, r := range body.Roles {
+		if !middleware.KnownRole(r) {
+			apierr.Abort(c, http.StatusBadRequest, apierr.UnknownRole, gin.H{"role": r})
+			return
+		}
+	}
+	if body.Tenant != "" && slices.Contains(body.Roles, middleware.RoleAdmin) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.AdminKeyTenant, nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var webhookSecret string
+	if body.Tenant != "" {
+		var err error
+		if webhookSecret, err = tenantWebhookSecret(ctx, body.Tenant); errors.Is(err, storage.ErrTenantNotFound) {
+			apierr.Abort(c, http.StatusBadRequest, apierr.UnknownTenant, gin.H{"tenant": body.Tenant})
+			return
+		} else if err != nil {
+			logging.FromContext(ctx).Error("load tenant failed", "tenant", body.Tenant, "error", err)
+			apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+			return
+		}
+	}
+
+	secret, hash := storage.NewKeySecret()
+	k := &storage.APIKey{Tenant: body.Tenant, Name: body.Name, Roles: body.Roles, Hash: hash}
+	if err := scanStore.SaveKey(ctx, k); err != nil {
+		logging.FromContext(ctx).Error("save api key failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.Header("X-Key-ID", k.ID)
+	signing := webhook.Describe(webhookSecret)
+	c.JSON(http.StatusCreated, issuedKey{APIKey: k, Secret: secret, Webhook: &signing})
+}
+
+// tenantWebhookSecret returns the secret signing tenant id's webhooks: the
+// stored one, else the one in its config entry, else a new one stored with
+// the tenant.
+func tenantWebhookSecret(ctx context.Context, id string) (string, error) {
+	i := slices.IndexFunc(configTenants, func(t config.TenantConfig) bool { return t.ID == id })
+	t, err := scanStore.GetTenant(ctx, id)
+	switch {
+	case errors.Is(err, storage.ErrTenantNotFound) && i >= 0:
+		if configTenants[i].WebhookSecret != "" {
+			return configTenants[i].WebhookSecret, nil
+		}
+		t = &storage.Tenant{ID: id, DailyQuota: configTenants[i].DailyQuota, MonthlyQuota: configTenants[i].MonthlyQuota}
+	case err != nil:
+		return "", err
+	case t.WebhookSecret != "":
+		return t.WebhookSecret, nil
+	case i >= 0 && configTenants[i].WebhookSecret != "":
+		return configTenants[i].WebhookSecret, nil
+	}
+	t.WebhookSecret = storage.NewWebhookSecret()
+	return t.WebhookSecret, scanStore.SaveTenant(ctx, t)
+}
+
+// RotateKeyHandler replaces a key's secret. The old secret keeps working
+// for the optional grace duration, such as "24h".
+func RotateKeyHandler(c *gin.Context) {
+	var body struct {
+		Grace string `json:"grace"`
+	}
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &body) {
+			return
+		}
+	}
+	var grace time.Duration
+	if body.Grace != "" {
+		var err error
+		if grace, err = time.ParseDuration(body.Grace); err != nil || grace < 0 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.InvalidGrace, nil)
+			return
+		}
+	}
+	k, ok := loadKey(c)
+	if !ok {
+		return
+	}
+	if k.RevokedAt != nil {
+		apierr.Abort(c, http.StatusConflict, apierr.KeyRevoked, nil)
+		return
+	}
+
+	secret, hash := storage.NewKeySecret()
+	now := time.Now().UTC()
+	k.PreviousHash, k.PreviousExpiresAt = "", nil
+	if grace > 0 {
+		expires := now.Add(grace)
+		k.PreviousHash, k.PreviousExpiresAt = k.Hash, &expires
+	}
+	k.Hash, k.RotatedAt = hash, &now
+	if err := scanStore.SaveKey(c.Request.Context(), k); err != nil {
+		logging.FromContext(c.Request.Context()).Error("save api key failed", "key_id", k.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.JSON(http.StatusOK, issuedKey{APIKey: k, Secret: secret})
+}
+
+// RevokeKeyHandler revokes a key and any previous secret still in its grace
+// period.
+func RevokeKeyHandler(c *gin.Context) {
+	k, ok := loadKey(c)
+	if !ok {
+		return
+	}
+	if k.RevokedAt == nil {
+		now := time.Now().UTC()
+		k.RevokedAt = &now
+		if err := scanStore.SaveKey(c.Request.Context(), k); err != nil {
+			logging.FromContext(c.Request.Context()).Error("save api key failed", "key_id", k.ID, "error", err)
+			apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, k)
+}
+
+func loadKey(c *gin.Context) (*storage.APIKey, bool) {
+	if !adminStore(c) {
+		return nil, false
+	}
+	k, err := scanStore.GetKey(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		apierr.Abort(c, http.StatusNotFound, apierr.KeyNotFound, nil)
+		return nil, false
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("load api key failed", "key_id", c.Param("id"), "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return nil, false
+	}
+	return k, true
+}
+
+func adminStore(c *gin.Context) bool {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return false
+	}
+	return true
+}
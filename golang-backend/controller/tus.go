@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/metrics"
+	"golang-backend/middleware"
+	"golang-backend/tus"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tusVersion = "1.0.0"
+
+var uploads *tus.Store
+
+func SetUploadStore(s *tus.Store) {
+	uploads = s
+}
+
+// tusRequest sets the header every tus response carries and rejects other
+// protocol versions. Only the request completing an upload counts as a
+// scan. It writes the error response itself when it returns false.
+func tusRequest(c *gin.Context) bool {
+	c.Header("Tus-Resumable", tusVersion)
+	middleware.SetScanCount(c, 0)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		apierr.Abort(c, http.StatusPreconditionFailed, apierr.TusVersion, gin.H{"supported": tusVersion})
+		return false
+	}
+	return true
+}
+
+// TusOptionsHandler advertises the tus version and extensions supported.
+func TusOptionsHandler(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", "creation,expiration,termination")
+	c.Header("Tus-Max-Size", strconv.FormatInt(maxUploadBytes, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUploadHandler starts a resumable upload of Upload-Length bytes.
+// Upload-Metadata may carry consent and callback_url, as sent to POST
+// /scans; the scan is queued when the last byte arrives.
+func CreateUploadHandler(c *gin.Context) {
+	if !tusRequest(c) {
+		return
+	}
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		validationFailed(c, []FieldError{{Field: "Upload-Length", Rule: "min", Param: "1"}})
+		return
+	}
+	if length > maxUploadBytes {
+		c.Header("Tus-Max-Size", strconv.FormatInt(maxUploadBytes, 10))
+		respondTooLarge(c)
+		return
+	}
+	meta, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		validationFailed(c, []FieldError{{Field: "Upload-Metadata", Rule: "format"}})
+		return
+	}
+	if !rawConsent(c, meta["consent"]) {
+		return
+	}
+	if _, ok := callbackFor(c, meta["callback_url"]); !ok {
+		return
+	}
+
+	u := &tus.Upload{Length: length, Metadata: meta}
+	if p, ok := middleware.PrincipalFrom(c); ok {
+		u.KeyID, u.Tenant = p.KeyID, p.Tenant
+	}
+	if err := uploads.Create(u); err != nil {
+		logging.FromContext(c.Request.Context()).Error("create upload failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.Header("Location", middleware.VersionPath(c, "/uploads/"+u.ID))
+	uploadHeaders(c, u)
+	c.Status(http.StatusCreated)
+}
+
+// UploadOffsetHandler reports how many bytes of an upload have arrived, so
+// a client can resume after them.
+func UploadOffsetHandler(c *gin.Context) {
+	if !tusRequest(c) {
+		return
+	}
+	u, ok := ownUpload(c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	uploadHeaders(c, u)
+	c.Status(http.StatusOK)
+}
+
+// PatchUploadHandler appends the body at Upload-Offset. Bytes received
+// before a dropped connection are kept. The request that completes the
+// upload queues its scan and points Location at it.
+func PatchUploadHandler(c *gin.Context) {
+	if !tusRequest(c) {
+		return
+	}
+	if ct := c.ContentType(); ct != "application/offset+octet-stream" {
+		apierr.Abort(c, http.StatusUnsupportedMediaType, apierr.UnsupportedMediaType, gin.H{
+			"content_type": ct,
+			"allowed":      []string{"application/offset+octet-stream"},
+		})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		validationFailed(c, []FieldError{{Field: "Upload-Offset", Rule: "min", Param: "0"}})
+		return
+	}
+	u, ok := ownUpload(c)
+	if !ok {
+		return
+	}
+
+	u, err = uploads.Append(u.ID, offset, c.Request.Body)
+	switch {
+	case errors.Is(err, tus.ErrNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.UploadNotFound, nil)
+		return
+	case errors.Is(err, tus.ErrLocked):
+		apierr.Abort(c, http.StatusLocked, apierr.UploadLocked, nil)
+		return
+	case errors.Is(err, tus.ErrOffset):
+		apierr.Abort(c, http.StatusConflict, apierr.UploadOffset, gin.H{"offset": u.Offset})
+		return
+	case errors.Is(err, tus.ErrTooLong):
+		uploadHeaders(c, u)
+		apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.FileTooLarge, gin.H{"max_bytes": u.Length})
+		return
+	case err != nil:
+		logging.FromContext(c.Request.Context()).Warn("upload chunk interrupted", "upload_id", c.Param("id"), "error", err)
+		if u != nil {
+			uploadHeaders(c, u)
+		}
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+
+	uploadHeaders(c, u)
+	if u.Complete() && u.ScanID == "" && !scanUpload(c, u) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUploadHandler abandons an upload and deletes what was received.
+func DeleteUploadHandler(c *gin.Context) {
+	if !tusRequest(c) {
+		return
+	}
+	u, ok := ownUpload(c)
+	if !ok {
+		return
+	}
+	if err := uploads.Remove(u.ID); err != nil && !errors.Is(err, tus.ErrNotFound) {
+		logging.FromContext(c.Request.Context()).Error("delete upload failed", "upload_id", u.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// scanUpload queues the scan of a complete upload. A failed attempt leaves
+// the upload complete, so a PATCH with no body at its end retries it. It
+// writes the error response itself when it returns false.
+func scanUpload(c *gin.Context, u *tus.Upload) bool {
+	ctx := c.Request.Context()
+	image, err := uploads.Data(u.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("read upload failed", "upload_id", u.ID, "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return false
+	}
+	metrics.ObserveUpload(int64(len(image)))
+	if err := checkImageBytes(image); err != nil {
+		respondOpenError(c, err)
+		return false
+	}
+	if !rawConsent(c, u.Metadata["consent"]) {
+		return false
+	}
+	callbackURL, ok := callbackFor(c, u.Metadata["callback_url"])
+	if !ok {
+		return false
+	}
+	job, ok := submitScan(c, image, callbackURL)
+	if !ok {
+		return false
+	}
+	middleware.SetScanCount(c, 1)
+	if err := uploads.SetScanID(u.ID, job.ID); err != nil {
+		logging.FromContext(ctx).Error("record upload scan failed", "upload_id", u.ID, "error", err)
+	}
+	return true
+}
+
+// ownUpload loads the upload named in the path, hiding those of other
+// keys. It writes the error response itself when it returns false.
+func ownUpload(c *gin.Context) (*tus.Upload, bool) {
+	u, err := uploads.Get(c.Param("id"))
+	if err != nil && !errors.Is(err, tus.ErrNotFound) {
+		logging.FromContext(c.Request.Context()).Error("load upload failed", "upload_id", c.Param("id"), "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return nil, false
+	}
+	p, _ := middleware.PrincipalFrom(c)
+	if err != nil || u.Tenant != p.Tenant || u.KeyID != p.KeyID {
+		apierr.Abort(c, http.StatusNotFound, apierr.UploadNotFound, nil)
+		return nil, false
+	}
+	return u, true
+}
+
+func uploadHeaders(c *gin.Context, u *tus.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Length, 10))
+	c.Header("Upload-Expires", u.ExpiresAt.UTC().Format(http.TimeFormat))
+	if u.ScanID != "" {
+		c.Header("Location", middleware.VersionPath(c, "/scans/"+u.ScanID))
+	}
+}
+
+// parseUploadMetadata decodes Upload-Metadata: comma-separated pairs of a
+// key and its base64 value.
+func parseUploadMetadata(h string) (map[string]string, error) {
+	meta := make(map[string]string)
+	if strings.TrimSpace(h) == "" {
+		return meta, nil
+	}
+	for _, pair := range strings.Split(h, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("empty metadata key")
+		}
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("metadata %s: %w", key, err)
+		}
+		meta[key] = string(b)
+	}
+	return meta, nil
+}
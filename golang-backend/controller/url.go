@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/metrics"
+	"golang-backend/service"
+	"golang-backend/storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type urlUpload struct {
+	URL     string           `json:"url" binding:"required,url"`
+	Consent *storage.Consent `json:"consent"`
+}
+
+// URLUploadHandler downloads the image at the given URL and scans it.
+func URLUploadHandler(c *gin.Context) {
+	var req urlUpload
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !bindConsent(c, req.Consent) {
+		return
+	}
+
+	ctx := scanContext(c)
+	image, err := service.FetchImage(ctx, req.URL, maxUploadBytes)
+	switch {
+	case errors.Is(err, service.ErrFetchForbidden):
+		apierr.Abort(c, http.StatusBadRequest, apierr.URLForbidden, gin.H{"reason": err.Error()})
+		return
+	case errors.Is(err, service.ErrFetchTooLarge):
+		respondTooLarge(c)
+		return
+	case err != nil:
+		logging.FromContext(ctx).Warn("fetch image failed", "error", err)
+		apierr.Abort(c, http.StatusBadGateway, apierr.DownloadFailed, nil)
+		return
+	}
+	metrics.ObserveUpload(int64(len(image)))
+
+	if err = checkImageBytes(image); err != nil {
+		respondOpenError(c, err)
+		return
+	}
+
+	ctx, record := trackScan(c, ctx)
+	result, err := service.Scan(ctx, bytes.NewReader(image))
+	id := record(result, err)
+	if d, late := pastDeadline(c, err); late {
+		respondPastDeadline(c, d, bytes.NewReader(image))
+		return
+	}
+	setScanID(c, id)
+	if err != nil {
+		respondScanError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, result)
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type usageReport struct {
+	storage.Usage
+	SuccessRate float64 `json:"success_rate"`
+}
+
+func newUsageReport(u storage.Usage) usageReport {
+	return usageReport{Usage: u, SuccessRate: u.SuccessRate()}
+}
+
+type tenantUsage struct {
+	usageReport
+	Keys []usageReport `json:"keys"`
+}
+
+// UsageHandler reports the caller's own scan usage between from and to.
+func UsageHandler(c *gin.Context) {
+	f, ok := usageFilter(c)
+	if !ok {
+		return
+	}
+	if p, ok := middleware.PrincipalFrom(c); ok {
+		f.KeyID, f.Tenant = p.KeyID, p.Tenant
+	}
+	usage, ok := loadUsage(c, f)
+	if !ok {
+		return
+	}
+	total := storage.Usage{KeyID: f.KeyID, Tenant: f.Tenant}
+	for _, u := range usage {
+		total.Add(u)
+	}
+	c.JSON(http.StatusOK, usageRange(f, gin.H{"usage": newUsageReport(total)}))
+}
+
+// AdminUsageHandler reports scan usage per tenant and API key between from
+// and to, optionally narrowed to one tenant or key_id.
+func AdminUsageHandler(c *gin.Context) {
+	f, ok := usageFilter(c)
+	if !ok {
+		return
+	}
+	f.Tenant, f.KeyID = c.Query("tenant"), c.Query("key_id")
+	usage, ok := loadUsage(c, f)
+	if !ok {
+		return
+	}
+	var total storage.Usage
+	tenants := []tenantUsage{}
+	for _, u := range usage {
+		total.Add(u)
+		if len(tenants) == 0 || tenants[len(tenants)-1].Tenant != u.Tenant {
+			tenants = append(tenants, tenantUsage{usageReport: usageReport{Usage: storage.Usage{Tenant: u.Tenant}}})
+		}
+		t := &tenants[len(tenants)-1]
+		t.Usage.Add(u)
+		t.Keys = append(t.Keys, newUsageReport(u))
+	}
+	for i := range tenants {
+		tenants[i].SuccessRate = tenants[i].Usage.SuccessRate()
+	}
+	c.JSON(http.StatusOK, usageRange(f, gin.H{"total": newUsageReport(total), "tenants": tenants}))
+}
+
+func usageFilter(c *gin.Context) (storage.UsageFilter, bool) {
+	if scanStore == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.StorageOff, nil)
+		return storage.UsageFilter{}, false
+	}
+	var f storage.UsageFilter
+	var err error
+	if f.From, err = queryTime(c, "from"); err == nil {
+		f.To, err = queryTime(c, "to")
+	}
+	if err != nil {
+		apierr.Write(c, http.StatusBadRequest, apierr.From(err, apierr.InvalidQuery))
+		return f, false
+	}
+	return f, true
+}
+
+// usageRange adds the requested from and to, when set, to resp.
+func usageRange(f storage.UsageFilter, resp gin.H) gin.H {
+	if !f.From.IsZero() {
+		resp["from"] = f.From
+	}
+	if !f.To.IsZero() {
+		resp["to"] = f.To
+	}
+	return resp
+}
+
+func loadUsage(c *gin.Context, f storage.UsageFilter) ([]storage.Usage, bool) {
+	usage, err := scanStore.Usage(c.Request.Context(), f)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("load usage failed", "error", err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		return nil, false
+	}
+	return usage, true
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"golang-backend/apierr"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why one request field was rejected. Rule is the
+// failed check, such as "required" or "max_bytes", and Param its argument.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+func init() {
+	// Report JSON field names rather than Go ones.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// validationFailed writes the rejected fields. Oversized files make it a
+// 413 and unsupported ones a 415, so clients keep their status handling.
+func validationFailed(c *gin.Context, fields []FieldError) {
+	status := http.StatusBadRequest
+	for _, f := range fields {
+		switch {
+		case f.Rule == "max_bytes":
+			status = http.StatusRequestEntityTooLarge
+		case f.Rule == "content_type" && status == http.StatusBadRequest:
+			status = http.StatusUnsupportedMediaType
+		}
+	}
+	apierr.Abort(c, status, apierr.InvalidFields, gin.H{"fields": fields})
+}
+
+// bindJSON decodes the JSON body into v and checks its binding tags,
+// writing the error response itself when it returns false.
+func bindJSON(c *gin.Context, v any) bool {
+	err := c.ShouldBindJSON(v)
+	if err == nil {
+		return true
+	}
+	var (
+		invalid  validator.ValidationErrors
+		typeErr  *json.UnmarshalTypeError
+		tooLarge *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &invalid):
+		fields := make([]FieldError, len(invalid))
+		for i, fe := range invalid {
+			// Namespace is "urlUpload.consent.version"; drop the struct name.
+			_, path, _ := strings.Cut(fe.Namespace(), ".")
+			fields[i] = FieldError{Field: path, Rule: fe.Tag(), Param: fe.Param()}
+		}
+		validationFailed(c, fields)
+	case errors.As(err, &typeErr):
+		validationFailed(c, []FieldError{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String()}})
+	case errors.As(err, &tooLarge):
+		respondTooLarge(c)
+	default:
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidBody, gin.H{"reason": err.Error()})
+	}
+	return false
+}
+
+// ValidateUpload parses a multipart upload and checks that each of files
+// is present, within upload.max_bytes and an allowed image type, reporting
+// every failing field at once.
+func ValidateUpload(files ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		form, ok := parseMultipart(c, maxUploadBytes*int64(len(files)), apierr.FileTooLarge)
+		if !ok {
+			return
+		}
+		var fields []FieldError
+		for _, name := range files {
+			headers := form.File[name]
+			if len(headers) == 0 {
+				fields = append(fields, FieldError{Field: name, Rule: "required"})
+				continue
+			}
+			fields = append(fields, checkFile(name, headers[0])...)
+		}
+		if len(fields) > 0 {
+			validationFailed(c, fields)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidateUploads is ValidateUpload for a field that may be sent up to
+// upload.max_batch_files times. Only a lone file is checked here; the
+// results of several report failures per file.
+func ValidateUploads(field string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		form, ok := parseMultipart(c, maxUploadBytes*int64(maxBatchFiles), apierr.BatchTooLarge)
+		if !ok {
+			return
+		}
+		var fields []FieldError
+		switch headers := form.File[field]; {
+		case len(headers) == 0:
+			fields = append(fields, FieldError{Field: field, Rule: "required"})
+		case len(headers) > maxBatchFiles:
+			fields = append(fields, FieldError{Field: field, Rule: "max_files", Param: strconv.Itoa(maxBatchFiles)})
+		case len(headers) == 1:
+			fields = checkFile(field, headers[0])
+		}
+		if len(fields) > 0 {
+			validationFailed(c, fields)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidateBatch is ValidateUpload for the "files" (or "file") fields of a
+// batch, which may hold up to upload.max_batch_files images.
+func ValidateBatch(c *gin.Context) {
+	form, ok := parseMultipart(c, maxUploadBytes*int64(maxBatchFiles), apierr.BatchTooLarge)
+	if !ok {
+		return
+	}
+	var fields []FieldError
+	files := append(form.File["files"], form.File["file"]...)
+	switch {
+	case len(files) == 0:
+		fields = append(fields, FieldError{Field: "files", Rule: "required"})
+	case len(files) > maxBatchFiles:
+		fields = append(fields, FieldError{Field: "files", Rule: "max_files", Param: strconv.Itoa(maxBatchFiles)})
+	}
+	if len(fields) > 0 {
+		validationFailed(c, fields)
+		return
+	}
+	// Per-file failures are reported in the batch results.
+	c.Next()
+}
+
+// parseMultipart reads a multipart body of at most limit bytes, answering
+// tooLarge when it is bigger.
+func parseMultipart(c *gin.Context, limit int64, tooLarge apierr.Code) (*multipart.Form, bool) {
+	if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType != "multipart/form-data" {
+		apierr.Abort(c, http.StatusUnsupportedMediaType, apierr.UnsupportedMediaType, gin.H{
+			"content_type": c.ContentType(),
+			"allowed":      []string{"multipart/form-data"},
+		})
+		return nil, false
+	}
+	limitBody(c, limit)
+	form, err := c.MultipartForm()
+	if err != nil {
+		if isTooLarge(err) {
+			apierr.Abort(c, http.StatusRequestEntityTooLarge, tooLarge, gin.H{"max_bytes": limit})
+			return nil, false
+		}
+		apierr.Abort(c, http.StatusBadRequest, apierr.MultipartInvalid, nil)
+		return nil, false
+	}
+	return form, true
+}
+
+func checkFile(name string, fh *multipart.FileHeader) []FieldError {
+	if fh.Size > maxUploadBytes {
+		return []FieldError{{Field: name, Rule: "max_bytes", Param: strconv.FormatInt(maxUploadBytes, 10)}}
+	}
+	f, err := openImage(fh)
+	if err != nil {
+		var unsupported *unsupportedMediaError
+		if errors.As(err, &unsupported) {
+			return []FieldError{{Field: name, Rule: "content_type", Param: strings.Join(allowedTypeList(), ",")}}
+		}
+		return []FieldError{{Field: name, Rule: "readable"}}
+	}
+	f.Close()
+	return nil
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"golang-backend/apierr"
+	"golang-backend/logging"
+	"golang-backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type verifyBody struct {
+	IDNumber  string `json:"id_number" binding:"required"`
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name" binding:"required"`
+	BirthDate string `json:"birth_date" binding:"required"`
+	LaserCode string `json:"laser_code" binding:"required"`
+}
+
+// VerifyHandler checks card details against the DOPA register. birth_date
+// may be ISO-8601 or as printed on the card.
+func VerifyHandler(c *gin.Context) {
+	var body verifyBody
+	if !bindJSON(c, &body) {
+		return
+	}
+	if !service.ValidCitizenID(body.IDNumber) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidCitizenID, nil)
+		return
+	}
+	laser, ok := service.NormalizeLaserCode(body.LaserCode)
+	if !ok {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidLaserCode, nil)
+		return
+	}
+	birth, ok := parseBirthDate(body.BirthDate)
+	if !ok {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidBirthDate, nil)
+		return
+	}
+
+	result, err := service.Verify(c.Request.Context(), service.VerifyRequest{
+		IDNumber:  body.IDNumber,
+		FirstName: body.FirstName,
+		LastName:  body.LastName,
+		BirthDate: birth,
+		LaserCode: laser,
+	})
+	switch {
+	case errors.Is(err, service.ErrVerifyDisabled):
+		apierr.Abort(c, http.StatusServiceUnavailable, apierr.VerifyDisabled, nil)
+		return
+	case err != nil:
+		logging.FromContext(c.Request.Context()).Error("dopa verification failed", "error", err)
+		apierr.Abort(c, http.StatusBadGateway, apierr.VerifyUnavailable, nil)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func parseBirthDate(s string) (*service.CardDate, bool) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return service.ParseCardDate(t.Format("2 Jan 2006"))
+	}
+	return service.ParseCardDate(s)
+}
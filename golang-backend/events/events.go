@@ -0,0 +1,138 @@
+// Package events announces finished scans on a message broker, so other
+// services can react to them without polling the API.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/storage"
+)
+
+const (
+	TypeCompleted = "scan.completed"
+	TypeFailed    = "scan.failed"
+)
+
+// Event is the message body. ScanID is empty when storage is disabled;
+// Result is the scan's JSON result unless events.include_result is off.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	ScanID     string          `json:"scan_id,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Tenant     string          `json:"tenant,omitempty"`
+	KeyID      string          `json:"key_id,omitempty"`
+	Route      string          `json:"route"`
+	Document   string          `json:"document,omitempty"`
+	Status     string          `json:"status"`
+	IDHash     string          `json:"id_hash,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Publisher sends an event to a broker.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+	Close() error
+}
+
+// New connects to the broker named by cfg.Broker, or returns nil when it
+// is empty.
+func New(cfg config.EventsConfig) (Publisher, error) {
+	switch cfg.Broker {
+	case "":
+		return nil, nil
+	case "nats":
+		return newNATS(cfg)
+	case "kafka":
+		return newKafka(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown events broker %q", cfg.Broker)
+}
+
+var (
+	settings = config.Default().Events
+	pending  chan Event
+	sent     sync.WaitGroup
+)
+
+// Start sends published events through p in the background. A nil p
+// leaves Publish a no-op.
+func Start(cfg config.EventsConfig, p Publisher) {
+	settings = cfg
+	if p == nil {
+		return
+	}
+	pending = make(chan Event, max(cfg.BufferSize, 1))
+	sent.Add(1)
+	go func() {
+		defer sent.Done()
+		for e := range pending {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+			if err := p.Publish(ctx, e); err != nil {
+				slog.Error("publish event failed", "type", e.Type, "scan_id", e.ScanID, "request_id", e.RequestID, "error", err)
+			}
+			cancel()
+		}
+		p.Close()
+	}()
+}
+
+// Stop sends the events still buffered, giving up when ctx ends.
+func Stop(ctx context.Context) error {
+	if pending == nil {
+		return nil
+	}
+	close(pending)
+	done := make(chan struct{})
+	go func() {
+		sent.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Publish queues the event for a finished scan. It never blocks the scan:
+// when the buffer is full the event is dropped and logged.
+func Publish(s *storage.Scan) {
+	if pending == nil {
+		return
+	}
+	e := Event{
+		ID:         storage.NewID(),
+		Type:       TypeCompleted,
+		ScanID:     s.ID,
+		RequestID:  s.RequestID,
+		Tenant:     s.Tenant,
+		KeyID:      s.KeyID,
+		Route:      s.Route,
+		Document:   s.Document,
+		Status:     s.Status,
+		IDHash:     s.IDHash,
+		Error:      s.Error,
+		OccurredAt: time.Now().UTC(),
+	}
+	if s.Status == storage.StatusFailed {
+		e.Type = TypeFailed
+	}
+	if settings.IncludeResult {
+		e.Result = s.Result
+	}
+	select {
+	case pending <- e:
+	default:
+		slog.Warn("event buffer full; event dropped", "type", e.Type, "scan_id", e.ScanID, "request_id", e.RequestID)
+	}
+}
@@ -0,0 +1,45 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/segmentio/kafka-go"
+
+	"golang-backend/config"
+)
+
+// kafkaPublisher writes every event to cfg.Topic, keyed by scan ID so a
+// scan's events stay in order, with the event type in the "type" header.
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func newKafka(cfg config.EventsConfig) *kafkaPublisher {
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.KafkaBrokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	key := e.ScanID
+	if key == "" {
+		key = e.ID
+	}
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(key),
+		Value:   body,
+		Headers: []kafka.Header{{Key: "type", Value: []byte(e.Type)}},
+	})
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
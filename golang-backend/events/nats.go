@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"golang-backend/config"
+)
+
+// natsPublisher sends each event to the subject cfg.Topic followed by the
+// event type's last part, such as "thai-id.scans.completed".
+type natsPublisher struct {
+	conn  *nats.Conn
+	topic string
+}
+
+func newNATS(cfg config.EventsConfig) (*natsPublisher, error) {
+	conn, err := nats.Connect(cfg.NATSURL, nats.Name("thai-id-backend"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &natsPublisher{conn: conn, topic: cfg.Topic}, nil
+}
+
+func (p *natsPublisher) Publish(_ context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, kind, _ := strings.Cut(e.Type, ".")
+	return p.conn.Publish(p.topic+"."+kind, body)
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
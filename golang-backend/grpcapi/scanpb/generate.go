@@ -0,0 +1,4 @@
+// Package scanpb holds the generated gRPC bindings for scan.proto.
+package scanpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative scan.proto
@@ -0,0 +1,195 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: scan.proto
+
+package scanpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	ScanService_Scan_FullMethodName       = "/thaiid.v1.ScanService/Scan"
+	ScanService_ScanStream_FullMethodName = "/thaiid.v1.ScanService/ScanStream"
+)
+
+// ScanServiceClient is the client API for ScanService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ScanService reads Thai ID cards and other documents with the same OCR
+// pipeline as the REST API. Calls authenticate with an "authorization:
+// Bearer <key>" or "x-api-key" metadata entry.
+type ScanServiceClient interface {
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResult, error)
+	// ScanStream accepts the image in chunks. The document and consent are
+	// read from the first chunk.
+	ScanStream(ctx context.Context, opts ...grpc.CallOption) (ScanService_ScanStreamClient, error)
+}
+
+type scanServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScanServiceClient(cc grpc.ClientConnInterface) ScanServiceClient {
+	return &scanServiceClient{cc}
+}
+
+func (c *scanServiceClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanResult)
+	err := c.cc.Invoke(ctx, ScanService_Scan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scanServiceClient) ScanStream(ctx context.Context, opts ...grpc.CallOption) (ScanService_ScanStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScanService_ServiceDesc.Streams[0], ScanService_ScanStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &scanServiceScanStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type ScanService_ScanStreamClient interface {
+	Send(*ScanChunk) error
+	CloseAndRecv() (*ScanResult, error)
+	grpc.ClientStream
+}
+
+type scanServiceScanStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *scanServiceScanStreamClient) Send(m *ScanChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *scanServiceScanStreamClient) CloseAndRecv() (*ScanResult, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ScanResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ScanServiceServer is the server API for ScanService service.
+// All implementations must embed UnimplementedScanServiceServer
+// for forward compatibility
+//
+// ScanService reads Thai ID cards and other documents with the same OCR
+// pipeline as the REST API. Calls authenticate with an "authorization:
+// Bearer <key>" or "x-api-key" metadata entry.
+type ScanServiceServer interface {
+	Scan(context.Context, *ScanRequest) (*ScanResult, error)
+	// ScanStream accepts the image in chunks. The document and consent are
+	// read from the first chunk.
+	ScanStream(ScanService_ScanStreamServer) error
+	mustEmbedUnimplementedScanServiceServer()
+}
+
+// UnimplementedScanServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedScanServiceServer struct {
+}
+
+func (UnimplementedScanServiceServer) Scan(context.Context, *ScanRequest) (*ScanResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedScanServiceServer) ScanStream(ScanService_ScanStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ScanStream not implemented")
+}
+func (UnimplementedScanServiceServer) mustEmbedUnimplementedScanServiceServer() {}
+
+// UnsafeScanServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScanServiceServer will
+// result in compilation errors.
+type UnsafeScanServiceServer interface {
+	mustEmbedUnimplementedScanServiceServer()
+}
+
+func RegisterScanServiceServer(s grpc.ServiceRegistrar, srv ScanServiceServer) {
+	s.RegisterService(&ScanService_ServiceDesc, srv)
+}
+
+func _ScanService_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScanServiceServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScanService_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScanServiceServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScanService_ScanStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ScanServiceServer).ScanStream(&scanServiceScanStreamServer{ServerStream: stream})
+}
+
+type ScanService_ScanStreamServer interface {
+	SendAndClose(*ScanResult) error
+	Recv() (*ScanChunk, error)
+	grpc.ServerStream
+}
+
+type scanServiceScanStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *scanServiceScanStreamServer) SendAndClose(m *ScanResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *scanServiceScanStreamServer) Recv() (*ScanChunk, error) {
+	m := new(ScanChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ScanService_ServiceDesc is the grpc.ServiceDesc for ScanService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ScanService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "thaiid.v1.ScanService",
+	HandlerType: (*ScanServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Scan",
+			Handler:    _ScanService_Scan_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ScanStream",
+			Handler:       _ScanService_ScanStream_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "scan.proto",
+}
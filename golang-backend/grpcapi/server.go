@@ -0,0 +1,347 @@
+// Package grpcapi serves the scan endpoints over gRPC for internal services.
+// Calls are authenticated with the same API keys and JWTs as REST, stored
+// like REST uploads and counted against tenant quotas; per-key rate limits
+// apply to the REST API only.
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"golang-backend/cache"
+	"golang-backend/controller"
+	"golang-backend/events"
+	"golang-backend/grpcapi/scanpb"
+	"golang-backend/logging"
+	"golang-backend/middleware"
+	"golang-backend/service"
+	"golang-backend/storage"
+)
+
+type principalKey struct{}
+
+type server struct {
+	scanpb.UnimplementedScanServiceServer
+	repo     storage.Repository
+	maxBytes int
+}
+
+// NewServer returns a gRPC server for ScanService. keys is nil when
+// authentication is disabled; repo is nil when storage is. usage is the
+// counter the REST quota middleware keeps.
+func NewServer(keys middleware.KeyStore, tenants *middleware.Tenants, usage cache.Counter, repo storage.Repository, maxBytes int64, opts ...grpc.ServerOption) *grpc.Server {
+	a := authenticator{keys: keys, tenants: tenants}
+	q := quota{counter: usage, tenants: tenants}
+	s := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(maxBytes) + (1 << 10)),
+		grpc.ChainUnaryInterceptor(a.unary, q.unary),
+		grpc.ChainStreamInterceptor(a.stream, q.stream),
+	}, opts...)...)
+	scanpb.RegisterScanServiceServer(s, &server{repo: repo, maxBytes: int(maxBytes)})
+	return s
+}
+
+func (s *server) Scan(ctx context.Context, req *scanpb.ScanRequest) (*scanpb.ScanResult, error) {
+	if len(req.Image) > s.maxBytes {
+		return nil, status.Errorf(codes.ResourceExhausted, "image exceeds %d bytes", s.maxBytes)
+	}
+	return s.scan(ctx, req.Document, req.Consent, req.Image, "/thaiid.v1.ScanService/Scan")
+}
+
+func (s *server) ScanStream(stream scanpb.ScanService_ScanStreamServer) error {
+	var (
+		image []byte
+		head  *scanpb.ScanChunk
+	)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if head == nil {
+			head = chunk
+		}
+		if len(image)+len(chunk.Data) > s.maxBytes {
+			return status.Errorf(codes.ResourceExhausted, "image exceeds %d bytes", s.maxBytes)
+		}
+		image = append(image, chunk.Data...)
+	}
+	if head == nil {
+		return status.Error(codes.InvalidArgument, "no chunks received")
+	}
+	result, err := s.scan(stream.Context(), head.Document, head.Consent, image, "/thaiid.v1.ScanService/ScanStream")
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(result)
+}
+
+func (s *server) scan(ctx context.Context, doc scanpb.Document, pc *scanpb.Consent, image []byte, route string) (*scanpb.ScanResult, error) {
+	if len(image) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image is required")
+	}
+	scanner, ok := scanners[doc]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "unknown document")
+	}
+	var consent *storage.Consent
+	if pc != nil {
+		consent = &storage.Consent{Purpose: pc.Purpose, Version: pc.Version, Channel: pc.Channel}
+		if pc.Timestamp != nil {
+			consent.Timestamp = pc.Timestamp.AsTime()
+		}
+	}
+	if err := controller.ValidateConsent(consent); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx, capture := service.WithCapture(ctx)
+	result, err := scanner.scan(ctx, bytes.NewReader(image))
+	scanID := s.save(ctx, route, consent, capture, result, err)
+	if err != nil {
+		return nil, scanError(ctx, err)
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encode result")
+	}
+	fields := &structpb.Struct{}
+	if err = fields.UnmarshalJSON(b); err != nil {
+		return nil, status.Error(codes.Internal, "failed to encode result")
+	}
+	out := &scanpb.ScanResult{ScanId: scanID, Document: string(scanner.doc), Result: fields}
+	if v, ok := fields.Fields["status"]; ok {
+		out.Status = v.GetStringValue()
+	}
+	return out, nil
+}
+
+// save persists, audits and publishes the scan like a REST upload,
+// returning its ID or "" when storage is disabled or fails.
+func (s *server) save(ctx context.Context, route string, consent *storage.Consent, capture *service.Capture, result any, err error) string {
+	rec := &storage.Scan{RequestID: logging.RequestID(ctx), Route: route, Consent: consent}
+	listPrior := true
+	if p, ok := ctx.Value(principalKey{}).(middleware.Principal); ok {
+		rec.KeyID, rec.Tenant = p.KeyID, p.Tenant
+		listPrior = p.Can(middleware.PermReadAll)
+	}
+	if pr, ok := peer.FromContext(ctx); ok {
+		rec.ClientIP, _, _ = net.SplitHostPort(pr.Addr.String())
+	}
+	rec.SetResult(result, err)
+	rec.SetCapture(capture)
+	defer events.Publish(rec)
+	if s.repo == nil {
+		return ""
+	}
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		if err := storage.CheckDuplicate(ctx, s.repo, rec, result, listPrior); err != nil {
+			logging.FromContext(ctx).Error("duplicate check failed", "error", err)
+		}
+	}
+	if err := s.repo.Save(ctx, rec); err != nil {
+		logging.FromContext(ctx).Error("save scan failed", "error", err)
+		rec.ID = ""
+		return ""
+	}
+	e := &storage.AuditEvent{Actor: rec.KeyID, Action: storage.AuditScan, ScanID: rec.ID, Route: "GRPC " + route,
+		Outcome: storage.AuditSucceeded, ClientIP: rec.ClientIP}
+	if err != nil {
+		e.Outcome = storage.AuditFailed
+	}
+	if err := s.repo.AppendAudit(ctx, e); err != nil {
+		logging.FromContext(ctx).Error("write audit event failed", "action", e.Action, "error", err)
+	}
+	return rec.ID
+}
+
+type scanner struct {
+	doc  service.Document
+	scan func(context.Context, io.Reader) (any, error)
+}
+
+var scanners = map[scanpb.Document]scanner{
+	scanpb.Document_DOCUMENT_UNSPECIFIED:        {service.DocumentFront, adapt(service.Scan)},
+	scanpb.Document_DOCUMENT_FRONT:              {service.DocumentFront, adapt(service.Scan)},
+	scanpb.Document_DOCUMENT_BACK:               {service.DocumentBack, adapt(service.ScanBack)},
+	scanpb.Document_DOCUMENT_PASSPORT:           {service.DocumentPassport, adapt(service.ScanPassport)},
+	scanpb.Document_DOCUMENT_DRIVER_LICENSE:     {service.DocumentDriverLicense, adapt(service.ScanDriverLicense)},
+	scanpb.Document_DOCUMENT_HOUSE_REGISTRATION: {service.DocumentHouseRegistration, adapt(service.ScanHouseRegistration)},
+}
+
+func adapt[T any](scan func(context.Context, io.Reader) (T, error)) func(context.Context, io.Reader) (any, error) {
+	return func(ctx context.Context, r io.Reader) (any, error) {
+		return scan(ctx, r)
+	}
+}
+
+// scanError maps service errors onto the status codes matching the REST
+// API's responses.
+func scanError(ctx context.Context, err error) error {
+	var (
+		quality *service.QualityError
+		pixels  *service.PixelLimitError
+		circuit *service.CircuitOpenError
+		busy    *service.OCRBusyError
+		full    *service.OCRQueueFullError
+	)
+	switch {
+	case errors.As(err, &circuit):
+		return status.Error(codes.Unavailable, "ocr service unavailable")
+	case errors.As(err, &busy):
+		return status.Error(codes.ResourceExhausted, "ocr service is busy")
+	case errors.As(err, &full):
+		return status.Error(codes.ResourceExhausted, "scan queue is full")
+	case errors.As(err, &quality):
+		return status.Errorf(codes.FailedPrecondition, "image quality too low: %s", quality.Reason)
+	case errors.As(err, &pixels):
+		return status.Error(codes.InvalidArgument, pixels.Error())
+	case errors.Is(err, service.ErrInvalidMRZ):
+		return status.Error(codes.FailedPrecondition, "could not read passport mrz")
+	case errors.Is(err, service.ErrCardExpired):
+		return status.Error(codes.FailedPrecondition, "card has expired")
+	}
+	logging.FromContext(ctx).Error("scan failed", "error", err)
+	return status.Error(codes.Internal, "failed to scan image")
+}
+
+type authenticator struct {
+	keys    middleware.KeyStore
+	tenants *middleware.Tenants
+}
+
+// authorize sets up the request ID and, with authentication enabled, the
+// caller's principal, who needs the scan permission.
+func (a authenticator) authorize(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := first(md, strings.ToLower(logging.RequestIDHeader))
+	if id == "" || len(id) > 64 {
+		id = storage.NewID()
+	}
+	ctx = logging.WithRequestID(ctx, id)
+	if a.keys == nil {
+		return ctx, nil
+	}
+
+	key := first(md, "x-api-key")
+	if h := first(md, "authorization"); key == "" && len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		key = strings.TrimSpace(h[7:])
+	}
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing api key")
+	}
+	p, ok := a.keys.Lookup(key)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid api key")
+	}
+	if !p.Can(middleware.PermScan) {
+		return nil, status.Error(codes.PermissionDenied, "forbidden")
+	}
+	if t, ok := a.tenants.Get(p.Tenant); ok && t.Disabled {
+		return nil, status.Error(codes.PermissionDenied, "tenant is disabled")
+	}
+	return context.WithValue(ctx, principalKey{}, p), nil
+}
+
+func (a authenticator) unary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx, err = a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer internalOnPanic(&err)
+	defer service.Recover(ctx, &err)
+	return handler(ctx, req)
+}
+
+func (a authenticator) stream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, err := a.authorize(ss.Context())
+	if err != nil {
+		return err
+	}
+	defer internalOnPanic(&err)
+	defer service.Recover(ctx, &err)
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// quota enforces the caller's tenant quotas, reserving a scan for each call
+// and keeping it only when the call succeeds.
+type quota struct {
+	counter cache.Counter
+	tenants *middleware.Tenants
+}
+
+func (q quota) reserve(ctx context.Context) (func(int), error) {
+	p, _ := ctx.Value(principalKey{}).(middleware.Principal)
+	settle, err := middleware.ReserveQuota(ctx, q.counter, q.tenants, p.Tenant, 1)
+	var exceeded *middleware.QuotaExceededError
+	if errors.As(err, &exceeded) {
+		return nil, status.Errorf(codes.ResourceExhausted, "%s quota exceeded, resets at %s", exceeded.Period, exceeded.ResetsAt.Format(time.RFC3339))
+	}
+	return settle, err
+}
+
+func (q quota) unary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	settle, err := q.reserve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	defer func() { settle(n) }()
+	resp, err := handler(ctx, req)
+	if err == nil {
+		n = 1
+	}
+	return resp, err
+}
+
+func (q quota) stream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	settle, err := q.reserve(ss.Context())
+	if err != nil {
+		return err
+	}
+	n := 0
+	defer func() { settle(n) }()
+	if err = handler(srv, ss); err == nil {
+		n = 1
+	}
+	return err
+}
+
+// internalOnPanic hides a recovered panic behind a plain Internal status.
+func internalOnPanic(err *error) {
+	if errors.Is(*err, service.ErrPanic) {
+		*err = status.Error(codes.Internal, "internal server error")
+	}
+}
+
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context { return s.ctx }
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,376 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/events"
+	"golang-backend/logging"
+	"golang-backend/service"
+	"golang-backend/storage"
+	"golang-backend/webhook"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Status string
+
+const (
+	StatusQueued     Status = "queued"
+	StatusProcessing Status = "processing"
+	StatusDone       Status = "done"
+	StatusFailed     Status = "failed"
+)
+
+var (
+	ErrQueueFull    = errors.New("job queue is full")
+	ErrNotFound     = errors.New("job not found")
+	ErrShuttingDown = errors.New("job queue is shutting down")
+)
+
+// Job.Stage follows Status in more detail: queued, then the service.Stage*
+// values while processing, then done or failed.
+type Job struct {
+	ID          string              `json:"id"`
+	Status      Status              `json:"status"`
+	Stage       string              `json:"stage"`
+	Result      *service.ThaiIDCard `json:"result,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	CallbackURL string              `json:"callback_url,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	// KeyID and Tenant are whose scan this is, so it is shown only to them.
+	KeyID  string `json:"key_id,omitempty"`
+	Tenant string `json:"tenant,omitempty"`
+}
+
+type task struct {
+	id        string
+	requestID string
+	image     []byte
+	record    *storage.Scan
+	listPrior bool
+	// trace carries the trace context of the request that queued the task,
+	// so the background scan shows up in the same trace.
+	trace map[string]string
+}
+
+// backend holds jobs and the tasks waiting for a worker.
+type backend interface {
+	// push saves job and queues t, failing with ErrQueueFull when the queue
+	// is at capacity.
+	push(ctx context.Context, job *Job, t task) error
+	// pop waits for the next task, failing with ErrShuttingDown once close
+	// has been called.
+	pop() (task, error)
+	// ack marks a popped task as finished.
+	ack(id string)
+	// release gives a popped task back unfinished, reporting whether it
+	// will be delivered again.
+	release(id string) bool
+	get(ctx context.Context, id string) (Job, error)
+	update(ctx context.Context, id string, fn func(*Job)) (Job, error)
+	depth(ctx context.Context) (queued, capacity int)
+	// updates receives jobs changed by other processes.
+	updates() <-chan Job
+	close()
+}
+
+// Queue runs scans on a fixed set of background workers and keeps finished
+// jobs for ResultTTL so clients can poll for them. With the memory backend
+// jobs live in this process; with redis they survive a restart and are
+// shared by every replica using the same prefix.
+type Queue struct {
+	backend backend
+	repo    storage.Repository
+
+	mu     sync.Mutex
+	closed bool
+	// watchers receive a copy of a job on every update until it finishes.
+	watchers map[string][]chan Job
+
+	// ctx is cancelled when Shutdown runs out of time, failing the scans
+	// still running or queued.
+	ctx     context.Context
+	abandon context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewQueue starts the workers. Finished scans are saved to repo under the
+// job ID when repo is not nil. keys seals what the redis backend keeps.
+func NewQueue(cfg config.JobsConfig, repo storage.Repository, keys *storage.Keyring) (*Queue, error) {
+	q := &Queue{watchers: make(map[string][]chan Job), repo: repo}
+	switch cfg.Backend {
+	case "", "memory":
+		q.backend = newMemoryBackend(cfg.QueueSize, cfg.ResultTTL)
+	case "redis":
+		b, err := newRedisBackend(cfg.RedisURL, cfg.RedisPrefix, cfg.QueueSize, cfg.ResultTTL, keys)
+		if err != nil {
+			return nil, err
+		}
+		q.backend = b
+	default:
+		return nil, fmt.Errorf("unknown jobs backend %q", cfg.Backend)
+	}
+	q.ctx, q.abandon = context.WithCancel(context.Background())
+	for i := 0; i < max(cfg.Workers, 1); i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	go q.listen()
+	return q, nil
+}
+
+// Submit enqueues image for scanning. The request ID on ctx is carried over
+// to the background scan for log correlation. When callbackURL is set the
+// finished job is POSTed to it. record carries the request metadata stored
+// with the scan, and listPrior whether the caller may see which earlier
+// scans a duplicate identity has, as for storage.CheckDuplicate.
+func (q *Queue) Submit(ctx context.Context, image []byte, callbackURL string, record *storage.Scan, listPrior bool) (Job, error) {
+	now := time.Now()
+	job := &Job{ID: newID(), Status: StatusQueued, Stage: string(StatusQueued), CallbackURL: callbackURL, CreatedAt: now, UpdatedAt: now}
+	if record != nil {
+		job.KeyID, job.Tenant = record.KeyID, record.Tenant
+	}
+
+	q.mu.Lock()
+	closed := q.closed
+	q.mu.Unlock()
+	if closed {
+		return Job{}, ErrShuttingDown
+	}
+	t := task{id: job.ID, requestID: logging.RequestID(ctx), image: image, record: record, listPrior: listPrior, trace: propagation.MapCarrier{}}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(t.trace))
+	if err := q.backend.push(ctx, job, t); err != nil {
+		return Job{}, err
+	}
+	return *job, nil
+}
+
+// Depth reports how many jobs wait for a worker and how many may; zero
+// capacity means no limit.
+func (q *Queue) Depth() (queued, capacity int) {
+	return q.backend.depth(context.Background())
+}
+
+// Shutdown stops accepting jobs and waits for the queued ones, and their
+// webhooks, to finish. With redis only the running scans are waited for;
+// queued ones are left for other replicas. When ctx ends first the
+// remaining scans are cancelled, and saved as failed unless the backend
+// can hand them to another worker, and ctx's error is returned once they
+// are.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		q.backend.close()
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.abandon()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) Get(id string) (Job, error) {
+	return q.backend.get(context.Background(), id)
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for {
+		t, err := q.backend.pop()
+		if err != nil {
+			return
+		}
+		q.run(t)
+	}
+}
+
+func (q *Queue) run(t task) {
+	q.update(t.id, func(j *Job) { j.Status = StatusProcessing })
+
+	ctx := logging.WithRequestID(q.ctx, t.requestID)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(t.trace))
+	ctx, span := otel.Tracer("golang-backend/jobs").Start(ctx, "jobs.scan", trace.WithAttributes(attribute.String("job.id", t.id)))
+	defer span.End()
+	ctx, capture := service.WithCapture(ctx)
+	ctx = service.WithProgress(ctx, func(stage string) {
+		q.update(t.id, func(j *Job) { j.Stage = stage })
+	})
+	var result *service.ThaiIDCard
+	err := ErrShuttingDown
+	if q.ctx.Err() == nil {
+		result, err = scan(ctx, t)
+	}
+	if err != nil && q.ctx.Err() != nil && q.backend.release(t.id) {
+		logging.FromContext(ctx).Info("async scan interrupted; requeued", "job_id", t.id)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("async scan failed", "job_id", t.id, "error", err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	// Save the outcome even when the queue has been abandoned.
+	q.save(context.WithoutCancel(ctx), t, capture, result, err)
+
+	q.update(t.id, func(j *Job) {
+		if err != nil {
+			j.Status, j.Stage, j.Error = StatusFailed, string(StatusFailed), "failed to scan image"
+			return
+		}
+		j.Status, j.Stage, j.Result = StatusDone, string(StatusDone), result
+	})
+	q.backend.ack(t.id)
+	q.notify(ctx, t)
+}
+
+// scan keeps a panicking scan from stopping the worker.
+func scan(ctx context.Context, t task) (result *service.ThaiIDCard, err error) {
+	defer service.Recover(ctx, &err)
+	return service.Scan(ctx, bytes.NewReader(t.image))
+}
+
+func (q *Queue) save(ctx context.Context, t task, capture *service.Capture, result *service.ThaiIDCard, err error) {
+	if t.record == nil {
+		return
+	}
+	t.record.ID = t.id
+	t.record.SetResult(result, err)
+	t.record.SetCapture(capture)
+	defer events.Publish(t.record)
+	if q.repo == nil {
+		return
+	}
+	if err == nil {
+		if err := storage.CheckDuplicate(ctx, q.repo, t.record, result, t.listPrior); err != nil {
+			logging.FromContext(ctx).Error("duplicate check failed", "job_id", t.id, "error", err)
+		}
+	}
+	if err := q.repo.Save(ctx, t.record); err != nil {
+		logging.FromContext(ctx).Error("save scan failed", "job_id", t.id, "error", err)
+	}
+}
+
+func (q *Queue) notify(ctx context.Context, t task) {
+	id := t.id
+	job, err := q.Get(id)
+	if err != nil || job.CallbackURL == "" {
+		return
+	}
+	var tenant string
+	if t.record != nil {
+		tenant = t.record.Tenant
+	}
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		if err := webhook.Deliver(ctx, tenant, job.CallbackURL, job); err != nil {
+			logging.FromContext(ctx).Error("webhook delivery abandoned", "job_id", id, "error", err)
+		}
+	}()
+}
+
+func (q *Queue) update(id string, fn func(*Job)) {
+	job, err := q.backend.update(context.Background(), id, fn)
+	if err != nil {
+		logging.FromContext(q.ctx).Error("update job failed", "job_id", id, "error", err)
+		return
+	}
+	q.broadcast(job)
+}
+
+// listen passes on to watchers the updates made by other replicas.
+func (q *Queue) listen() {
+	for job := range q.backend.updates() {
+		q.broadcast(job)
+	}
+}
+
+func (q *Queue) broadcast(job Job) {
+	id := job.ID
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, ch := range q.watchers[id] {
+		select {
+		case ch <- job:
+			continue
+		default:
+		}
+		// A watcher this far behind skips a stage, but not the final state.
+		if job.finished() {
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- job
+		}
+	}
+	for _, ch := range q.watchers[id] {
+		if job.finished() {
+			close(ch)
+		}
+	}
+	if job.finished() {
+		delete(q.watchers, id)
+	}
+}
+
+// Watch returns the job and a channel receiving it after each update; the
+// channel is closed once the job is done or failed. stop must be called
+// when the caller loses interest.
+func (q *Queue) Watch(id string) (job Job, updates <-chan Job, stop func(), err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, err := q.backend.get(context.Background(), id)
+	if err != nil {
+		return Job{}, nil, nil, err
+	}
+	ch := make(chan Job, 8)
+	if j.finished() {
+		close(ch)
+		return j, ch, func() {}, nil
+	}
+	q.watchers[id] = append(q.watchers[id], ch)
+	stop = func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if i := slices.Index(q.watchers[id], ch); i >= 0 {
+			q.watchers[id] = slices.Delete(q.watchers[id], i, i+1)
+			close(ch)
+		}
+	}
+	return j, ch, stop, nil
+}
+
+func (j *Job) finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryBackend keeps jobs and queued work in process; both are lost on
+// restart.
+type memoryBackend struct {
+	mu     sync.RWMutex
+	jobs   map[string]*Job
+	work   chan task
+	closed bool
+	ttl    time.Duration
+}
+
+func newMemoryBackend(size int, ttl time.Duration) *memoryBackend {
+	b := &memoryBackend{jobs: make(map[string]*Job), work: make(chan task, size), ttl: ttl}
+	go b.janitor()
+	return b
+}
+
+func (b *memoryBackend) push(_ context.Context, job *Job, t task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrShuttingDown
+	}
+	select {
+	case b.work <- t:
+		b.jobs[job.ID] = job
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// pop returns queued tasks until close has been called and they are all
+// taken.
+func (b *memoryBackend) pop() (task, error) {
+	t, ok := <-b.work
+	if !ok {
+		return task{}, ErrShuttingDown
+	}
+	return t, nil
+}
+
+func (b *memoryBackend) ack(string) {}
+
+// release cannot hand a task to anyone else, so it is never redelivered.
+func (b *memoryBackend) release(string) bool { return false }
+
+func (b *memoryBackend) get(_ context.Context, id string) (Job, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	job, ok := b.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+func (b *memoryBackend) update(_ context.Context, id string, fn func(*Job)) (Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	fn(job)
+	job.UpdatedAt = time.Now()
+	return *job, nil
+}
+
+func (b *memoryBackend) depth(context.Context) (int, int) {
+	return len(b.work), cap(b.work)
+}
+
+// updates is nil: every update is made by this process.
+func (b *memoryBackend) updates() <-chan Job { return nil }
+
+func (b *memoryBackend) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.work)
+	}
+}
+
+func (b *memoryBackend) janitor() {
+	for range time.Tick(time.Minute) {
+		cutoff := time.Now().Add(-b.ttl)
+		b.mu.Lock()
+		for id, job := range b.jobs {
+			if job.finished() && job.UpdatedAt.Before(cutoff) {
+				delete(b.jobs, id)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
@@ -0,0 +1,350 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"golang-backend/storage"
+)
+
+// leaseTTL is how long a popped task stays owned without its worker
+// renewing the lease. A replica that dies mid-scan loses its tasks to the
+// others within a couple of lease periods.
+const leaseTTL = 30 * time.Second
+
+// redisBackend keeps jobs in Redis so they survive restarts and any
+// replica can work on them or report their status. Under prefix:
+//
+//	job:<id>    the Job as JSON, expiring ttl after it finishes
+//	task:<id>   the image and request metadata, deleted once scanned
+//	queue       IDs waiting for a worker
+//	processing  IDs taken by a worker, each held by lease:<id>
+//	updates     a channel announcing every job change
+//
+// Jobs, tasks and updates hold card images and results, so they are sealed
+// with the storage keys, as stored scans are, when encryption is on.
+type redisBackend struct {
+	client   *redis.Client
+	keys     *storage.Keyring
+	prefix   string
+	size     int
+	ttl      time.Duration
+	replica  string
+	changes  chan Job
+	ctx      context.Context
+	shutdown context.CancelFunc
+
+	mu     sync.Mutex
+	leases map[string]context.CancelFunc
+}
+
+type redisTask struct {
+	ID        string            `json:"id"`
+	RequestID string            `json:"request_id,omitempty"`
+	Image     []byte            `json:"image"`
+	Record    *storage.Scan     `json:"record,omitempty"`
+	ListPrior bool              `json:"list_prior,omitempty"`
+	Trace     map[string]string `json:"trace,omitempty"`
+}
+
+type redisUpdate struct {
+	Replica string `json:"replica"`
+	Job     Job    `json:"job"`
+}
+
+func newRedisBackend(url, prefix string, size int, ttl time.Duration, keys *storage.Keyring) (*redisBackend, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("redis url: %w", err)
+	}
+	opts.ContextTimeoutEnabled = true
+	b := &redisBackend{
+		client:  redis.NewClient(opts),
+		keys:    keys,
+		prefix:  prefix,
+		size:    size,
+		ttl:     ttl,
+		replica: newID(),
+		changes: make(chan Job, 64),
+		leases:  make(map[string]context.CancelFunc),
+	}
+	b.ctx, b.shutdown = context.WithCancel(context.Background())
+	go b.subscribe()
+	go b.reap()
+	return b, nil
+}
+
+// pushScript queues a task unless ARGV[1] are already waiting.
+var pushScript = redis.NewScript(`
+local size = tonumber(ARGV[1])
+if size > 0 and redis.call('LLEN', KEYS[1]) >= size then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[2])
+redis.call('SET', KEYS[3], ARGV[3])
+redis.call('LPUSH', KEYS[1], ARGV[4])
+return 1
+`)
+
+func (b *redisBackend) push(ctx context.Context, job *Job, t task) error {
+	if b.ctx.Err() != nil {
+		return ErrShuttingDown
+	}
+	jobJSON, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	taskJSON, err := json.Marshal(redisTask{ID: t.id, RequestID: t.requestID, Image: t.image, Record: t.record, ListPrior: t.listPrior, Trace: t.trace})
+	if err != nil {
+		return err
+	}
+	keys := []string{b.key("queue"), b.key("job:" + job.ID), b.key("task:" + job.ID)}
+	ok, err := pushScript.Run(ctx, b.client, keys, b.size, b.keys.Seal(jobJSON, keys[1]), b.keys.Seal(taskJSON, keys[2]), job.ID).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// pop waits for a queued task and takes a lease on it, which is renewed
+// until ack or release.
+func (b *redisBackend) pop() (task, error) {
+	for {
+		id, err := b.client.BLMove(b.ctx, b.key("queue"), b.key("processing"), "RIGHT", "LEFT", time.Second).Result()
+		if b.ctx.Err() != nil {
+			if err == nil {
+				// Taken just as we stopped; push it back for the others.
+				b.requeue(context.Background(), id)
+			}
+			return task{}, ErrShuttingDown
+		}
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			slog.Warn("job queue pop failed", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		b.hold(id)
+
+		raw, err := b.client.Get(b.ctx, b.key("task:"+id)).Bytes()
+		if err != nil {
+			// Already finished by a worker whose lease had lapsed.
+			b.ack(id)
+			continue
+		}
+		var t redisTask
+		if raw, err = b.keys.Open(raw, b.key("task:"+id)); err == nil {
+			err = json.Unmarshal(raw, &t)
+		}
+		if err != nil {
+			slog.Error("job task unreadable", "job_id", id, "error", err)
+			b.ack(id)
+			continue
+		}
+		return task{id: t.ID, requestID: t.RequestID, image: t.Image, record: t.Record, listPrior: t.ListPrior, trace: t.Trace}, nil
+	}
+}
+
+// hold takes the lease on id and renews it in the background.
+func (b *redisBackend) hold(id string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.mu.Lock()
+	b.leases[id] = cancel
+	b.mu.Unlock()
+	b.client.Set(ctx, b.key("lease:"+id), b.replica, leaseTTL)
+	go func() {
+		ticker := time.NewTicker(leaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.client.Set(ctx, b.key("lease:"+id), b.replica, leaseTTL)
+			}
+		}
+	}()
+}
+
+func (b *redisBackend) unhold(id string) {
+	b.mu.Lock()
+	if cancel, ok := b.leases[id]; ok {
+		cancel()
+		delete(b.leases, id)
+	}
+	b.mu.Unlock()
+}
+
+func (b *redisBackend) ack(id string) {
+	b.unhold(id)
+	ctx := context.Background()
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, b.key("processing"), 1, id)
+		pipe.Del(ctx, b.key("task:"+id), b.key("lease:"+id))
+		return nil
+	})
+	if err != nil {
+		slog.Warn("job ack failed", "job_id", id, "error", err)
+	}
+}
+
+// release gives up a task without finishing it, so another worker picks
+// it up.
+func (b *redisBackend) release(id string) bool {
+	b.unhold(id)
+	return b.requeue(context.Background(), id)
+}
+
+// requeueScript moves a task from processing back to the front of the
+// queue, dropping its lease.
+var requeueScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('DEL', KEYS[3])
+redis.call('RPUSH', KEYS[2], ARGV[1])
+return 1
+`)
+
+func (b *redisBackend) requeue(ctx context.Context, id string) bool {
+	keys := []string{b.key("processing"), b.key("queue"), b.key("lease:" + id)}
+	n, err := requeueScript.Run(ctx, b.client, keys, id).Int()
+	if err != nil {
+		slog.Warn("job requeue failed", "job_id", id, "error", err)
+	}
+	return n == 1
+}
+
+// reap requeues tasks whose lease has lapsed, because the replica holding
+// them stopped. A task must be seen unleased twice in a row, so one popped
+// an instant before its lease was set is left alone.
+func (b *redisBackend) reap() {
+	unleased := map[string]bool{}
+	ticker := time.NewTicker(leaseTTL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		unleased = b.requeueLapsed(unleased)
+	}
+}
+
+// requeueLapsed requeues the tasks without a lease that were also unleased
+// on the previous pass, and returns those seen unleased for the first time.
+func (b *redisBackend) requeueLapsed(unleased map[string]bool) map[string]bool {
+	ids, err := b.client.LRange(b.ctx, b.key("processing"), 0, -1).Result()
+	if err != nil {
+		return unleased
+	}
+	next := map[string]bool{}
+	for _, id := range ids {
+		if n, err := b.client.Exists(b.ctx, b.key("lease:"+id)).Result(); err != nil || n > 0 {
+			continue
+		}
+		if !unleased[id] {
+			next[id] = true
+			continue
+		}
+		if b.requeue(b.ctx, id) {
+			slog.Warn("job lease lapsed; requeued", "job_id", id)
+		}
+	}
+	return next
+}
+
+func (b *redisBackend) get(ctx context.Context, id string) (Job, error) {
+	raw, err := b.client.Get(ctx, b.key("job:"+id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	if raw, err = b.keys.Open(raw, b.key("job:"+id)); err != nil {
+		return Job{}, err
+	}
+	var job Job
+	err = json.Unmarshal(raw, &job)
+	return job, err
+}
+
+// update is a plain read-modify-write: only the worker holding a job's
+// lease changes it.
+func (b *redisBackend) update(ctx context.Context, id string, fn func(*Job)) (Job, error) {
+	job, err := b.get(ctx, id)
+	if err != nil {
+		return job, err
+	}
+	fn(&job)
+	job.UpdatedAt = time.Now()
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return job, err
+	}
+	var ttl time.Duration = redis.KeepTTL
+	if job.finished() {
+		ttl = b.ttl
+	}
+	if err := b.client.Set(ctx, b.key("job:"+id), b.keys.Seal(raw, b.key("job:"+id)), ttl).Err(); err != nil {
+		return job, err
+	}
+	msg, _ := json.Marshal(redisUpdate{Replica: b.replica, Job: job})
+	b.client.Publish(ctx, b.key("updates"), b.keys.Seal(msg, b.key("updates")))
+	return job, nil
+}
+
+func (b *redisBackend) depth(ctx context.Context) (int, int) {
+	n, err := b.client.LLen(ctx, b.key("queue")).Result()
+	if err != nil {
+		return 0, b.size
+	}
+	return int(n), b.size
+}
+
+// updates delivers changes other replicas made to jobs.
+func (b *redisBackend) updates() <-chan Job { return b.changes }
+
+func (b *redisBackend) subscribe() {
+	sub := b.client.Subscribe(b.ctx, b.key("updates"))
+	go func() {
+		<-b.ctx.Done()
+		sub.Close()
+	}()
+	for msg := range sub.Channel() {
+		var u redisUpdate
+		payload, err := b.keys.Open([]byte(msg.Payload), b.key("updates"))
+		if err != nil || json.Unmarshal(payload, &u) != nil || u.Replica == b.replica {
+			continue
+		}
+		select {
+		case b.changes <- u.Job:
+		case <-b.ctx.Done():
+			return
+		}
+	}
+}
+
+// close stops taking tasks; those still queued wait in Redis for another
+// replica or the next start.
+func (b *redisBackend) close() {
+	b.shutdown()
+}
+
+func (b *redisBackend) key(name string) string {
+	return b.prefix + name
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"golang-backend/config"
+	"golang-backend/storage"
+)
+
+func testRedisBackend(t *testing.T, size int) (*redisBackend, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	keys, err := storage.NewKeyring(context.Background(), config.EncryptionConfig{
+		Keys: []string{"k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newRedisBackend("redis://"+mr.Addr(), "test:", size, time.Hour, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.close)
+	return b, mr
+}
+
+func pushTask(t *testing.T, b *redisBackend, image string) string {
+	t.Helper()
+	id := newID()
+	job := &Job{ID: id, Status: StatusQueued, CreatedAt: time.Now()}
+	if err := b.push(context.Background(), job, task{id: id, image: []byte(image), record: &storage.Scan{Tenant: "tenant-one"}}); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func popTask(t *testing.T, b *redisBackend) task {
+	t.Helper()
+	got := make(chan task, 1)
+	go func() {
+		tk, err := b.pop()
+		if err != nil {
+			t.Error(err)
+		}
+		got <- tk
+	}()
+	select {
+	case tk := <-got:
+		return tk
+	case <-time.After(5 * time.Second):
+		t.Fatal("pop timed out")
+		return task{}
+	}
+}
+
+func TestRedisLeases(t *testing.T) {
+	b, mr := testRedisBackend(t, 0)
+	first, second := pushTask(t, b, "front of card one"), pushTask(t, b, "front of card two")
+
+	tk := popTask(t, b)
+	if tk.id != first || string(tk.image) != "front of card one" || tk.record.Tenant != "tenant-one" {
+		t.Fatalf("popped %s %q %+v, want %s with its image and record", tk.id, tk.image, tk.record, first)
+	}
+	if !mr.Exists("test:lease:"+first) || !contains(t, mr, "test:processing", first) {
+		t.Fatal("popped task is not leased and processing")
+	}
+
+	// A released task goes to the front of the queue.
+	if !b.release(first) {
+		t.Fatal("release did not requeue the task")
+	}
+	if mr.Exists("test:lease:" + first) {
+		t.Error("released task kept its lease")
+	}
+	if tk = popTask(t, b); tk.id != first {
+		t.Fatalf("popped %s after release, want %s again", tk.id, first)
+	}
+
+	// A task whose replica stopped renewing its lease is requeued after
+	// two passes, not one.
+	b.unhold(first)
+	mr.FastForward(leaseTTL + time.Second)
+	unleased := b.requeueLapsed(map[string]bool{})
+	if !unleased[first] || contains(t, mr, "test:queue", first) {
+		t.Fatalf("first pass: unleased %v, want %s marked and not yet requeued", unleased, first)
+	}
+	if b.requeueLapsed(unleased); !contains(t, mr, "test:queue", first) || contains(t, mr, "test:processing", first) {
+		t.Fatal("second pass did not requeue the lapsed task")
+	}
+
+	// Acked tasks leave nothing behind but the job.
+	tk = popTask(t, b)
+	b.ack(tk.id)
+	if mr.Exists("test:task:"+tk.id) || mr.Exists("test:lease:"+tk.id) || contains(t, mr, "test:processing", tk.id) {
+		t.Error("acked task left its task, lease or processing entry")
+	}
+	if _, err := b.get(context.Background(), tk.id); err != nil {
+		t.Errorf("job of acked task: %v", err)
+	}
+	if tk = popTask(t, b); tk.id != second {
+		t.Errorf("popped %s, want %s", tk.id, second)
+	}
+}
+
+func TestRedisSealed(t *testing.T) {
+	b, mr := testRedisBackend(t, 0)
+	id := pushTask(t, b, "front of card one")
+	if _, err := b.update(context.Background(), id, func(j *Job) { j.Error = "ocr failed on 1101700230708" }); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"test:task:" + id, "test:job:" + id} {
+		v, err := mr.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, plain := range []string{"front of card one", base64.StdEncoding.EncodeToString([]byte("front of card one")), "1101700230708", "tenant-one"} {
+			if bytes.Contains([]byte(v), []byte(plain)) {
+				t.Errorf("%s holds %q in clear", key, plain)
+			}
+		}
+	}
+	job, err := b.get(context.Background(), id)
+	if err != nil || job.Error != "ocr failed on 1101700230708" {
+		t.Errorf("get = %+v, %v; want the sealed job back", job, err)
+	}
+}
+
+func TestRedisQueueFull(t *testing.T) {
+	b, _ := testRedisBackend(t, 1)
+	pushTask(t, b, "one")
+	err := b.push(context.Background(), &Job{ID: "j2"}, task{id: "j2", image: []byte("two")})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Errorf("push to a full queue: %v, want ErrQueueFull", err)
+	}
+	if n, size := b.depth(context.Background()); n != 1 || size != 1 {
+		t.Errorf("depth = %d/%d, want 1/1", n, size)
+	}
+}
+
+func contains(t *testing.T, mr *miniredis.Miniredis, key, id string) bool {
+	t.Helper()
+	ids, err := mr.List(key)
+	if err != nil && !errors.Is(err, miniredis.ErrKeyNotFound) {
+		t.Fatal(err)
+	}
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
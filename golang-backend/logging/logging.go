@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"golang-backend/config"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+func Setup(cfg config.LogConfig) {
+	SetupTo(cfg, os.Stdout)
+}
+
+// SetupTo is Setup writing to w, such as stderr for commands whose output
+// goes to stdout.
+func SetupTo(cfg config.LogConfig, w io.Writer) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	if strings.EqualFold(cfg.Format, "text") {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(redactHandler{h}))
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns the default logger annotated with the request ID and
+// trace ID carried by ctx, if any.
+func FromContext(ctx context.Context) *slog.Logger {
+	l := slog.Default()
+	if id := RequestID(ctx); id != "" {
+		l = l.With("request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With("trace_id", sc.TraceID().String())
+	}
+	return l
+}
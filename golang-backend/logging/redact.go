@@ -0,0 +1,248 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// citizenIDPattern matches 13-digit Thai citizen IDs, with or without
+	// the usual 1-4-5-2-1 separators, in Arabic or Thai digits. RE2's \b
+	// only knows ASCII, so redactCitizenIDs checks the boundaries itself.
+	citizenIDPattern = regexp.MustCompile(`[0-9๐-๙][\s-]?[0-9๐-๙]{4}[\s-]?[0-9๐-๙]{5}[\s-]?[0-9๐-๙]{2}[\s-]?[0-9๐-๙]`)
+	// jsonFieldPattern matches "key": "value" pairs, as found in raw OCR
+	// payloads; the values of personal keys are masked.
+	jsonFieldPattern = regexp.MustCompile(`("([^"]*)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	thaiNamePattern  = regexp.MustCompile(`(นางสาว|นาง|นาย|เด็กชาย|เด็กหญิง|ด\.ช\.|ด\.ญ\.)\s*[\p{Thai}]+(?:\s+[\p{Thai}]+)?`)
+	engNamePattern   = regexp.MustCompile(`\b(Mr|Mrs|Miss|Ms)\.?\s+[A-Z][A-Za-z'-]*(?:\s+[A-Z][A-Za-z'-]*)?`)
+	addressPattern   = regexp.MustCompile(`(ที่อยู่|บ้านเลขที่|หมู่ที่|ตำบล|แขวง|อำเภอ|เขต|จังหวัด|ต\.|อ\.|จ\.)\s*[^\s",}]+`)
+)
+
+// sensitiveKeys are log attribute keys whose values are always redacted,
+// besides the personal keys.
+var sensitiveKeys = map[string]bool{"raw_ocr": true}
+
+// Redact masks citizen IDs, names and addresses in s.
+func Redact(s string) string {
+	s = jsonFieldPattern.ReplaceAllStringFunc(s, func(pair string) string {
+		if m := jsonFieldPattern.FindStringSubmatch(pair); personalKey(m[2]) {
+			return m[1] + `"` + redacted + `"`
+		}
+		return pair
+	})
+	s = redactCitizenIDs(s)
+	s = thaiNamePattern.ReplaceAllString(s, "$1 "+redacted)
+	s = engNamePattern.ReplaceAllString(s, "$1 "+redacted)
+	return addressPattern.ReplaceAllString(s, "$1 "+redacted)
+}
+
+// redactCitizenIDs masks the matches of citizenIDPattern that stand alone,
+// not running on from a longer number or a word.
+func redactCitizenIDs(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range citizenIDPattern.FindAllStringIndex(s, -1) {
+		before, _ := utf8.DecodeLastRuneInString(s[:m[0]])
+		after, _ := utf8.DecodeRuneInString(s[m[1]:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(redacted)
+		last = m[1]
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// personalKeys are the OCR labels and result fields whose values RedactJSON
+// masks whole, including everything nested under them such as name parts,
+// address parts, birth dates and the portrait. Keys match exactly, ignoring
+// case and the _original suffix of text changed by normalization, so
+// neighbours like filename or server_name are left alone.
+var personalKeys = keySet(
+	"name", "name_th", "name_en", "name_th_parts", "name_en_parts", "th_name", "en_name", "en_name_raw",
+	"prefix_name_th", "first_name_th", "last_name_th", "prefix_name_en", "first_name_en", "last_name_en",
+	"en_prefix", "en_firstname", "en_lastname", "first_name", "last_name", "surname", "given_names", "title_raw",
+	"address", "address_parts", "house_number", "house_code", "house_id",
+	"id_number", "back_id_number", "id_card", "citizen_id", "laser_code", "laser_code_raw",
+	"passport_number", "license_number", "license_no", "personal_number", "mrz_lines", "mrz_line1", "mrz_line2",
+	"birth", "birth_date", "date_of_birth_th", "date_of_birth_en",
+	"photo", "raw_fields", "back_raw_fields",
+)
+
+func keySet(keys ...string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// RedactJSON masks the personal fields of the JSON document b by key,
+// keeping its layout: every string under a personalKeys key becomes
+// [REDACTED], and every number nested in an object or list under one,
+// such as the day of a birth date, becomes null. A number held directly,
+// like a confidence score, is kept. Other strings go through Redact. If b
+// is not valid JSON it is masked as text by Redact.
+func RedactJSON(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out bytes.Buffer
+	if err := redactJSONValue(dec, &out, unmasked); err != nil {
+		return []byte(Redact(string(b)))
+	}
+	return out.Bytes()
+}
+
+// How a value is masked: not at all, as the value of a personal key, or
+// nested inside one.
+const (
+	unmasked = iota
+	maskedKey
+	maskedNested
+)
+
+func redactJSONValue(dec *json.Decoder, out *bytes.Buffer, mask int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		object := t == '{'
+		out.WriteRune(rune(t))
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			childMask := unmasked
+			if mask != unmasked {
+				childMask = maskedNested
+			}
+			if object {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				k, _ := key.(string)
+				writeJSONString(out, k)
+				out.WriteByte(':')
+				if childMask == unmasked && personalKey(k) {
+					childMask = maskedKey
+				}
+			}
+			if err := redactJSONValue(dec, out, childMask); err != nil {
+				return err
+			}
+		}
+		end, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		out.WriteRune(rune(end.(json.Delim)))
+	case string:
+		switch {
+		case t == "":
+		case mask != unmasked:
+			t = redacted
+		default:
+			t = Redact(t)
+		}
+		writeJSONString(out, t)
+	case json.Number:
+		if mask == maskedNested {
+			out.WriteString("null")
+		} else {
+			out.WriteString(t.String())
+		}
+	case bool:
+		fmt.Fprint(out, t)
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	out.Write(b)
+}
+
+func personalKey(k string) bool {
+	return personalKeys[strings.TrimSuffix(strings.ToLower(k), "_original")]
+}
+
+// redactHandler applies Redact to the message and every attribute before
+// passing records on.
+type redactHandler struct {
+	slog.Handler
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = redactAttr(a)
+	}
+	return redactHandler{h.Handler.WithAttrs(out)}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	if sensitiveKey(a.Key) && v.Kind() != slog.KindGroup {
+		return slog.String(a.Key, redacted)
+	}
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]any, len(group))
+		for i, g := range group {
+			attrs[i] = redactAttr(g)
+		}
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+		return slog.String(a.Key, Redact(fmt.Sprint(v.Any())))
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func sensitiveKey(k string) bool {
+	return sensitiveKeys[strings.ToLower(k)] || personalKey(k)
+}
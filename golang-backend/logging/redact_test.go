@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"id 1101700230708 read", "id [REDACTED] read"},
+		{"id 1-1017-00230-70-8 read", "id [REDACTED] read"},
+		{"id ๑๑๐๑๗๐๐๒๓๐๗๐๘", "id [REDACTED]"},
+		{"holder นาย สมชาย ใจดี", "holder นาย [REDACTED]"},
+		{"holder Mr. John Smith", "holder Mr [REDACTED]"},
+		{"at ต.บางพูด อ.ปากเกร็ด", "at ต. [REDACTED] อ. [REDACTED]"},
+		{`{"name_th":"สมชาย","status":"ok"}`, `{"name_th":"[REDACTED]","status":"ok"}`},
+		{`{"filename":"card.jpg","server_name":"ocr.internal"}`, `{"filename":"card.jpg","server_name":"ocr.internal"}`},
+		{"scan failed after 3 retries", "scan failed after 3 retries"},
+		{"order 12345678901234", "order 12345678901234"}, // 14 digits is not a citizen ID
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"personal strings",
+			`{"id_number":"1101700230708","id_valid":true,"status":"ok"}`,
+			`{"id_number":"[REDACTED]","id_valid":true,"status":"ok"}`,
+		},
+		{
+			"nested parts",
+			`{"name_th_parts":{"prefix":"นาย","first":"สมชาย","last":"ใจดี"},"gender":"M"}`,
+			`{"name_th_parts":{"prefix":"[REDACTED]","first":"[REDACTED]","last":"[REDACTED]"},"gender":"M"}`,
+		},
+		{
+			"dates keep their shape",
+			`{"dates":{"birth":{"raw":"28 พ.ย. 2515","day":28,"year_ce":1972},"expiry":{"iso":"2030-01-01"}}}`,
+			`{"dates":{"birth":{"raw":"[REDACTED]","day":null,"year_ce":null},"expiry":{"iso":"2030-01-01"}}}`,
+		},
+		{
+			"confidence scores are kept",
+			`{"confidence":{"name_th":0.9,"address":0.5},"overall_confidence":0.7}`,
+			`{"confidence":{"name_th":0.9,"address":0.5},"overall_confidence":0.7}`,
+		},
+		{
+			"photo and lists",
+			`{"photo":{"data":"aGVsbG8=","width":120},"warnings":["expiry_unreadable"],"raw_fields":{"a":"b"}}`,
+			`{"photo":{"data":"[REDACTED]","width":null},"warnings":["expiry_unreadable"],"raw_fields":{"a":"[REDACTED]"}}`,
+		},
+		{
+			"other strings are scanned",
+			`{"note":"customer 1101700230708 called","empty":"","n":null}`,
+			`{"note":"customer [REDACTED] called","empty":"","n":null}`,
+		},
+		{
+			"keys match exactly",
+			`{"first_name_th_original":"สมชาย","filename":"card.jpg","server_name":"ocr","nickname":"x","Address":"ต.บางพูด"}`,
+			`{"first_name_th_original":"[REDACTED]","filename":"card.jpg","server_name":"ocr","nickname":"x","Address":"[REDACTED]"}`,
+		},
+		{
+			"not json",
+			`id 1101700230708 {`,
+			`id [REDACTED] {`,
+		},
+		{"empty", ``, ``},
+	}
+	for _, tt := range tests {
+		if got := string(RedactJSON([]byte(tt.in))); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRedactAttr(t *testing.T) {
+	tests := []struct {
+		key, value, want string
+	}{
+		{"name", "Somchai", "[REDACTED]"},
+		{"citizen_id", "x", "[REDACTED]"},
+		{"raw_ocr", "x", "[REDACTED]"},
+		{"filename", "card.jpg", "card.jpg"},
+		{"server_name", "ocr.internal", "ocr.internal"},
+		{"upload_id", "u1", "u1"},
+		{"error", "bad id 1101700230708", "bad id [REDACTED]"},
+	}
+	for _, tt := range tests {
+		if got := redactAttr(slog.String(tt.key, tt.value)).Value.String(); got != tt.want {
+			t.Errorf("%s=%q: got %q, want %q", tt.key, tt.value, got, tt.want)
+		}
+	}
+}
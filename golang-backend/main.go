@@ -1,19 +1,306 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang-backend/apierr"
+	"golang-backend/cache"
+	"golang-backend/config"
 	"golang-backend/controller"
+	"golang-backend/events"
+	"golang-backend/grpcapi"
+	"golang-backend/jobs"
+	"golang-backend/logging"
+	"golang-backend/metrics"
+	"golang-backend/middleware"
+	"golang-backend/openapi"
+	"golang-backend/service"
+	"golang-backend/storage"
+	"golang-backend/tracing"
+	"golang-backend/tus"
+	"golang-backend/webhook"
+	"path/filepath"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-func main() {
-	r := gin.Default()
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "http://localhost:5173")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Next()
+// serve runs the HTTP and gRPC servers until SIGINT or SIGTERM.
+func serve() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	logging.Setup(cfg.Log)
+	stopTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
+	service.Configure(cfg)
+	provider, err := service.NewProvider(cfg.OCR)
+	if err != nil {
+		log.Fatalf("ocr provider: %v", err)
+	}
+	resultCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		log.Fatalf("cache: %v", err)
+	}
+	service.SetProvider(service.WithCache(provider, resultCache))
+	controller.SetResultCache(resultCache)
+	liveness, err := service.NewLivenessProvider(cfg.Face.Liveness)
+	if err != nil {
+		log.Fatalf("liveness provider: %v", err)
+	}
+	service.SetLivenessProvider(liveness)
+	if err := service.LoadAddressDataset(cfg.Address.Dataset); err != nil {
+		log.Fatalf("address dataset: %v", err)
+	}
+	repo, err := storage.Open(context.Background(), cfg.Storage)
+	if err != nil {
+		log.Fatalf("storage: %v", err)
+	}
+	images, err := storage.OpenImages(context.Background(), cfg.Storage.Images)
+	if err != nil {
+		log.Fatalf("image store: %v", err)
+	}
+	keyring, err := storage.NewKeyring(context.Background(), cfg.Storage.Encryption)
+	if err != nil {
+		log.Fatalf("storage encryption: %v", err)
+	}
+	images = storage.EncryptImages(images, keyring)
+	repo = storage.WithImages(storage.WithEncryption(repo, keyring), images, cfg.Storage.Images)
+	controller.Configure(cfg)
+	if err := controller.LoadReportFont(cfg.Report.Font); err != nil {
+		log.Fatalf("report font: %v", err)
+	}
+	webhook.Configure(cfg)
+	controller.SetScanStore(repo, images)
+	publisher, err := events.New(cfg.Events)
+	if err != nil {
+		log.Fatalf("events: %v", err)
+	}
+	events.Start(cfg.Events, publisher)
+	queue, err := jobs.NewQueue(cfg.Jobs, repo, keyring)
+	if err != nil {
+		log.Fatalf("jobs: %v", err)
+	}
+	controller.SetJobQueue(queue)
+	uploadDir := cfg.Resumable.Dir
+	if uploadDir == "" {
+		uploadDir = filepath.Join(os.TempDir(), "thai-id-uploads")
+	}
+	uploads, err := tus.NewStore(uploadDir, cfg.Resumable.Expiry)
+	if err != nil {
+		log.Fatalf("resumable uploads: %v", err)
+	}
+	controller.SetUploadStore(uploads)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("trusted proxies: %v", err)
+	}
+	r.MaxMultipartMemory = cfg.Upload.MaxBytes
+	r.Use(middleware.RequestID())
+	r.Use(tracing.Middleware(cfg.Tracing.ServiceName)...)
+	r.Use(middleware.Logger(), middleware.Recovery(), metrics.Middleware())
+	r.Use(middleware.CORS(cfg.CORS))
+	r.NoRoute(apierr.NotFound)
+	r.GET("/healthz", controller.HealthzHandler)
+	r.GET("/readyz", controller.ReadyzHandler)
+	r.GET("/metrics", metrics.Handler())
+
+	tenants := middleware.NewTenants(cfg.Auth.Tenants, repo)
+	webhook.SetTenantSecrets(func(id string) string {
+		t, _ := tenants.Get(id)
+		return t.WebhookSecret
 	})
-	r.POST("/upload", controller.UploadHandler)
-	r.Run(":8080")
+	var mw apiMiddleware
+	if repo != nil {
+		mw.common = append(mw.common, middleware.Audit(repo))
+	}
+	if cfg.RateLimit.Enabled && cfg.RateLimit.PerIPSecond > 0 {
+		mw.common = append(mw.common, middleware.RateLimitIP(cfg.RateLimit.PerIPSecond, cfg.RateLimit.PerIPBurst))
+	}
+	var keys middleware.KeyStore
+	if cfg.Auth.Enabled {
+		keys = middleware.AnyOf(
+			middleware.StaticKeys(cfg.Auth.APIKeys, cfg.Auth.APIKeyRoles),
+			middleware.StaticKeys(cfg.Auth.AdminKeys, []string{middleware.RoleAdmin}),
+			middleware.TenantKeys(cfg.Auth.Tenants, cfg.Auth.APIKeyRoles),
+		)
+		if repo != nil {
+			keys = middleware.AnyOf(keys, middleware.StoredKeys(repo))
+		}
+		if cfg.Auth.JWT.JWKSURL != "" {
+			tokens, err := middleware.NewJWTKeys(context.Background(), cfg.Auth.JWT)
+			if err != nil {
+				log.Fatalf("jwks: %v", err)
+			}
+			keys = middleware.AnyOf(keys, tokens)
+		}
+		mw.common = append(mw.common, middleware.APIKey(keys), middleware.TenantEnabled(tenants))
+	} else {
+		slog.Warn("api key authentication is disabled")
+	}
+
+	if cfg.RateLimit.Enabled {
+		limit, report := middleware.RateLimit(cfg.RateLimit.PerSecond, cfg.RateLimit.Burst)
+		mw.common = append(mw.common, report)
+		mw.scan = append(mw.scan, limit)
+	}
+	usage, err := cache.NewCounter(cfg.Quota.Backend, cfg.Quota.RedisURL, "thaiid:")
+	if err != nil {
+		log.Fatalf("quota counter: %v", err)
+	}
+	mw.common = append(mw.common, middleware.QuotaHeaders(usage, tenants))
+	if cfg.Idempotency.Enabled {
+		store, err := cache.New(config.CacheConfig{
+			Enabled:  true,
+			Backend:  cfg.Idempotency.Backend,
+			Size:     cfg.Idempotency.Size,
+			TTL:      cfg.Idempotency.TTL,
+			RedisURL: cfg.Idempotency.RedisURL,
+		})
+		if err != nil {
+			log.Fatalf("idempotency store: %v", err)
+		}
+		mw.scan = append(mw.scan, middleware.Idempotency(store, keyring))
+		controller.SetIdempotencyStore(store)
+	}
+	if repo != nil && cfg.Storage.Retention > 0 {
+		go storage.RunRetention(ctx, repo, cfg.Storage.Retention, cfg.Storage.PurgeInterval, controller.ForgetCopies)
+	}
+	mw.scan = append(mw.scan, middleware.Quota(usage, tenants))
+
+	mountAPI(r.Group("/v1", middleware.Version(1)), mw)
+	if cfg.API.LegacyRoutes {
+		deprecatedAt, sunset, err := legacyDates(cfg.API)
+		if err != nil {
+			log.Fatalf("api: %v", err)
+		}
+		mountAPI(r.Group("/", middleware.Version(1), middleware.Deprecated("/v1", deprecatedAt, sunset)), mw)
+	}
+
+	if cfg.Docs.Enabled {
+		spec := openapi.New("Thai ID card scanner API", "1.0.0")
+		if cfg.Auth.Enabled {
+			spec.Security("apiKey", openapi.SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"})
+			spec.Security("bearer", openapi.SecurityScheme{Type: "http", Scheme: "bearer"})
+		}
+		controller.DescribeAPI(spec)
+		if cfg.API.LegacyRoutes {
+			spec.Alias("/v1")
+		}
+		for _, route := range spec.Missing(r.Routes()) {
+			slog.Warn("route is missing from the openapi document", "route", route)
+		}
+		r.GET("/openapi.json", spec.Handler())
+		r.GET("/docs", spec.UIHandler("/openapi.json"))
+	}
+
+	srv := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+	serve, redirect, err := configureTLS(srv, cfg.Server.TLS)
+	if err != nil {
+		log.Fatalf("tls: %v", err)
+	}
+	go func() {
+		slog.Info("listening", "addr", cfg.Server.Addr, "tls", srv.TLSConfig != nil)
+		if err := serve(); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server stopped", "error", err)
+			os.Exit(1)
+		}
+	}()
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Addr != "" {
+		opts := []grpc.ServerOption{}
+		if cfg.Server.TLS.CertFile != "" {
+			creds, err := credentials.NewServerTLSFromFile(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+			if err != nil {
+				log.Fatalf("grpc tls: %v", err)
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			log.Fatalf("grpc listen: %v", err)
+		}
+		grpcServer = grpcapi.NewServer(keys, tenants, usage, repo, cfg.Upload.MaxBytes, opts...)
+		go func() {
+			slog.Info("grpc listening", "addr", cfg.GRPC.Addr)
+			if err := grpcServer.Serve(lis); err != nil {
+				slog.Error("grpc server stopped", "error", err)
+			}
+		}()
+	}
+	if redirect != nil {
+		go func() {
+			slog.Info("listening for acme challenges", "addr", redirect.Addr)
+			if err := redirect.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("acme listener stopped", "error", err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	stop()
+	slog.Info("shutting down", "drain_delay", cfg.Server.DrainDelay, "timeout", cfg.Server.ShutdownTimeout)
+	controller.StartDraining()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if redirect != nil {
+		redirect.Shutdown(shutdownCtx)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("in-flight requests did not finish", "error", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+	if err := queue.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("queued scans were abandoned", "error", err)
+	}
+	if err := events.Stop(shutdownCtx); err != nil {
+		slog.Warn("scan events were not all published", "error", err)
+	}
+	if repo != nil {
+		repo.Close()
+	}
+	if err := stopTracing(shutdownCtx); err != nil {
+		slog.Warn("spans were not all exported", "error", err)
+	}
+	slog.Info("shutdown complete")
+}
+
+// stopGRPC waits for in-flight calls until ctx ends, then closes the rest.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
 }
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	uploadSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "upload_size_bytes",
+		Help:    "Size of uploaded images.",
+		Buckets: prometheus.ExponentialBuckets(16<<10, 2, 12),
+	})
+
+	forwardedSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ocr_image_size_bytes",
+		Help:    "Size of images sent to the OCR service, after preprocessing.",
+		Buckets: prometheus.ExponentialBuckets(16<<10, 2, 12),
+	})
+
+	ocrDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ocr_request_duration_seconds",
+		Help:    "Latency of calls to the OCR service.",
+		Buckets: []float64{.1, .25, .5, 1, 2, 5, 10, 20, 30, 60},
+	}, []string{"status"})
+
+	ocrRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocr_requests_total",
+		Help: "Calls to the OCR service, by response status code (\"error\" for transport failures).",
+	}, []string{"status"})
+
+	ocrInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "ocr_requests_in_flight",
+		Help: "Calls to the OCR service holding a concurrency slot.",
+	})
+
+	ocrQueueWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ocr_queue_wait_seconds",
+		Help:    "Time OCR calls waited for a concurrency slot, by result (admitted or rejected).",
+		Buckets: []float64{0, .05, .1, .25, .5, 1, 2, 5, 10, 30},
+	}, []string{"result"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocr_cache_lookups_total",
+		Help: "OCR result cache lookups, by result (hit or miss).",
+	}, []string{"result"})
+)
+
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		requestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		requestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+func ObserveUpload(size int64) {
+	uploadSize.Observe(float64(size))
+}
+
+func ObserveForwarded(size int64) {
+	forwardedSize.Observe(float64(size))
+}
+
+// ObserveOCR records one OCR call; status is the HTTP status code, or 0 when
+// the request failed before a response was received.
+func ObserveOCR(status int, d time.Duration) {
+	label := "error"
+	if status != 0 {
+		label = strconv.Itoa(status)
+	}
+	ocrRequestsTotal.WithLabelValues(label).Inc()
+	ocrDuration.WithLabelValues(label).Observe(d.Seconds())
+}
+
+func AddOCRInFlight(delta float64) {
+	ocrInFlight.Add(delta)
+}
+
+func ObserveOCRWait(d time.Duration, admitted bool) {
+	result := "rejected"
+	if admitted {
+		result = "admitted"
+	}
+	ocrQueueWait.WithLabelValues(result).Observe(d.Seconds())
+}
+
+func ObserveCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"strings"
+
+	"golang-backend/logging"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const auditDetailKey = "audit_detail"
+
+// SetAuditDetail records detail with the request's audit event.
+func SetAuditDetail(c *gin.Context, detail string) {
+	c.Set(auditDetailKey, detail)
+}
+
+type AuditLog interface {
+	AppendAudit(ctx context.Context, e *storage.AuditEvent) error
+}
+
+// Audit records every scan, retrieval, search, correction and deletion
+// request, and every tenant and key change, in log once it has been
+// handled, including ones rejected by authentication further down the
+// chain. The scan ID comes from the :id route parameter or the X-Scan-ID
+// response header; on resumable uploads :id names the upload, which is
+// recorded as the detail instead.
+func Audit(log AuditLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		action := AuditAction(c.Request.Method, c.FullPath())
+		if action == "" {
+			return
+		}
+		status := c.Writer.Status()
+		e := &storage.AuditEvent{
+			Action:   action,
+			ScanID:   c.Param("id"),
+			Route:    c.Request.Method + " " + c.FullPath(),
+			Outcome:  storage.AuditSucceeded,
+			Status:   status,
+			ClientIP: c.ClientIP(),
+		}
+		if UnversionedRoute(c.FullPath()) == "/uploads/:id" {
+			e.Detail = "upload " + e.ScanID
+			e.ScanID = ""
+		}
+		if e.ScanID == "" {
+			e.ScanID = c.Writer.Header().Get("X-Scan-ID")
+		}
+		if !strings.HasPrefix(action, "scan.") {
+			// Admin events name the tenant or key they changed instead.
+			e.Detail = cmp.Or(e.ScanID, c.Writer.Header().Get("X-Key-ID"))
+			e.ScanID = ""
+		}
+		if detail := c.GetString(auditDetailKey); detail != "" {
+			e.Detail = detail
+		}
+		if p, ok := PrincipalFrom(c); ok {
+			e.Actor = p.KeyID
+		}
+		switch {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			e.Outcome = storage.AuditDenied
+		case status >= 400:
+			e.Outcome = storage.AuditFailed
+		}
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := log.AppendAudit(ctx, e); err != nil {
+			logging.FromContext(ctx).Error("write audit event failed", "action", action, "error", err)
+		}
+	}
+}
+
+// AuditAction returns the audit action recorded for requests to route, or
+// "" for routes that are not audited.
+func AuditAction(method, route string) string {
+	route = UnversionedRoute(route)
+	switch {
+	case route == "":
+		return ""
+	case method == http.MethodPost && (strings.HasPrefix(route, "/upload") || route == "/scans"),
+		method == http.MethodPatch && route == "/uploads/:id",
+		method == http.MethodGet && route == "/ws/scan":
+		return storage.AuditScan
+	case method == http.MethodPost && (route == "/verify" || route == "/verify/face"):
+		return storage.AuditRead
+	case method == http.MethodGet && (route == "/scans" || route == "/reviews"):
+		return storage.AuditList
+	case method == http.MethodGet && route == "/scans/search":
+		return storage.AuditSearch
+	case method == http.MethodGet && route == "/scans/export":
+		return storage.AuditExport
+	case method == http.MethodGet && (route == "/scans/:id" || route == "/reviews/:id"):
+		return storage.AuditRead
+	case method == http.MethodGet && route == "/scans/:id/report.pdf":
+		return storage.AuditReport
+	case method == http.MethodGet && route == "/scans/:id/events":
+		return storage.AuditWatch
+	case method == http.MethodPost && route == "/reviews/:id":
+		return storage.AuditReview
+	case method == http.MethodPatch && route == "/scans/:id":
+		return storage.AuditCorrect
+	case method == http.MethodDelete && route == "/scans/:id":
+		return storage.AuditDelete
+	case method == http.MethodPost && route == "/scans/:id/purge":
+		return storage.AuditPurge
+	case method == http.MethodPut && route == "/admin/tenants/:id":
+		return storage.AuditTenantUpdate
+	case method == http.MethodPost && route == "/admin/keys":
+		return storage.AuditKeyCreate
+	case method == http.MethodPost && route == "/admin/keys/:id/rotate":
+		return storage.AuditKeyRotate
+	case method == http.MethodDelete && route == "/admin/keys/:id":
+		return storage.AuditKeyRevoke
+	}
+	return ""
+}
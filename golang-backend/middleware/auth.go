@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"golang-backend/apierr"
+	"golang-backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// Principal identifies the caller behind an authenticated request. KeyID is a
+// digest of the API key, or "jwt:<subject>" for tokens, so it can be logged
+// and used as a rate-limit bucket. Tenant is empty for keys outside any
+// tenant, such as admin keys.
+type Principal struct {
+	KeyID  string
+	Tenant string
+	Roles  []string
+}
+
+type KeyStore interface {
+	Lookup(key string) (Principal, bool)
+}
+
+type staticKeys struct {
+	keys   [][]byte
+	tenant string
+	roles  []string
+}
+
+// StaticKeys is a KeyStore backed by a fixed list of keys, typically from
+// config, each acting with roles.
+func StaticKeys(keys []string, roles []string) KeyStore {
+	s := staticKeys{roles: roles}
+	for _, k := range keys {
+		if k != "" {
+			s.keys = append(s.keys, []byte(k))
+		}
+	}
+	return s
+}
+
+// TenantKeys is a KeyStore for the keys of each configured tenant. Tenants
+// without roles of their own act with defaultRoles.
+func TenantKeys(tenants []config.TenantConfig, defaultRoles []string) KeyStore {
+	var stores anyOf
+	for _, t := range tenants {
+		roles := t.Roles
+		if len(roles) == 0 {
+			roles = defaultRoles
+		}
+		s := StaticKeys(t.Keys, roles).(staticKeys)
+		s.tenant = t.ID
+		stores = append(stores, s)
+	}
+	return stores
+}
+
+func (s staticKeys) Lookup(key string) (Principal, bool) {
+	found := false
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(k, []byte(key)) == 1 {
+			found = true
+		}
+	}
+	if !found {
+		return Principal{}, false
+	}
+	return Principal{KeyID: KeyID(key), Tenant: s.tenant, Roles: s.roles}, true
+}
+
+type anyOf []KeyStore
+
+// AnyOf tries each store in turn.
+func AnyOf(stores ...KeyStore) KeyStore {
+	return anyOf(stores)
+}
+
+func (a anyOf) Lookup(key string) (Principal, bool) {
+	for _, s := range a {
+		if p, ok := s.Lookup(key); ok {
+			return p, true
+		}
+	}
+	return Principal{}, false
+}
+
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// APIKey requires an "Authorization: Bearer <key>" or "X-API-Key" header that
+// is known to store.
+func APIKey(store KeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			if h := c.GetHeader("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+				key = strings.TrimSpace(h[7:])
+			}
+		}
+		if key == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			apierr.Abort(c, http.StatusUnauthorized, apierr.APIKeyMissing, nil)
+			return
+		}
+
+		p, ok := store.Lookup(key)
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			apierr.Abort(c, http.StatusUnauthorized, apierr.APIKeyInvalid, nil)
+			return
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+func PrincipalFrom(c *gin.Context) (Principal, bool) {
+	p, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	return p.(Principal), true
+}
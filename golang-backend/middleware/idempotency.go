@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"golang-backend/apierr"
+	"golang-backend/cache"
+	"golang-backend/storage"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	replayedHeader       = "Idempotent-Replayed"
+	maxIdempotencyKeyLen = 255
+	scanIDsKey           = "idempotency_scan_ids"
+)
+
+// storedResponse is what a completed request left behind for its key.
+type storedResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Location    string `json:"location,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// recorder copies the response body while it is written to the client.
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// scanIDs collects the IDs of the scans a request stored, so the response
+// kept for its key can be found again when one of them is purged.
+type scanIDs struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+// NoteScan records that the current request stored scan id. It does
+// nothing outside the Idempotency middleware.
+func NoteScan(c *gin.Context, id string) {
+	v, ok := c.Get(scanIDsKey)
+	if !ok || id == "" {
+		return
+	}
+	s := v.(*scanIDs)
+	s.mu.Lock()
+	s.ids = append(s.ids, id)
+	s.mu.Unlock()
+}
+
+// ForgetScan removes the stored response of the request that created scan
+// id, reporting whether there was one.
+func ForgetScan(ctx context.Context, store cache.Cache, id string) (bool, error) {
+	index := scanIndexKey(id)
+	storeKey, ok := store.Get(ctx, index)
+	if !ok {
+		return false, nil
+	}
+	if err := store.Delete(ctx, string(storeKey)); err != nil {
+		return false, err
+	}
+	return true, store.Delete(ctx, index)
+}
+
+func scanIndexKey(id string) string {
+	return "idempotency:scan:" + id
+}
+
+// Idempotency replays the stored response when a request repeats an
+// Idempotency-Key, so retried uploads neither rerun OCR nor create a second
+// job. Keys are scoped to the caller's API key and route, reusing a key with
+// a different JSON body is rejected with 422, and a key still in progress
+// gets 409.
+// 5xx responses are not stored so the client can retry them. Scans noted
+// with NoteScan are indexed so ForgetScan can drop the stored response.
+// Stored responses hold results, so they are sealed with keys like the
+// scans themselves; a nil keys stores them as they are.
+func Idempotency(store cache.Cache, keys *storage.Keyring) gin.HandlerFunc {
+	var (
+		mu       sync.Mutex
+		inFlight = map[string]bool{}
+	)
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			apierr.Abort(c, http.StatusBadRequest, apierr.IdempotencyKeyTooLong, gin.H{"max_length": maxIdempotencyKeyLen})
+			return
+		}
+		scope := "ip:" + c.ClientIP()
+		if p, ok := PrincipalFrom(c); ok {
+			scope = "key:" + p.KeyID
+		}
+		storeKey := "idempotency:" + scope + ":" + c.FullPath() + ":" + key
+		ctx := c.Request.Context()
+
+		if b, ok := store.Get(ctx, storeKey); ok {
+			var prev storedResponse
+			if b, err := keys.Open(b, storeKey); err == nil && json.Unmarshal(b, &prev) == nil {
+				replay(c, &prev)
+				return
+			}
+		}
+
+		mu.Lock()
+		if inFlight[storeKey] {
+			mu.Unlock()
+			apierr.Abort(c, http.StatusConflict, apierr.IdempotencyInProgress, nil)
+			return
+		}
+		inFlight[storeKey] = true
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			delete(inFlight, storeKey)
+			mu.Unlock()
+		}()
+
+		h := sha256.New()
+		if hashedBody(c) {
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(c.Request.Body, h), c.Request.Body}
+		}
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		scans := &scanIDs{}
+		c.Set(scanIDsKey, scans)
+		c.Next()
+
+		// Responses that ask the client to try again are not kept, or the
+		// retry would only be replayed the same answer.
+		if status := rec.Status(); status >= http.StatusInternalServerError ||
+			status == http.StatusConflict || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout {
+			return
+		}
+		_, _ = io.Copy(io.Discard, c.Request.Body) // hash what the handler left unread
+		b, err := json.Marshal(storedResponse{
+			Fingerprint: hex.EncodeToString(h.Sum(nil)),
+			Status:      rec.Status(),
+			ContentType: rec.Header().Get("Content-Type"),
+			Location:    rec.Header().Get("Location"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			return
+		}
+		store.Set(ctx, storeKey, keys.Seal(b, storeKey))
+		scans.mu.Lock()
+		ids := slices.Clone(scans.ids)
+		scans.mu.Unlock()
+		for _, id := range ids {
+			store.Set(ctx, scanIndexKey(id), []byte(storeKey))
+		}
+	}
+}
+
+func replay(c *gin.Context, prev *storedResponse) {
+	h := sha256.New()
+	if hashedBody(c) {
+		_, _ = io.Copy(h, c.Request.Body)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != prev.Fingerprint {
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.IdempotencyKeyMismatch, nil)
+		return
+	}
+	if prev.Location != "" {
+		c.Header("Location", prev.Location)
+	}
+	c.Header(replayedHeader, "true")
+	c.Data(prev.Status, prev.ContentType, prev.Body)
+	c.Abort()
+}
+
+// hashedBody reports whether the body is compared on replay. Multipart
+// bodies are not, since clients pick a new boundary on every retry.
+func hashedBody(c *gin.Context) bool {
+	return c.ContentType() != "multipart/form-data"
+}
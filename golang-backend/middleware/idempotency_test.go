@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang-backend/cache"
+	"golang-backend/config"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotentRouter serves POST /scan through Idempotency, answering with
+// the body it was sent and the status in the X-Status header, and counts
+// the requests that reached it.
+func idempotentRouter(store cache.Cache, keys *storage.Keyring, calls *atomic.Int32, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/scan", Idempotency(store, keys), func(c *gin.Context) {
+		calls.Add(1)
+		if release != nil {
+			<-release
+		}
+		status := http.StatusOK
+		if c.GetHeader("X-Status") == "500" {
+			status = http.StatusInternalServerError
+		}
+		body, _ := c.GetRawData()
+		c.Data(status, "application/json", body)
+	})
+	return r
+}
+
+func idempotentRequest(r http.Handler, key, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/scan", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	type step struct {
+		key, body string
+		header    []string
+		status    int
+		replayed  bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		calls int32
+	}{
+		{"replay", []step{
+			{key: "a", body: `{"n":1}`, status: 200},
+			{key: "a", body: `{"n":1}`, status: 200, replayed: true},
+			{key: "a", body: `{"n":1}`, status: 200, replayed: true},
+		}, 1},
+		{"other body", []step{
+			{key: "a", body: `{"n":1}`, status: 200},
+			{key: "a", body: `{"n":2}`, status: 422},
+		}, 1},
+		{"other key", []step{
+			{key: "a", body: `{"n":1}`, status: 200},
+			{key: "b", body: `{"n":1}`, status: 200},
+		}, 2},
+		{"no key", []step{
+			{body: `{"n":1}`, status: 200},
+			{body: `{"n":1}`, status: 200},
+		}, 2},
+		{"server error is retried", []step{
+			{key: "a", body: `{"n":1}`, header: []string{"X-Status", "500"}, status: 500},
+			{key: "a", body: `{"n":1}`, status: 200},
+			{key: "a", body: `{"n":1}`, status: 200, replayed: true},
+		}, 2},
+		{"key too long", []step{
+			{key: strings.Repeat("k", maxIdempotencyKeyLen+1), body: `{}`, status: 400},
+		}, 0},
+	}
+	for _, tt := range tests {
+		var calls atomic.Int32
+		r := idempotentRouter(cache.NewLRU(100, time.Hour), nil, &calls, nil)
+		for i, s := range tt.steps {
+			w := idempotentRequest(r, s.key, s.body, s.header...)
+			if w.Code != s.status {
+				t.Errorf("%s: request %d: status %d, want %d", tt.name, i, w.Code, s.status)
+			}
+			if got := w.Header().Get(replayedHeader) == "true"; got != s.replayed {
+				t.Errorf("%s: request %d: replayed %t, want %t", tt.name, i, got, s.replayed)
+			}
+			if s.status == 200 && w.Body.String() != s.body {
+				t.Errorf("%s: request %d: body %s, want %s", tt.name, i, w.Body, s.body)
+			}
+		}
+		if n := calls.Load(); n != tt.calls {
+			t.Errorf("%s: handler ran %d times, want %d", tt.name, n, tt.calls)
+		}
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	r := idempotentRouter(cache.NewLRU(100, time.Hour), nil, &calls, release)
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- idempotentRequest(r, "a", `{"n":1}`) }()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if w := idempotentRequest(r, "a", `{"n":1}`); w.Code != http.StatusConflict {
+		t.Errorf("concurrent retry: status %d, want 409", w.Code)
+	}
+	close(release)
+	if w := <-first; w.Code != http.StatusOK {
+		t.Errorf("first request: status %d, want 200", w.Code)
+	}
+	if w := idempotentRequest(r, "a", `{"n":1}`); w.Header().Get(replayedHeader) != "true" {
+		t.Errorf("retry after completion: status %d, not replayed", w.Code)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestIdempotencySealed(t *testing.T) {
+	keys, err := storage.NewKeyring(context.Background(), config.EncryptionConfig{
+		Keys: []string{"k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := cache.NewLRU(100, time.Hour)
+	var calls atomic.Int32
+	r := idempotentRouter(store, keys, &calls, nil)
+	body := `{"id_number":"1101700230708"}`
+	idempotentRequest(r, "a", body)
+
+	stored, ok := store.Get(context.Background(), "idempotency:ip:192.0.2.1:/scan:a")
+	if !ok {
+		t.Fatal("no stored response")
+	}
+	if bytes.Contains(stored, []byte("1101700230708")) || bytes.Contains(stored, []byte(base64.StdEncoding.EncodeToString([]byte(body)))) {
+		t.Errorf("stored response holds the body in clear: %s", stored)
+	}
+	if w := idempotentRequest(r, "a", body); w.Header().Get(replayedHeader) != "true" || w.Body.String() != body {
+		t.Errorf("replay: status %d body %s, want the sealed response", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-backend/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksMinRefresh limits refetches triggered by tokens with an unknown kid.
+const jwksMinRefresh = time.Minute
+
+// JWTKeys is a KeyStore that accepts RS256/ES256 bearer tokens signed by a
+// key published at the configured JWKS URL.
+type JWTKeys struct {
+	cfg    config.JWTConfig
+	parser *jwt.Parser
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// NewJWTKeys fetches the key set and refreshes it every RefreshInterval.
+func NewJWTKeys(ctx context.Context, cfg config.JWTConfig) (*JWTKeys, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{"RS256", "ES256"}), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	j := &JWTKeys{cfg: cfg, parser: jwt.NewParser(opts...), client: &http.Client{Timeout: 10 * time.Second}}
+	if err := j.refresh(ctx); err != nil {
+		return nil, err
+	}
+	go func() {
+		for range time.Tick(cfg.RefreshInterval) {
+			if err := j.refresh(context.Background()); err != nil {
+				slog.Warn("refresh jwks failed", "error", err)
+			}
+		}
+	}()
+	return j, nil
+}
+
+func (j *JWTKeys) Lookup(token string) (Principal, bool) {
+	if strings.Count(token, ".") != 2 {
+		return Principal{}, false
+	}
+	claims := jwt.MapClaims{}
+	if _, err := j.parser.ParseWithClaims(token, claims, j.key); err != nil {
+		return Principal{}, false
+	}
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return Principal{}, false
+	}
+	tenant, _ := claims[j.cfg.TenantClaim].(string)
+	return Principal{KeyID: "jwt:" + sub, Tenant: tenant, Roles: rolesClaim(claims[j.cfg.RolesClaim])}, true
+}
+
+func (j *JWTKeys) key(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	j.mu.RLock()
+	k, ok := j.keys[kid]
+	stale := time.Since(j.fetchedAt) > jwksMinRefresh
+	j.mu.RUnlock()
+	if !ok && stale {
+		if err := j.refresh(context.Background()); err != nil {
+			return nil, err
+		}
+		j.mu.RLock()
+		k, ok = j.keys[kid]
+		j.mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return k, nil
+}
+
+func rolesClaim(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+	return nil
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j *JWTKeys) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.cfg.JWKSURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks returned %d", resp.StatusCode)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			slog.Warn("skipping jwk", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	j.mu.Lock()
+	j.keys, j.fetchedAt = keys, time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64Int(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64Int(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64Int(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64Int(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, errors.New("unsupported key type " + k.Kty)
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
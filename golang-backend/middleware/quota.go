@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-backend/apierr"
+	"golang-backend/cache"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	scanCountKey  = "scan_count"
+	quotaUsageKey = "quota_usage"
+)
+
+// quotaLocation is where quota days and months start, matching the Thai
+// business day the tenants are billed on.
+var quotaLocation = time.FixedZone("ICT", 7*60*60)
+
+// quotaNow is the clock quota periods are taken from.
+var quotaNow = time.Now
+
+type quotaPeriod struct {
+	name   string
+	limit  int64
+	key    string
+	resets time.Time
+	used   int64
+}
+
+// quotaUsage is the tenant's usage of its quotas, counting the scans the
+// request reserved in each of them.
+type quotaUsage struct {
+	tenant   string
+	now      time.Time
+	periods  []quotaPeriod
+	counter  cache.Counter
+	reserved int
+}
+
+// SetScanCount records how many scans a request performed, for requests such
+// as batch uploads that scan more than one image. Requests count as one scan
+// otherwise.
+func SetScanCount(c *gin.Context, n int) {
+	c.Set(scanCountKey, n)
+}
+
+// TryReserveScans makes sure n scans are reserved for the request, for
+// requests that learn how many they perform only once running. It returns
+// the period of the first quota they would go over, reserving nothing
+// more, or "" once they are reserved.
+func TryReserveScans(c *gin.Context, n int) string {
+	v, ok := c.Get(quotaUsageKey)
+	if !ok {
+		return ""
+	}
+	u := v.(*quotaUsage)
+	q, over := u.reserve(context.WithoutCancel(c.Request.Context()), n-u.reserved)
+	if !over {
+		return ""
+	}
+	return q.name
+}
+
+// ReserveScans answers 429 and returns false unless n scans fit in what is
+// left of the caller's quotas, reserving them. Quota itself only reserves
+// one, so handlers scanning several images call it before they start.
+func ReserveScans(c *gin.Context, n int) bool {
+	v, ok := c.Get(quotaUsageKey)
+	if !ok {
+		return true
+	}
+	u := v.(*quotaUsage)
+	ctx := context.WithoutCancel(c.Request.Context())
+	if q, over := u.reserve(ctx, n-u.reserved); over {
+		// The request fails, so what it already reserved is given back.
+		q.used -= int64(u.reserved)
+		u.settle(ctx, 0)
+		u.setHeaders(c)
+		quotaExceeded(c, u, q)
+		return false
+	}
+	u.setHeaders(c)
+	return true
+}
+
+// QuotaExceededError reports the quota a scan was refused under.
+type QuotaExceededError struct {
+	Period   string
+	Tenant   string
+	Limit    int64
+	Used     int64
+	ResetsAt time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d scans exceeded for tenant %s", e.Period, e.Limit, e.Tenant)
+}
+
+// ReserveQuota reserves n scans in the quotas of tenant as Quota does, for
+// callers outside gin such as the gRPC API. It returns a
+// *QuotaExceededError when they do not fit, and otherwise a function to
+// call with how many scans succeeded, which gives back the rest.
+func ReserveQuota(ctx context.Context, counter cache.Counter, tenants *Tenants, tenant string, n int) (func(n int), error) {
+	t, ok := tenants.Get(tenant)
+	if !ok || (t.DailyQuota <= 0 && t.MonthlyQuota <= 0) {
+		return func(int) {}, nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	u := newQuotaUsage(counter, t)
+	if q, over := u.reserve(ctx, n); over {
+		return nil, &QuotaExceededError{Period: q.name, Tenant: t.ID, Limit: q.limit, Used: q.used, ResetsAt: q.resets}
+	}
+	return func(n int) { u.settle(ctx, n) }, nil
+}
+
+// newQuotaUsage lists the quotas of t in the periods now falls in, with
+// nothing used or reserved yet.
+func newQuotaUsage(counter cache.Counter, t storage.Tenant) *quotaUsage {
+	now := quotaNow().In(quotaLocation)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, quotaLocation)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, quotaLocation)
+	u := &quotaUsage{tenant: t.ID, now: now, counter: counter}
+	if t.DailyQuota > 0 {
+		u.periods = append(u.periods, quotaPeriod{name: "daily", limit: t.DailyQuota, key: "quota:" + t.ID + ":" + day.Format("20060102"), resets: day.AddDate(0, 0, 1)})
+	}
+	if t.MonthlyQuota > 0 {
+		u.periods = append(u.periods, quotaPeriod{name: "monthly", limit: t.MonthlyQuota, key: "quota:" + t.ID + ":" + month.Format("200601"), resets: month.AddDate(0, 1, 0)})
+	}
+	return u
+}
+
+// reserve counts n more scans against every quota at once, so concurrent
+// requests cannot all fit in the same remainder. When one goes over, the
+// n scans are given back and that quota is returned. Periods whose counter
+// fails are left out, letting the request through.
+func (u *quotaUsage) reserve(ctx context.Context, n int) (quotaPeriod, bool) {
+	if n <= 0 {
+		return quotaPeriod{}, false
+	}
+	var kept []quotaPeriod
+	over := -1
+	for _, q := range u.periods {
+		used, err := u.counter.Incr(ctx, q.key, int64(n), q.resets.Sub(u.now)+time.Hour)
+		if err != nil {
+			slog.Warn("reserve quota failed", "tenant", u.tenant, "error", err)
+			continue
+		}
+		q.used = used
+		kept = append(kept, q)
+		if used > q.limit && over < 0 {
+			over = len(kept) - 1
+		}
+	}
+	if over >= 0 {
+		for i, q := range kept {
+			u.incr(ctx, q, -n)
+			kept[i].used -= int64(n)
+		}
+	}
+	u.periods = kept
+	if over >= 0 {
+		return kept[over], true
+	}
+	u.reserved += n
+	return quotaPeriod{}, false
+}
+
+// settle keeps n of the scans reserved and gives back the others.
+func (u *quotaUsage) settle(ctx context.Context, n int) {
+	if n == u.reserved {
+		return
+	}
+	for i, q := range u.periods {
+		u.incr(ctx, q, n-u.reserved)
+		u.periods[i].used += int64(n - u.reserved)
+	}
+	u.reserved = n
+}
+
+func (u *quotaUsage) incr(ctx context.Context, q quotaPeriod, n int) {
+	if _, err := u.counter.Incr(ctx, q.key, int64(n), q.resets.Sub(u.now)+time.Hour); err != nil {
+		slog.Warn("record quota usage failed", "tenant", u.tenant, "error", err)
+	}
+}
+
+// read loads how much of each quota is used, leaving out periods whose
+// counter fails.
+func (u *quotaUsage) read(ctx context.Context) {
+	var kept []quotaPeriod
+	for _, q := range u.periods {
+		used, err := u.counter.Get(ctx, q.key)
+		if err != nil {
+			slog.Warn("read quota usage failed", "tenant", u.tenant, "error", err)
+			continue
+		}
+		q.used = used
+		kept = append(kept, q)
+	}
+	u.periods = kept
+}
+
+// setHeaders reports each quota as it stands with the request's scans.
+func (u *quotaUsage) setHeaders(c *gin.Context) {
+	for _, q := range u.periods {
+		c.Header(fmt.Sprintf("X-Quota-%s-Limit", q.name), strconv.FormatInt(q.limit, 10))
+		c.Header(fmt.Sprintf("X-Quota-%s-Remaining", q.name), strconv.FormatInt(max(q.limit-q.used, 0), 10))
+		c.Header(fmt.Sprintf("X-Quota-%s-Reset", q.name), strconv.FormatInt(q.resets.Unix(), 10))
+	}
+}
+
+func quotaExceeded(c *gin.Context, u *quotaUsage, q quotaPeriod) {
+	c.Header("Retry-After", strconv.Itoa(int(q.resets.Sub(u.now).Seconds())+1))
+	apierr.Abort(c, http.StatusTooManyRequests, apierr.QuotaExceeded, gin.H{
+		"period":    q.name,
+		"tenant":    u.tenant,
+		"limit":     q.limit,
+		"used":      q.used,
+		"resets_at": q.resets,
+	})
+}
+
+// QuotaHeaders reports the caller's quotas on every response, so clients
+// can see what is left before they scan. Quota reports them again on scan
+// routes, counting the request's own scans.
+func QuotaHeaders(counter cache.Counter, tenants *Tenants) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, _ := PrincipalFrom(c)
+		if t, ok := tenants.Get(p.Tenant); ok && (t.DailyQuota > 0 || t.MonthlyQuota > 0) {
+			u := newQuotaUsage(counter, t)
+			u.read(c.Request.Context())
+			u.setHeaders(c)
+		}
+		c.Next()
+	}
+}
+
+// Quota enforces the daily and monthly scan quotas of the caller's tenant,
+// keeping usage in counter. A request reserves one scan in every quota
+// before it runs, and one scanning more reserves them all, see
+// ReserveScans. Once it is done, only the scans of a successful request
+// stay counted. Counter errors are logged and the request is let through.
+func Quota(counter cache.Counter, tenants *Tenants) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, _ := PrincipalFrom(c)
+		t, ok := tenants.Get(p.Tenant)
+		if !ok || (t.DailyQuota <= 0 && t.MonthlyQuota <= 0) {
+			c.Next()
+			return
+		}
+
+		ctx := context.WithoutCancel(c.Request.Context())
+		u := newQuotaUsage(counter, t)
+		c.Set(quotaUsageKey, u)
+		if !ReserveScans(c, 1) {
+			return
+		}
+
+		// Scans of a request that panics are given back too.
+		n := 0
+		defer func() { u.settle(ctx, n) }()
+		c.Next()
+
+		if c.Writer.Status() < http.StatusBadRequest {
+			n = 1
+			if v, ok := c.Get(scanCountKey); ok {
+				n = v.(int)
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-backend/cache"
+	"golang-backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quotaRouter serves POST /scan for tenant t1 through Quota. The handler
+// answers with the status in the X-Status header and scans the number of
+// images in X-Scans, waiting on release first when it is not nil.
+func quotaRouter(counter cache.Counter, daily, monthly int64, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	tenants := NewTenants([]config.TenantConfig{{ID: "t1", DailyQuota: daily, MonthlyQuota: monthly}}, nil)
+	r := gin.New()
+	r.POST("/scan", func(c *gin.Context) {
+		c.Set(principalKey, Principal{KeyID: "k1", Tenant: "t1"})
+	}, Quota(counter, tenants), func(c *gin.Context) {
+		if release != nil {
+			<-release
+		}
+		if c.GetHeader("X-Scans") == "3" {
+			if !ReserveScans(c, 3) {
+				return
+			}
+			SetScanCount(c, 3)
+		}
+		if c.GetHeader("X-Status") == "500" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func quotaRequest(r http.Handler, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/scan", nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func setQuotaNow(t *testing.T, now time.Time) {
+	t.Helper()
+	quotaNow = func() time.Time { return now }
+	t.Cleanup(func() { quotaNow = time.Now })
+}
+
+func TestQuota(t *testing.T) {
+	type step struct {
+		header    []string
+		status    int
+		remaining string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"daily limit", []step{
+			{nil, 200, "2"},
+			{nil, 200, "1"},
+			{nil, 200, "0"},
+			{nil, 429, "0"},
+		}},
+		{"failures are given back", []step{
+			{[]string{"X-Status", "500"}, 500, "2"},
+			{nil, 200, "2"},
+		}},
+		{"batch reserves every scan", []step{
+			{nil, 200, "2"},
+			{[]string{"X-Scans", "3"}, 429, "2"},
+			{nil, 200, "1"},
+		}},
+		{"batch fits", []step{
+			{[]string{"X-Scans", "3"}, 200, "0"},
+			{nil, 429, "0"},
+		}},
+	}
+	for _, tt := range tests {
+		r := quotaRouter(cache.NewMemoryCounter(), 3, 0, nil)
+		for i, s := range tt.steps {
+			w := quotaRequest(r, s.header...)
+			if w.Code != s.status {
+				t.Errorf("%s: request %d: status %d, want %d", tt.name, i, w.Code, s.status)
+			}
+			if got := w.Header().Get("X-Quota-daily-Remaining"); got != s.remaining {
+				t.Errorf("%s: request %d: remaining %q, want %q", tt.name, i, got, s.remaining)
+			}
+		}
+	}
+}
+
+func TestQuotaConcurrent(t *testing.T) {
+	const limit, requests = 5, 40
+	counter := cache.NewMemoryCounter()
+	release := make(chan struct{})
+	r := quotaRouter(counter, limit, 0, release)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		codes = map[int]int{}
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := quotaRequest(r)
+			mu.Lock()
+			codes[w.Code]++
+			mu.Unlock()
+		}()
+	}
+	// The refused requests return at once; let the others finish together.
+	for {
+		mu.Lock()
+		refused := codes[http.StatusTooManyRequests]
+		mu.Unlock()
+		if refused == requests-limit {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if codes[http.StatusOK] != limit {
+		t.Errorf("%d requests succeeded, want %d: %v", codes[http.StatusOK], limit, codes)
+	}
+	key := "quota:t1:" + quotaNow().In(quotaLocation).Format("20060102")
+	if used, _ := counter.Get(context.Background(), key); used != limit {
+		t.Errorf("counted %d scans, want %d", used, limit)
+	}
+}
+
+func TestQuotaPeriods(t *testing.T) {
+	ict := func(s string) time.Time {
+		v, err := time.ParseInLocation(time.DateTime, s, quotaLocation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name           string
+		first, second  time.Time
+		daily, monthly int64
+		// resets is when the quota refusing the second request resets, or
+		// zero when the second request starts a new period.
+		resets time.Time
+	}{
+		{"same ICT day", ict("2026-10-14 00:00:00"), ict("2026-10-14 23:59:59"), 1, 0, ict("2026-10-15 00:00:00")},
+		{"next ICT day", ict("2026-10-14 23:59:59"), ict("2026-10-15 00:00:00"), 1, 0, time.Time{}},
+		// 17:00 UTC is already midnight in Bangkok.
+		{"UTC evening is the next ICT day", time.Date(2026, 10, 14, 16, 59, 0, 0, time.UTC), time.Date(2026, 10, 14, 17, 0, 0, 0, time.UTC), 1, 0, time.Time{}},
+		{"same ICT month", ict("2026-10-01 00:00:00"), ict("2026-10-31 23:59:59"), 0, 1, ict("2026-11-01 00:00:00")},
+		{"next ICT month", ict("2026-10-31 23:59:59"), ict("2026-11-01 00:00:00"), 0, 1, time.Time{}},
+		{"next year", ict("2026-12-31 23:59:59"), ict("2027-01-01 00:00:00"), 1, 1, time.Time{}},
+	}
+	for _, tt := range tests {
+		r := quotaRouter(cache.NewMemoryCounter(), tt.daily, tt.monthly, nil)
+		setQuotaNow(t, tt.first)
+		if w := quotaRequest(r); w.Code != http.StatusOK {
+			t.Fatalf("%s: first request: status %d", tt.name, w.Code)
+		}
+		setQuotaNow(t, tt.second)
+		w := quotaRequest(r)
+		if tt.resets.IsZero() {
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: second request: status %d, want 200", tt.name, w.Code)
+			}
+			continue
+		}
+		period := "daily"
+		if tt.daily == 0 {
+			period = "monthly"
+		}
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("%s: second request: status %d, want 429", tt.name, w.Code)
+		}
+		if got, want := w.Header().Get("X-Quota-"+period+"-Reset"), strconv.FormatInt(tt.resets.Unix(), 10); got != want {
+			t.Errorf("%s: %s reset %s, want %s", tt.name, period, got, want)
+		}
+	}
+}
+
+func TestReserveQuota(t *testing.T) {
+	counter := cache.NewMemoryCounter()
+	tenants := NewTenants([]config.TenantConfig{{ID: "t1", DailyQuota: 2}}, nil)
+	ctx := context.Background()
+
+	settle, err := ReserveQuota(ctx, counter, tenants, "t1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(0) // the call failed; its scan is given back
+	for i := 0; i < 2; i++ {
+		if settle, err = ReserveQuota(ctx, counter, tenants, "t1", 1); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		settle(1)
+	}
+	_, err = ReserveQuota(ctx, counter, tenants, "t1", 1)
+	var exceeded *QuotaExceededError
+	if !errors.As(err, &exceeded) || exceeded.Period != "daily" || exceeded.Used != 2 {
+		t.Errorf("third call: %v, want the daily quota exceeded with 2 used", err)
+	}
+	if _, err := ReserveQuota(ctx, counter, tenants, "unknown", 1); err != nil {
+		t.Errorf("tenant without quotas: %v", err)
+	}
+}
+
+func TestQuotaHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	counter := cache.NewMemoryCounter()
+	tenants := NewTenants([]config.TenantConfig{{ID: "t1", DailyQuota: 3, MonthlyQuota: 10}, {ID: "free"}}, nil)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(principalKey, Principal{KeyID: "k1", Tenant: c.GetHeader("X-Tenant")})
+	}, QuotaHeaders(counter, tenants))
+	r.GET("/usage", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/scan", Quota(counter, tenants), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		method, tenant string
+		// daily and monthly are the remaining scans reported, limit the
+		// daily limit; all are empty for a tenant without quotas.
+		daily, monthly, limit string
+	}{
+		{http.MethodGet, "t1", "3", "10", "3"},
+		{http.MethodPost, "t1", "2", "9", "3"},
+		{http.MethodGet, "t1", "2", "9", "3"},
+		{http.MethodPost, "t1", "1", "8", "3"},
+		{http.MethodGet, "free", "", "", ""},
+	}
+	for i, tt := range tests {
+		path := "/usage"
+		if tt.method == http.MethodPost {
+			path = "/scan"
+		}
+		req := httptest.NewRequest(tt.method, path, nil)
+		req.Header.Set("X-Tenant", tt.tenant)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		h := w.Header()
+		daily, monthly, limit := h.Get("X-Quota-daily-Remaining"), h.Get("X-Quota-monthly-Remaining"), h.Get("X-Quota-daily-Limit")
+		if daily != tt.daily || monthly != tt.monthly || limit != tt.limit {
+			t.Errorf("request %d %s %s: daily %q of %q, monthly %q; want %q of %q, %q",
+				i, tt.method, tt.tenant, daily, limit, monthly, tt.daily, tt.limit, tt.monthly)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang-backend/apierr"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+const limiterIdleTTL = 10 * time.Minute
+
+// rateNow is the clock of the rate limits, replaced in tests.
+var rateNow = time.Now
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      rate.Limit
+	burst     int
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// RateLimit applies a token bucket per API key, falling back to the client IP
+// for unauthenticated requests. limit takes a token and answers 429 once
+// they run out; report only describes the bucket, for routes that are not
+// limited, so that every response carries X-RateLimit-Limit, the burst
+// allowed, X-RateLimit-Remaining, the requests that could be made right
+// away, and X-RateLimit-Reset, the Unix time the bucket is full again.
+func RateLimit(perSecond float64, burst int) (limit, report gin.HandlerFunc) {
+	rl := newRateLimiter(perSecond, burst)
+	limit = func(c *gin.Context) {
+		if rl.take(c, bucketKey(c)) {
+			c.Next()
+		}
+	}
+	report = func(c *gin.Context) {
+		rl.setHeaders(c, rl.get(bucketKey(c)), rateNow())
+		c.Next()
+	}
+	return limit, report
+}
+
+// RateLimitIP applies a token bucket per client IP to every request, ahead
+// of authentication, so requests with made-up or revoked keys are limited
+// too. Its headers are replaced by RateLimit's report on requests it lets
+// through.
+func RateLimitIP(perSecond float64, burst int) gin.HandlerFunc {
+	rl := newRateLimiter(perSecond, burst)
+	return func(c *gin.Context) {
+		if rl.take(c, "ip:"+c.ClientIP()) {
+			c.Next()
+		}
+	}
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate.Limit(perSecond),
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// take takes a token from key's bucket, answering 429 with Retry-After
+// when there is none.
+func (rl *rateLimiter) take(c *gin.Context, key string) bool {
+	lim, now := rl.get(key), rateNow()
+	r := lim.ReserveN(now, 1)
+	d := r.DelayFrom(now)
+	if d > 0 {
+		r.CancelAt(now)
+	}
+	rl.setHeaders(c, lim, now)
+	if d > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+		apierr.Abort(c, http.StatusTooManyRequests, apierr.RateLimited, nil)
+		return false
+	}
+	return true
+}
+
+func bucketKey(c *gin.Context) string {
+	if p, ok := PrincipalFrom(c); ok {
+		return "key:" + p.KeyID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func (rl *rateLimiter) setHeaders(c *gin.Context, lim *rate.Limiter, now time.Time) {
+	tokens := lim.TokensAt(now)
+	full := now.Add(time.Duration((float64(rl.burst) - tokens) / float64(rl.rate) * float64(time.Second)))
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rl.burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(max(int(math.Floor(tokens)), 0)))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(full.UnixNano())/1e9)), 10))
+}
+
+func (rl *rateLimiter) get(key string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rateNow()
+	if now.Sub(rl.lastSweep) > time.Minute {
+		for k, b := range rl.buckets {
+			if now.Sub(b.lastSeen) > limiterIdleTTL {
+				delete(rl.buckets, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rl.rate, rl.burst)}
+		rl.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter
+}
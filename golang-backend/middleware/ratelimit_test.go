@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setRateNow(t *testing.T, now *time.Time) {
+	t.Helper()
+	rateNow = func() time.Time { return *now }
+	t.Cleanup(func() { rateNow = time.Now })
+}
+
+func rateRequest(r http.Handler, method, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Unix(1_800_000_000, 0)
+	setRateNow(t, &now)
+	limit, report := RateLimit(1, 3)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(principalKey, Principal{KeyID: c.GetHeader("X-Key")})
+	}, report)
+	r.GET("/usage", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/scan", limit, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	type step struct {
+		advance          time.Duration
+		method, key      string
+		status           int
+		remaining, reset string
+		retryAfter       string
+	}
+	start := now
+	at := func(d time.Duration) string { return strconv.FormatInt(start.Add(d).Unix(), 10) }
+	steps := []step{
+		{0, http.MethodGet, "k1", 200, "3", at(0), ""},
+		{0, http.MethodPost, "k1", 200, "2", at(time.Second), ""},
+		{0, http.MethodPost, "k1", 200, "1", at(2 * time.Second), ""},
+		{0, http.MethodPost, "k1", 200, "0", at(3 * time.Second), ""},
+		{0, http.MethodPost, "k1", 429, "0", at(3 * time.Second), "1"},
+		// Reports do not take a token.
+		{0, http.MethodGet, "k1", 200, "0", at(3 * time.Second), ""},
+		// Other keys have their own bucket.
+		{0, http.MethodPost, "k2", 200, "2", at(time.Second), ""},
+		// A token comes back every second: 1.5 have at 1.5s, leaving half
+		// a token, and the bucket is full again at 4s.
+		{1500 * time.Millisecond, http.MethodPost, "k1", 200, "0", at(4 * time.Second), ""},
+		{0, http.MethodPost, "k1", 429, "0", at(4 * time.Second), "1"},
+		// It fills up to the burst and no further.
+		{time.Minute, http.MethodGet, "k1", 200, "3", at(62 * time.Second), ""},
+	}
+	for i, s := range steps {
+		now = now.Add(s.advance)
+		w := rateRequest(r, s.method, map[string]string{http.MethodGet: "/usage", http.MethodPost: "/scan"}[s.method], "X-Key", s.key)
+		h := w.Header()
+		if w.Code != s.status || h.Get("X-RateLimit-Remaining") != s.remaining || h.Get("X-RateLimit-Reset") != s.reset || h.Get("Retry-After") != s.retryAfter {
+			t.Errorf("step %d: status %d remaining %q reset %q retry after %q; want %d %q %q %q", i, w.Code,
+				h.Get("X-RateLimit-Remaining"), h.Get("X-RateLimit-Reset"), h.Get("Retry-After"), s.status, s.remaining, s.reset, s.retryAfter)
+		}
+		if got := h.Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("step %d: limit %q, want 3", i, got)
+		}
+	}
+}
+
+func TestRateLimitIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Unix(1_800_000_000, 0)
+	setRateNow(t, &now)
+	r := gin.New()
+	r.Use(RateLimitIP(1, 2), APIKey(StaticKeys([]string{"good"}, []string{RoleScanner})))
+	r.GET("/scans", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Guessed keys are refused and use up the IP's tokens, so the IP is
+	// limited before it can try more.
+	for i, want := range []int{401, 401, 429, 429} {
+		if w := rateRequest(r, http.MethodGet, "/scans", "X-API-Key", "guess"+strconv.Itoa(i)); w.Code != want {
+			t.Errorf("guess %d: status %d, want %d", i, w.Code, want)
+		}
+	}
+	if w := rateRequest(r, http.MethodGet, "/scans", "X-API-Key", "good"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("valid key from a limited IP: status %d retry after %q, want 429 and 1", w.Code, w.Header().Get("Retry-After"))
+	}
+	now = now.Add(time.Second)
+	if w := rateRequest(r, http.MethodGet, "/scans", "X-API-Key", "good"); w.Code != http.StatusOK {
+		t.Errorf("valid key after refill: status %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"golang-backend/apierr"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Permission string
+
+const (
+	PermScan Permission = "scan"
+	// PermRead reads scans made with the caller's own key; PermReadAll
+	// reads any scan.
+	PermRead    Permission = "scan.read"
+	PermReadAll Permission = "scan.read_all"
+	PermList    Permission = "scan.list"
+	PermCorrect Permission = "scan.correct"
+	PermDelete  Permission = "scan.delete"
+	// PermSearch looks scans up by citizen ID or name.
+	PermSearch Permission = "scan.search"
+	// PermPII sees stored results, raw OCR output and images unmasked.
+	PermPII   Permission = "pii.read"
+	PermAdmin Permission = "admin"
+)
+
+const (
+	RoleScanner  = "scanner"
+	RoleReviewer = "reviewer"
+	RoleAdmin    = "admin"
+)
+
+// rolePermissions keeps PII away from admins, who manage scans without
+// needing to see their contents.
+var rolePermissions = map[string][]Permission{
+	RoleScanner:  {PermScan, PermRead},
+	RoleReviewer: {PermRead, PermReadAll, PermList, PermCorrect, PermPII, PermSearch},
+	RoleAdmin:    {PermRead, PermReadAll, PermList, PermDelete, PermAdmin},
+}
+
+// KnownRole reports whether role grants any permissions.
+func KnownRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func (p Principal) Can(perm Permission) bool {
+	for _, r := range p.Roles {
+		if slices.Contains(rolePermissions[r], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the caller has perm. Everything is allowed when
+// authentication is disabled and no principal is set.
+func Can(c *gin.Context, perm Permission) bool {
+	p, ok := PrincipalFrom(c)
+	return !ok || p.Can(perm)
+}
+
+// Require rejects callers without perm with 403.
+func Require(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Can(c, perm) {
+			apierr.Abort(c, http.StatusForbidden, apierr.PermissionDenied, gin.H{"permission": perm})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"golang-backend/apierr"
+	"golang-backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a later handler into an INTERNAL_ERROR
+// response, logging the value and stack with the request ID. Neither is
+// sent to the client.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			log := logging.FromContext(c.Request.Context())
+			if err, ok := p.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				// The client went away; there is no one to answer.
+				log.Warn("client disconnected", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+				c.Abort()
+				return
+			}
+			log.Error("panic recovered",
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"panic", p,
+				"stack", string(debug.Stack()),
+			)
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			apierr.Abort(c, http.StatusInternalServerError, apierr.InternalError, nil)
+		}()
+		c.Next()
+	}
+}
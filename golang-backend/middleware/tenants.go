@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang-backend/apierr"
+	"golang-backend/config"
+	"golang-backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storedTTL bounds how long a stored key or tenant is cached, and so how
+// long a revocation or quota change takes to reach every replica.
+const storedTTL = 30 * time.Second
+
+type KeyRepository interface {
+	FindKey(ctx context.Context, hash string) (*storage.APIKey, error)
+	GetTenant(ctx context.Context, id string) (*storage.Tenant, error)
+}
+
+type cached[T any] struct {
+	value   T
+	ok      bool
+	expires time.Time
+}
+
+// ttlCache keeps lookups, including misses, for storedTTL.
+type ttlCache[T any] struct {
+	mu        sync.Mutex
+	items     map[string]cached[T]
+	lastSweep time.Time
+}
+
+func (c *ttlCache[T]) get(key string, load func() (T, bool, error)) (T, bool) {
+	now := time.Now()
+	c.mu.Lock()
+	if now.Sub(c.lastSweep) > time.Minute {
+		for k, v := range c.items {
+			if now.After(v.expires) {
+				delete(c.items, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	v, hit := c.items[key]
+	c.mu.Unlock()
+	if hit && now.Before(v.expires) {
+		return v.value, v.ok
+	}
+
+	value, ok, err := load()
+	if err != nil {
+		// Keep serving a stale entry rather than failing every request
+		// while the database is unavailable.
+		if hit {
+			return v.value, v.ok
+		}
+		return value, ok
+	}
+	c.mu.Lock()
+	if c.items == nil {
+		c.items = make(map[string]cached[T])
+	}
+	c.items[key] = cached[T]{value: value, ok: ok, expires: now.Add(storedTTL)}
+	c.mu.Unlock()
+	return value, ok
+}
+
+type storedKeys struct {
+	repo  KeyRepository
+	cache ttlCache[*storage.APIKey]
+}
+
+// StoredKeys is a KeyStore for keys issued through the admin API. The
+// principal's KeyID is the stored key ID, so it survives rotation.
+func StoredKeys(repo KeyRepository) KeyStore {
+	return &storedKeys{repo: repo}
+}
+
+func (s *storedKeys) Lookup(key string) (Principal, bool) {
+	hash := storage.HashKey(key)
+	k, ok := s.cache.get(hash, func() (*storage.APIKey, bool, error) {
+		k, err := s.repo.FindKey(context.Background(), hash)
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, false, nil
+		}
+		if err != nil {
+			slog.Warn("look up api key failed", "error", err)
+			return nil, false, err
+		}
+		return k, true, nil
+	})
+	if !ok || !k.Matches(hash, time.Now()) {
+		return Principal{}, false
+	}
+	return Principal{KeyID: k.ID, Tenant: k.Tenant, Roles: k.Roles}, true
+}
+
+// Tenants resolves tenant settings, preferring those stored through the
+// admin API over config.
+type Tenants struct {
+	config map[string]config.TenantConfig
+	repo   KeyRepository
+	cache  ttlCache[storage.Tenant]
+}
+
+// NewTenants resolves tenants from cfg and, when repo is not nil, storage.
+func NewTenants(cfg []config.TenantConfig, repo KeyRepository) *Tenants {
+	t := &Tenants{config: make(map[string]config.TenantConfig, len(cfg)), repo: repo}
+	for _, c := range cfg {
+		t.config[c.ID] = c
+	}
+	return t
+}
+
+// Get returns the settings of tenant id, if it is known.
+func (t *Tenants) Get(id string) (storage.Tenant, bool) {
+	if id == "" {
+		return storage.Tenant{}, false
+	}
+	c, inConfig := t.config[id]
+	fromConfig := storage.Tenant{ID: id, DailyQuota: c.DailyQuota, MonthlyQuota: c.MonthlyQuota, WebhookSecret: c.WebhookSecret}
+	if t.repo == nil {
+		return fromConfig, inConfig
+	}
+	return t.cache.get(id, func() (storage.Tenant, bool, error) {
+		stored, err := t.repo.GetTenant(context.Background(), id)
+		if errors.Is(err, storage.ErrTenantNotFound) {
+			return fromConfig, inConfig, nil
+		}
+		if err != nil {
+			slog.Warn("look up tenant failed", "tenant", id, "error", err)
+			return fromConfig, inConfig, err
+		}
+		if stored.WebhookSecret == "" {
+			stored.WebhookSecret = c.WebhookSecret
+		}
+		return *stored, true, nil
+	})
+}
+
+// TenantEnabled rejects callers whose tenant has been disabled with 403.
+func TenantEnabled(tenants *Tenants) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, _ := PrincipalFrom(c)
+		if t, ok := tenants.Get(p.Tenant); ok && t.Disabled {
+			apierr.Abort(c, http.StatusForbidden, apierr.TenantDisabled, gin.H{"tenant": t.ID})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const versionKey = "api_version"
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+(/|$)`)
+
+// Version tags requests with the API version of the group that routed
+// them, so handlers can shape responses per version.
+func Version(v int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(versionKey, v)
+		c.Next()
+	}
+}
+
+// VersionFrom returns the request's API version, 1 when untagged.
+func VersionFrom(c *gin.Context) int {
+	if v, ok := c.Get(versionKey); ok {
+		return v.(int)
+	}
+	return 1
+}
+
+// VersionPath prefixes path with the request's versioned API root, such as
+// "/v1/scans/abc" for "/scans/abc".
+func VersionPath(c *gin.Context, path string) string {
+	return fmt.Sprintf("/v%d%s", VersionFrom(c), path)
+}
+
+// UnversionedRoute strips the version prefix from a route, so "/v1/scans"
+// and the legacy "/scans" are treated alike.
+func UnversionedRoute(route string) string {
+	if loc := versionPrefix.FindStringIndex(route); loc != nil {
+		return "/" + route[loc[1]:]
+	}
+	return route
+}
+
+// Deprecated marks responses of legacy routes with the Deprecation header,
+// a Link to the successor under prefix and, when sunset is set, the Sunset
+// header. deprecatedAt may be zero.
+func Deprecated(prefix string, deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	deprecation := "true"
+	if !deprecatedAt.IsZero() {
+		deprecation = fmt.Sprintf("@%d", deprecatedAt.Unix())
+	}
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("Deprecation", deprecation)
+		if !sunset.IsZero() {
+			h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		h.Add("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", prefix, c.Request.URL.Path))
+		c.Next()
+	}
+}
@@ -0,0 +1,84 @@
+// Package netguard keeps server-side requests to client-supplied URLs away
+// from private, loopback, link-local and other non-public addresses.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbidden is returned when a connection would reach a non-public
+// address.
+var ErrForbidden = errors.New("address is not allowed")
+
+// denied are ranges IsGlobalUnicast counts as global that are not on the
+// public internet, or reach it only through gateways that may sit inside
+// the network.
+var denied = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard only
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// The well-known NAT64 prefix and 6to4 carry an IPv4 address, which is
+// checked in their place.
+var (
+	nat64  = netip.MustParsePrefix("64:ff9b::/96")
+	sixTo4 = netip.MustParsePrefix("2002::/16")
+)
+
+// Dialer returns a dialer that refuses non-public addresses. The check runs
+// on the resolved IP at connect time so DNS rebinding and redirects cannot
+// reach internal hosts. allowPrivate turns it off.
+func Dialer(timeout time.Duration, allowPrivate bool) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !Public(ap.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbidden, ap.Addr())
+			}
+			return nil
+		},
+	}
+}
+
+// Public reports whether a is a global unicast address outside the private
+// and denied ranges. NAT64 and 6to4 addresses are judged by the IPv4
+// address they embed.
+func Public(a netip.Addr) bool {
+	a = a.Unmap()
+	switch b := a.As16(); {
+	case nat64.Contains(a):
+		a = netip.AddrFrom4([4]byte(b[12:]))
+	case sixTo4.Contains(a):
+		a = netip.AddrFrom4([4]byte(b[2:6]))
+	}
+	if !a.IsGlobalUnicast() || a.IsPrivate() || a.IsLoopback() || a.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, p := range denied {
+		if p.Contains(a) {
+			return false
+		}
+	}
+	return true
+}
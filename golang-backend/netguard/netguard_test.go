@@ -0,0 +1,82 @@
+package netguard
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"203.0.113.7", false},
+		{"2606:4700::1111", true},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"192.0.0.8", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.20.0.1", true},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:8.8.8.8", true},
+		{"64:ff9b::a00:1", false},     // NAT64 of 10.0.0.1
+		{"64:ff9b::7f00:1", false},    // NAT64 of 127.0.0.1
+		{"64:ff9b::a9fe:a9fe", false}, // NAT64 of 169.254.169.254
+		{"64:ff9b::808:808", true},    // NAT64 of 8.8.8.8
+		{"64:ff9b:1::808:808", false}, // local-use NAT64
+		{"2002:a00:1::1", false},      // 6to4 of 10.0.0.1
+		{"2002:808:808::1", true},     // 6to4 of 8.8.8.8
+		{"100::1", false},
+		{"2001:db8::1", false},
+	}
+	for _, tt := range tests {
+		if got := Public(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("Public(%s) = %t, want %t", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	if _, err := Dialer(time.Second, false).Dial("tcp", l.Addr().String()); !errors.Is(err, ErrForbidden) {
+		t.Errorf("dial loopback: %v, want ErrForbidden", err)
+	}
+	c, err := Dialer(time.Second, true).Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("dial loopback with private allowed: %v", err)
+	}
+	c.Close()
+}
@@ -0,0 +1,201 @@
+// Package openapi builds an OpenAPI 3 document for the REST API and serves
+// it with Swagger UI. Operations are described next to the handlers, and
+// schemas are generated from the Go types they read and write, so the
+// document follows the code.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Security   []Requirement       `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Security    []Requirement        `json:"security,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	// Public marks an endpoint that needs no credentials.
+	Public bool `json:"-"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]*Response      `json:"responses,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// Requirement names the security schemes an operation accepts.
+type Requirement map[string][]string
+
+// Spec collects operations and the schemas they reference.
+type Spec struct {
+	doc   Document
+	refs  map[string]string
+	enums map[reflect.Type][]string
+}
+
+func New(title, version string) *Spec {
+	return &Spec{
+		doc: Document{
+			OpenAPI:    "3.0.3",
+			Info:       Info{Title: title, Version: version},
+			Paths:      map[string]PathItem{},
+			Components: Components{Schemas: map[string]*Schema{}, Responses: map[string]*Response{}},
+		},
+		refs:  map[string]string{},
+		enums: map[reflect.Type][]string{},
+	}
+}
+
+// Enum lists the values of v's named string type. Call it before the
+// schemas that use the type.
+func (s *Spec) Enum(v any, values []string) {
+	s.enums[reflect.TypeOf(v)] = values
+}
+
+// Describe sets the document description.
+func (s *Spec) Describe(description string) {
+	s.doc.Info.Description = description
+}
+
+// Security adds a security scheme and requires it on every operation that
+// does not override Security.
+func (s *Spec) Security(name string, scheme SecurityScheme) {
+	if s.doc.Components.SecuritySchemes == nil {
+		s.doc.Components.SecuritySchemes = map[string]SecurityScheme{}
+	}
+	s.doc.Components.SecuritySchemes[name] = scheme
+	s.doc.Security = append(s.doc.Security, Requirement{name: {}})
+}
+
+// Response registers a reusable response and returns a reference to it.
+func (s *Spec) Response(name string, r *Response) *Response {
+	s.doc.Components.Responses[name] = r
+	return &Response{Ref: "#/components/responses/" + name}
+}
+
+// Add documents the route method path, written as registered with gin.
+// Path parameters such as :id are declared automatically.
+func (s *Spec) Add(method, path string, op Operation) {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			name := seg[1:]
+			segments[i] = "{" + name + "}"
+			if !slices.ContainsFunc(op.Parameters, func(p Parameter) bool { return p.In == "path" && p.Name == name }) {
+				op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: String()})
+			}
+		}
+	}
+	if op.Public {
+		op.Security = []Requirement{{}}
+	}
+	path = strings.Join(segments, "/")
+	if s.doc.Paths[path] == nil {
+		s.doc.Paths[path] = PathItem{}
+	}
+	s.doc.Paths[path][strings.ToLower(method)] = &op
+}
+
+// Alias documents every operation under prefix again without it, marked
+// deprecated, for routes kept for older clients.
+func (s *Spec) Alias(prefix string) {
+	for path, item := range s.doc.Paths {
+		legacy, ok := strings.CutPrefix(path, prefix+"/")
+		if !ok {
+			continue
+		}
+		aliases := PathItem{}
+		for method, op := range item {
+			alias := *op
+			alias.Deprecated = true
+			aliases[method] = &alias
+		}
+		s.doc.Paths["/"+legacy] = aliases
+	}
+}
+
+// Missing returns the routes that have not been documented.
+func (s *Spec) Missing(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, r := range routes {
+		path := r.Path
+		for _, seg := range strings.Split(path, "/") {
+			if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+				path = strings.Replace(path, seg, "{"+seg[1:]+"}", 1)
+			}
+		}
+		if _, ok := s.doc.Paths[path][strings.ToLower(r.Method)]; !ok {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	return missing
+}
+
+// Handler serves the document as JSON.
+func (s *Spec) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, &s.doc)
+	}
+}
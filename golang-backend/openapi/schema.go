@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+func String() *Schema  { return &Schema{Type: "string"} }
+func Integer() *Schema { return &Schema{Type: "integer"} }
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+// Binary is a file part of a multipart body.
+func Binary() *Schema { return &Schema{Type: "string", Format: "binary"} }
+
+func ArrayOf(items *Schema) *Schema { return &Schema{Type: "array", Items: items} }
+
+// Object is an inline object whose properties are all required unless
+// listed in optional.
+func Object(props map[string]*Schema, optional ...string) *Schema {
+	s := &Schema{Type: "object", Properties: props}
+	for name := range props {
+		if !slices.Contains(optional, name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	slices.Sort(s.Required)
+	return s
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Schema returns the schema of v's type following encoding/json rules.
+// Named struct types are added to the components and referenced.
+func (s *Spec) Schema(v any) *Schema {
+	return s.schemaOf(reflect.TypeOf(v))
+}
+
+func (s *Spec) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{Type: "object", Description: "Free-form JSON."}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string", Enum: s.enums[t]}
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return ArrayOf(s.schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := componentName(t)
+		ref := "#/components/schemas/" + name
+		if _, ok := s.refs[t.PkgPath()+"."+t.Name()]; !ok {
+			s.refs[t.PkgPath()+"."+t.Name()] = ref
+			s.doc.Components.Schemas[name] = s.structSchema(t)
+		}
+		return &Schema{Ref: s.refs[t.PkgPath()+"."+t.Name()]}
+	}
+	return &Schema{}
+}
+
+// structSchema flattens embedded structs the way encoding/json does, with
+// shallower fields winning.
+func (s *Spec) structSchema(t reflect.Type) *Schema {
+	out := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema := s.schemaOf(f.Type)
+		if f.Type.Kind() == reflect.Pointer && schema.Ref == "" {
+			schema.Nullable = true
+		}
+		out.Properties[name] = schema
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			out.Required = append(out.Required, name)
+		}
+	}
+	for _, et := range embedded {
+		inner := s.structSchema(et)
+		for name, schema := range inner.Properties {
+			if _, ok := out.Properties[name]; ok {
+				continue
+			}
+			out.Properties[name] = schema
+			if slices.Contains(inner.Required, name) {
+				out.Required = append(out.Required, name)
+			}
+		}
+	}
+	slices.Sort(out.Required)
+	return out
+}
+
+func componentName(t reflect.Type) string {
+	r := []rune(t.Name())
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
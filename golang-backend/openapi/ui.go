@@ -0,0 +1,35 @@
+package openapi
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var uiPage = template.Must(template.New("docs").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: {{.URL}}, dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`))
+
+// UIHandler serves Swagger UI for the document at specURL. The UI assets
+// are loaded from unpkg.
+func (s *Spec) UIHandler(specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		uiPage.Execute(c.Writer, struct{ Title, URL string }{s.doc.Info.Title, specURL})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/controller"
+	"golang-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiMiddleware is built once and shared by every mount of the API, so
+// rate limits and quotas count across versions. common runs on every
+// route, scan only on the scan routes.
+type apiMiddleware struct {
+	common []gin.HandlerFunc
+	scan   []gin.HandlerFunc
+}
+
+// mountAPI registers the API routes on g. A future version mounts them on
+// its own group tagged with middleware.Version, and handlers whose response
+// schema changes branch on middleware.VersionFrom.
+func mountAPI(g *gin.RouterGroup, mw apiMiddleware) {
+	api := g.Group("", mw.common...)
+	scan := api.Group("", append([]gin.HandlerFunc{middleware.Require(middleware.PermScan)}, mw.scan...)...)
+	// Synchronous scans are refused up front while the OCR queue is full.
+	ocr := scan.Group("", controller.ShedOCR, controller.CheckScanQuery, controller.ProcessingDeadline, controller.Negotiate)
+	ocr.POST("/upload", controller.ValidateUploads("file"), controller.UploadHandler)
+	ocr.POST("/upload/batch", controller.ValidateBatch, controller.BatchUploadHandler)
+	ocr.POST("/upload/base64", controller.Base64UploadHandler)
+	ocr.POST("/upload/url", controller.URLUploadHandler)
+	ocr.POST("/upload/back", controller.ValidateUpload("file"), controller.BackUploadHandler)
+	ocr.POST("/upload/combined", controller.ValidateUpload("front", "back"), controller.CombinedUploadHandler)
+	ocr.POST("/upload/passport", controller.ValidateUpload("file"), controller.PassportUploadHandler)
+	ocr.POST("/upload/driver-license", controller.ValidateUpload("file"), controller.DriverLicenseUploadHandler)
+	ocr.POST("/upload/house-registration", controller.ValidateUpload("file"), controller.HouseRegistrationUploadHandler)
+	scan.POST("/scans", controller.ShedJobs, controller.ValidateUpload("file"), controller.CreateScanHandler)
+	scan.POST("/verify", controller.VerifyHandler)
+	scan.POST("/verify/face", controller.FaceMatchHandler)
+	scan.GET("/ws/scan", controller.LiveScanHandler)
+	scan.POST("/uploads", controller.CreateUploadHandler)
+	scan.HEAD("/uploads/:id", controller.UploadOffsetHandler)
+	scan.PATCH("/uploads/:id", controller.PatchUploadHandler)
+	scan.DELETE("/uploads/:id", controller.DeleteUploadHandler)
+	g.OPTIONS("/uploads", controller.TusOptionsHandler)
+	api.GET("/scans", middleware.Require(middleware.PermList), controller.Negotiate, controller.ListScansHandler)
+	api.GET("/scans/search", middleware.Require(middleware.PermSearch), controller.Negotiate, controller.SearchScansHandler)
+	api.GET("/scans/export", middleware.Require(middleware.PermList), controller.ExportScansHandler)
+	api.GET("/scans/:id", middleware.Require(middleware.PermRead), controller.Negotiate, controller.GetScanHandler)
+	api.PATCH("/scans/:id", middleware.Require(middleware.PermCorrect), controller.Negotiate, controller.PatchScanHandler)
+	api.DELETE("/scans/:id", middleware.Require(middleware.PermDelete), controller.DeleteScanHandler)
+	api.POST("/scans/:id/purge", middleware.Require(middleware.PermAdmin), controller.PurgeScanHandler)
+	api.GET("/scans/:id/report.pdf", middleware.Require(middleware.PermRead), controller.ScanReportHandler)
+	api.GET("/scans/:id/events", middleware.Require(middleware.PermRead), controller.ScanEventsHandler)
+	api.GET("/usage", controller.UsageHandler)
+
+	review := api.Group("/reviews", middleware.Require(middleware.PermCorrect))
+	review.GET("", controller.Negotiate, controller.ListReviewsHandler)
+	review.GET("/:id", controller.Negotiate, controller.GetReviewHandler)
+	review.POST("/:id", controller.ReviewScanHandler)
+
+	admin := api.Group("/admin", middleware.Require(middleware.PermAdmin))
+	admin.GET("/audit", controller.ListAuditHandler)
+	admin.GET("/usage", controller.AdminUsageHandler)
+	admin.GET("/tenants", controller.ListTenantsHandler)
+	admin.PUT("/tenants/:id", controller.PutTenantHandler)
+	admin.GET("/keys", controller.ListKeysHandler)
+	admin.POST("/keys", controller.CreateKeyHandler)
+	admin.POST("/keys/:id/rotate", controller.RotateKeyHandler)
+	admin.DELETE("/keys/:id", controller.RevokeKeyHandler)
+}
+
+// legacyDates parses the dates announced on the unversioned routes.
+func legacyDates(cfg config.APIConfig) (deprecatedAt, sunset time.Time, err error) {
+	if cfg.Deprecation != "" {
+		if deprecatedAt, err = time.Parse(time.DateOnly, cfg.Deprecation); err != nil {
+			return deprecatedAt, sunset, fmt.Errorf("deprecation must be YYYY-MM-DD: %w", err)
+		}
+	}
+	if cfg.Sunset != "" {
+		if sunset, err = time.Parse(time.DateOnly, cfg.Sunset); err != nil {
+			return deprecatedAt, sunset, fmt.Errorf("sunset must be YYYY-MM-DD: %w", err)
+		}
+	}
+	return deprecatedAt, sunset, nil
+}
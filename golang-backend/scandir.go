@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// scanExtensions are the files scan-dir picks up.
+var scanExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".webp": true,
+	".heic": true, ".heif": true, ".pdf": true,
+}
+
+// fileResult is one output row. Status is "ok", or "error" with Error set.
+type fileResult struct {
+	File   string
+	Status string
+	Error  string
+	Result any
+}
+
+func scanDirCommand() *cobra.Command {
+	var (
+		opts        scanOptions
+		out         string
+		format      string
+		concurrency int
+		resume      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "scan-dir DIR",
+		Short: "Scan every image under a directory into one CSV or JSONL file",
+		Long: `Scans the images under DIR, several at a time, writing a row per file
+as each finishes. A file that fails gets a row with status "error" and
+the reason instead of stopping the run. With --resume, files already
+scanned successfully into --out are skipped and new rows appended, so an
+interrupted run can be continued; a file's last row is its outcome.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scan, resultType, err := opts.scanner()
+			if err != nil {
+				return err
+			}
+			if format == "" {
+				format = "csv"
+				if strings.EqualFold(filepath.Ext(out), ".jsonl") {
+					format = "jsonl"
+				}
+			}
+			if format != "csv" && format != "jsonl" {
+				return fmt.Errorf("unknown format %q", format)
+			}
+			if resume && (out == "" || out == "-") {
+				return errors.New("--resume needs --out")
+			}
+			if err := setupScanning(); err != nil {
+				return err
+			}
+
+			done := map[string]bool{}
+			if resume {
+				if done, err = scannedFiles(out, format); err != nil {
+					return fmt.Errorf("resume: %w", err)
+				}
+			}
+			files, err := imageFiles(args[0], done)
+			if err != nil {
+				return err
+			}
+
+			w, header := cmd.OutOrStdout(), true
+			if out != "" && out != "-" {
+				flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+				if resume {
+					flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+				}
+				f, err := os.OpenFile(out, flags, 0o644)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if info, err := f.Stat(); err == nil && info.Size() > 0 {
+					header = false
+				}
+				w = f
+			}
+			write := resultWriter(w, format, resultType, header)
+
+			start := time.Now()
+			var failed int
+			for r := range scanFiles(cmd.Context(), args[0], files, scan, max(concurrency, 1)) {
+				if r.Status != "ok" {
+					failed++
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", r.File, r.Error)
+				}
+				if err := write(r); err != nil {
+					return err
+				}
+			}
+			slog.Info("scan-dir finished", "files", len(files), "failed", failed, "skipped", len(done), "duration", time.Since(start).Round(time.Second).String())
+			return nil
+		},
+	}
+	opts.register(cmd)
+	cmd.Flags().StringVar(&out, "out", "", "output file (default stdout)")
+	cmd.Flags().StringVar(&format, "format", "", "csv or jsonl (default from the --out extension, else csv)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 4, "files scanned at once")
+	cmd.Flags().BoolVar(&resume, "resume", false, "skip files already scanned into --out and append to it")
+	return cmd
+}
+
+// imageFiles lists the images under dir, relative to it, leaving out those
+// in skip.
+func imageFiles(dir string, skip map[string]bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !scanExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !skip[rel] {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// scanFiles scans files with up to concurrency at a time, sending each
+// outcome as it finishes. No more are started once ctx ends; the channel is
+// closed when those running are done.
+func scanFiles(ctx context.Context, dir string, files []string, scan func(context.Context, io.Reader) (any, error), concurrency int) <-chan fileResult {
+	results := make(chan fileResult)
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range paths {
+				results <- scanFile(ctx, filepath.Join(dir, rel), rel, scan)
+			}
+		}()
+	}
+	go func() {
+		defer close(paths)
+		for _, rel := range files {
+			select {
+			case paths <- rel:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+func scanFile(ctx context.Context, path, rel string, scan func(context.Context, io.Reader) (any, error)) fileResult {
+	f, err := os.Open(path)
+	if err != nil {
+		return fileResult{File: rel, Status: "error", Error: err.Error()}
+	}
+	defer f.Close()
+	result, err := scan(ctx, f)
+	if err != nil {
+		return fileResult{File: rel, Status: "error", Error: err.Error()}
+	}
+	return fileResult{File: rel, Status: "ok", Result: result}
+}
+
+// resultWriter returns a function writing a row to w as soon as it is
+// given, so an interrupted run loses nothing. CSV rows hold file, status
+// and error followed by the result's fields, after a header row when
+// header is set; JSONL lines hold the same with the result as an object.
+func resultWriter(w io.Writer, format string, resultType reflect.Type, header bool) func(fileResult) error {
+	if format == "jsonl" {
+		enc := json.NewEncoder(w)
+		return func(r fileResult) error {
+			return enc.Encode(struct {
+				File   string `json:"file"`
+				Status string `json:"status"`
+				Error  string `json:"error,omitempty"`
+				Result any    `json:"result,omitempty"`
+			}{r.File, r.Status, r.Error, r.Result})
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	names := fieldNames(resultType)
+	return func(r fileResult) error {
+		if header {
+			header = false
+			cw.Write(append([]string{"file", "status", "error"}, names...))
+		}
+		row := []string{r.File, r.Status, r.Error}
+		if r.Result == nil {
+			row = append(row, make([]string, len(names))...)
+		} else {
+			values, err := fieldValues(reflect.Indirect(reflect.ValueOf(r.Result)))
+			if err != nil {
+				return err
+			}
+			row = append(row, values...)
+		}
+		cw.Write(row)
+		cw.Flush()
+		return cw.Error()
+	}
+}
+
+// scannedFiles reads a previous scan-dir output and returns the files it
+// scanned successfully. A missing file means nothing was scanned yet.
+func scannedFiles(path, format string) (map[string]bool, error) {
+	done := map[string]bool{}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "jsonl" {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, 16<<20)
+		for sc.Scan() {
+			var row struct{ File, Status string }
+			if json.Unmarshal(sc.Bytes(), &row) == nil && row.Status == "ok" {
+				done[row.File] = true
+			}
+		}
+		return done, sc.Err()
+	}
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return done, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(row) >= 2 && row[1] == "ok" {
+			done[row[0]] = true
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// thaiAdminCSV lists provinces and Bangkok districts. A full
+// province,district,subdistrict,postcode dataset can be loaded on top of it
+// with LoadAddressDataset.
+//
+//go:embed data/thai_admin.csv
+var thaiAdminCSV []byte
+
+// ThaiAddress is a card address split into its administrative parts.
+type ThaiAddress struct {
+	HouseNumber string `json:"house_number,omitempty"`
+	Moo         string `json:"moo,omitempty"`
+	Soi         string `json:"soi,omitempty"`
+	Road        string `json:"road,omitempty"`
+	Subdistrict string `json:"subdistrict,omitempty"`
+	District    string `json:"district,omitempty"`
+	Province    string `json:"province,omitempty"`
+	PostalCode  string `json:"postal_code,omitempty"`
+}
+
+type adminDistrict struct {
+	subdistricts map[string]string // name -> postcode
+	postcode     string
+}
+
+var (
+	adminDivisions = map[string]map[string]*adminDistrict{}
+
+	provinceAliases = map[string]string{
+		"กรุงเทพ":  "กรุงเทพมหานคร",
+		"กรุงเทพฯ": "กรุงเทพมหานคร",
+		"กทม":      "กรุงเทพมหานคร",
+		"กทม.":     "กรุงเทพมหานคร",
+	}
+
+	addressMarker = regexp.MustCompile(`(?:^|\s)(บ้านเลขที่|หมู่ที่|หมู่|ม\.|ซอย|ซ\.|ถนน|ถ\.|ตำบล|ต\.|แขวง|อำเภอ|อ\.|เขต|จังหวัด|จ\.)\s*`)
+	postalCode    = regexp.MustCompile(`\b\d{5}\b`)
+)
+
+func init() {
+	if err := readAddressDataset(bytes.NewReader(thaiAdminCSV)); err != nil {
+		panic(err)
+	}
+}
+
+// LoadAddressDataset merges a province,district,subdistrict,postcode CSV into
+// the embedded dataset. An empty path is a no-op.
+func LoadAddressDataset(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return readAddressDataset(f)
+}
+
+func readAddressDataset(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 4
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if i == 0 && row[0] == "province" {
+			continue
+		}
+		province, district, subdistrict, postcode := row[0], row[1], row[2], row[3]
+		districts := adminDivisions[province]
+		if districts == nil {
+			districts = map[string]*adminDistrict{}
+			adminDivisions[province] = districts
+		}
+		if district == "" {
+			continue
+		}
+		d := districts[district]
+		if d == nil {
+			d = &adminDistrict{subdistricts: map[string]string{}}
+			districts[district] = d
+		}
+		if subdistrict == "" {
+			d.postcode = postcode
+			continue
+		}
+		d.subdistricts[subdistrict] = postcode
+	}
+	return nil
+}
+
+// ParseThaiAddress splits an address line such as
+// "99/9 หมู่ที่ 3 ต.บางพูด อ.ปากเกร็ด จ.นนทบุรี" and corrects the
+// subdistrict, district and province against the dataset.
+func ParseThaiAddress(s string) *ThaiAddress {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return nil
+	}
+	a := &ThaiAddress{}
+	if m := postalCode.FindStringIndex(s); m != nil {
+		a.PostalCode = s[m[0]:m[1]]
+		s = strings.TrimSpace(s[:m[0]] + s[m[1]:])
+	}
+
+	marks := addressMarker.FindAllStringSubmatchIndex(s, -1)
+	end := len(s)
+	if len(marks) > 0 {
+		end = marks[0][0]
+	}
+	a.HouseNumber = strings.TrimSpace(s[:end])
+	var last *string
+	for i, m := range marks {
+		end := len(s)
+		if i+1 < len(marks) {
+			end = marks[i+1][0]
+		}
+		value := strings.TrimSpace(s[m[1]:end])
+		var dst *string
+		switch s[m[2]:m[3]] {
+		case "บ้านเลขที่":
+			dst = &a.HouseNumber
+		case "หมู่ที่", "หมู่", "ม.":
+			dst = &a.Moo
+		case "ซอย", "ซ.":
+			dst = &a.Soi
+		case "ถนน", "ถ.":
+			dst = &a.Road
+		case "ตำบล", "ต.", "แขวง":
+			dst = &a.Subdistrict
+		case "อำเภอ", "อ.", "เขต":
+			dst = &a.District
+		default:
+			dst = &a.Province
+		}
+		*dst, last = value, dst
+	}
+
+	// Bangkok addresses usually end in the province name without a marker.
+	if a.Province == "" && last != nil {
+		if i := strings.LastIndexByte(*last, ' '); i > 0 {
+			if p, ok := matchProvince((*last)[i+1:]); ok {
+				*last, a.Province = (*last)[:i], p
+			}
+		}
+	}
+	a.resolve()
+	return a
+}
+
+// resolve replaces OCR'd names with the closest dataset entries and fills
+// the postal code when the dataset knows it.
+func (a *ThaiAddress) resolve() {
+	p, ok := matchProvince(a.Province)
+	if !ok {
+		return
+	}
+	a.Province = p
+	districts := adminDivisions[p]
+	if a.District == "เมือง" {
+		a.District += p
+	}
+	name, ok := closest(a.District, districts)
+	if !ok {
+		return
+	}
+	a.District = name
+	d := districts[name]
+	if name, ok := closest(a.Subdistrict, d.subdistricts); ok {
+		a.Subdistrict = name
+		if a.PostalCode == "" {
+			a.PostalCode = d.subdistricts[name]
+		}
+	}
+	if a.PostalCode == "" {
+		a.PostalCode = d.postcode
+	}
+}
+
+func matchProvince(s string) (string, bool) {
+	if p, ok := provinceAliases[s]; ok {
+		return p, true
+	}
+	return closest(s, adminDivisions)
+}
+
+// closest returns the key of m nearest to s by edit distance, allowing
+// roughly one error per four characters.
+func closest[V any](s string, m map[string]V) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	if _, ok := m[s]; ok {
+		return s, true
+	}
+	rs := []rune(s)
+	best, bestDist := "", len(rs)/4+1
+	for k := range m {
+		if d := levenshtein(rs, []rune(k)); d < bestDist || (d == bestDist && best != "" && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	return best, best != ""
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
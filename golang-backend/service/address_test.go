@@ -0,0 +1,42 @@
+package service
+
+import "testing"
+
+func TestParseThaiAddress(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *ThaiAddress
+	}{
+		{
+			"99/9 หมู่ที่ 3 ต.บางพูด อ.ปากเกร็ด จ.นนทบุรี",
+			&ThaiAddress{HouseNumber: "99/9", Moo: "3", Subdistrict: "บางพูด", District: "ปากเกร็ด", Province: "นนทบุรี"},
+		},
+		{
+			"บ้านเลขที่ 12 ซอย 5 ถนนสุขุมวิท ตำบลบางพูด อำเภอปากเกร็ด จังหวัดนนทบุรี 11120",
+			&ThaiAddress{HouseNumber: "12", Soi: "5", Road: "สุขุมวิท", Subdistrict: "บางพูด", District: "ปากเกร็ด", Province: "นนทบุรี", PostalCode: "11120"},
+		},
+		{
+			// Bangkok addresses end in the province without a marker.
+			"1/2 ถ.สีลม แขวงสุริยวงศ์ เขตบางรัก กรุงเทพมหานคร",
+			&ThaiAddress{HouseNumber: "1/2", Road: "สีลม", Subdistrict: "สุริยวงศ์", District: "บางรัก", Province: "กรุงเทพมหานคร"},
+		},
+		{
+			// Misread names are corrected against the dataset.
+			"7 ม.1 ต.บางพูด อ.ปากเกร็ด จ.นนทบุรึ",
+			&ThaiAddress{HouseNumber: "7", Moo: "1", Subdistrict: "บางพูด", District: "ปากเกร็ด", Province: "นนทบุรี"},
+		},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		got := ParseThaiAddress(tt.in)
+		if tt.want == nil || got == nil {
+			if got != tt.want {
+				t.Errorf("ParseThaiAddress(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+			continue
+		}
+		if *got != *tt.want {
+			t.Errorf("ParseThaiAddress(%q)\n got %+v\nwant %+v", tt.in, *got, *tt.want)
+		}
+	}
+}
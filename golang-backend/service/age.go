@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+type minAgeKey struct{}
+
+// WithMinAge asks card scans to check that the holder is at least age years
+// old, reported as AgeCheckPassed.
+func WithMinAge(ctx context.Context, age int) context.Context {
+	return context.WithValue(ctx, minAgeKey{}, age)
+}
+
+// checkAge sets Age from the birth date on now's date in Thailand and, when
+// ctx asks for a minimum age, AgeCheckPassed. An unreadable birth date fails
+// the check.
+func (c *ThaiIDCard) checkAge(ctx context.Context, now time.Time) {
+	if c.Dates.Birth != nil {
+		y, m, d := now.In(cardLocation).Date()
+		birth := c.Dates.Birth
+		age := y - birth.YearCE
+		if m < time.Month(birth.Month) || m == time.Month(birth.Month) && d < birth.Day {
+			age--
+		}
+		c.Age = &age
+	}
+	if min, ok := ctx.Value(minAgeKey{}).(int); ok {
+		passed := c.Age != nil && *c.Age >= min
+		c.AgeCheckPassed = &passed
+	}
+}
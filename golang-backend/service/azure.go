@@ -0,0 +1,77 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+)
+
+// newAzureProvider runs an Azure Form Recognizer (Document Intelligence)
+// model, prebuilt-read by default, and polls for the result.
+func newAzureProvider(cfg config.AzureConfig) *textProvider {
+	analyze := strings.TrimRight(cfg.Endpoint, "/") + "/formrecognizer/documentModels/" + cfg.Model +
+		":analyze?api-version=" + cfg.APIVersion
+	send := func(ctx context.Context, method, target string, body []byte) (*response, error) {
+		return doWithRetry(ctx, client, func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			if body != nil {
+				req.Header.Set("Content-Type", "application/octet-stream")
+			}
+			req.Header.Set("Ocp-Apim-Subscription-Key", cfg.Key)
+			return req, nil
+		})
+	}
+
+	return &textProvider{name: "azure", detect: func(ctx context.Context, image []byte) (string, error) {
+		resp, err := send(ctx, http.MethodPost, analyze, image)
+		if err != nil {
+			return "", err
+		}
+		if resp.status != http.StatusAccepted {
+			return "", fmt.Errorf("azure returned %d: %s", resp.status, resp.body)
+		}
+		operation := resp.header.Get("Operation-Location")
+
+		for {
+			t := time.NewTimer(cfg.PollInterval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return "", ctx.Err()
+			case <-t.C:
+			}
+
+			resp, err = send(ctx, http.MethodGet, operation, nil)
+			if err != nil {
+				return "", err
+			}
+			if resp.status != http.StatusOK {
+				return "", fmt.Errorf("azure returned %d: %s", resp.status, resp.body)
+			}
+			var out struct {
+				Status        string `json:"status"`
+				AnalyzeResult struct {
+					Content string `json:"content"`
+				} `json:"analyzeResult"`
+			}
+			if err = json.Unmarshal(resp.body, &out); err != nil {
+				return "", fmt.Errorf("decode azure response: %w", err)
+			}
+			switch out.Status {
+			case "succeeded":
+				return out.AnalyzeResult.Content, nil
+			case "failed":
+				return "", fmt.Errorf("azure analysis failed: %s", resp.body)
+			}
+		}
+	}}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Laser codes are printed as two letters and ten digits, e.g. JT1-1234567-89.
+var laserCodePattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{10}$`)
+
+type ThaiIDCardBack struct {
+	// IDNumber is only present when the OCR service reads a citizen ID off
+	// the back side, which some card generations print next to the code.
+	IDNumber       string `json:"back_id_number,omitempty"`
+	LaserCode      string `json:"laser_code"`
+	LaserCodeRaw   string `json:"laser_code_raw"`
+	LaserCodeValid bool   `json:"laser_code_valid"`
+	// Named apart from the front's so both survive in ThaiIDCardFull.
+	RawFields map[string]string `json:"back_raw_fields,omitempty"`
+}
+
+func ScanBack(ctx context.Context, image io.Reader) (*ThaiIDCardBack, error) {
+	fields, err := recognize(ctx, DocumentBack, image)
+	if err != nil {
+		return nil, err
+	}
+	raw := first(fields, "laser_code", "laser_id", "laser")
+	code, ok := NormalizeLaserCode(raw)
+	return &ThaiIDCardBack{
+		IDNumber:       strings.ReplaceAll(first(fields, "id_card", "id_number"), " ", ""),
+		LaserCode:      code,
+		LaserCodeRaw:   raw,
+		LaserCodeValid: ok,
+		RawFields:      originalFields(fields),
+	}, nil
+}
+
+// NormalizeLaserCode strips separators and whitespace from s and, when the
+// result has the expected shape, formats it as XX0-0000000-00.
+func NormalizeLaserCode(s string) (string, bool) {
+	compact := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '.' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(s)))
+	if !laserCodePattern.MatchString(compact) {
+		return compact, false
+	}
+	return compact[:3] + "-" + compact[3:10] + "-" + compact[10:], true
+}
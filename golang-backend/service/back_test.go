@@ -0,0 +1,26 @@
+package service
+
+import "testing"
+
+func TestNormalizeLaserCode(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		valid bool
+	}{
+		{"ME0-1234567-89", "ME0-1234567-89", true},
+		{"ME012345678 9", "ME0-1234567-89", true},
+		{" me0.1234567.89 ", "ME0-1234567-89", true},
+		{"JT9123456789", "JT9-1234567-89", true},
+		{"M30-1234567-89", "M30123456789", false},
+		{"ME0-1234567-8", "ME012345678", false},
+		{"ME0-1234567-890", "ME01234567890", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, valid := NormalizeLaserCode(tt.in)
+		if got != tt.want || valid != tt.valid {
+			t.Errorf("NormalizeLaserCode(%q) = %q, %v, want %q, %v", tt.in, got, valid, tt.want, tt.valid)
+		}
+	}
+}
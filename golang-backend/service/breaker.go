@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang-backend/config"
+)
+
+// CircuitOpenError is returned without calling the backend while its
+// circuit breaker is open.
+type CircuitOpenError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("ocr provider %s unavailable, retry after %s", e.Provider, e.RetryAfter)
+}
+
+// breakerProvider opens after Failures consecutive unavailable errors and
+// rejects calls until Cooldown has passed, then lets a single probe through.
+type breakerProvider struct {
+	OCRProvider
+	cfg config.BreakerConfig
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	probing  bool
+	openedAt time.Time
+}
+
+func newBreakerProvider(p OCRProvider, cfg config.BreakerConfig) *breakerProvider {
+	return &breakerProvider{OCRProvider: p, cfg: cfg}
+}
+
+func (b *breakerProvider) Recognize(ctx context.Context, doc Document, image []byte) (map[string]string, error) {
+	if wait, ok := b.allow(); !ok {
+		return nil, &CircuitOpenError{Provider: b.Name(), RetryAfter: wait}
+	}
+	fields, err := b.OCRProvider.Recognize(ctx, doc, image)
+	// A caller's deadline passing says nothing about the backend, so it only
+	// frees the probe slot for the next call.
+	if ctx.Err() != nil {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+		return fields, err
+	}
+	b.record(err)
+	return fields, err
+}
+
+func (b *breakerProvider) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return 0, true
+	}
+	elapsed := time.Since(b.openedAt)
+	if elapsed >= b.cfg.Cooldown && !b.probing {
+		b.probing = true
+		return 0, true
+	}
+	return max(b.cfg.Cooldown-elapsed, time.Second), false
+}
+
+func (b *breakerProvider) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !unavailable(err) {
+		if b.open {
+			slog.Info("ocr circuit closed", "provider", b.Name())
+		}
+		b.failures, b.open, b.probing = 0, false, false
+		return
+	}
+	b.failures++
+	if b.probing || b.failures >= b.cfg.Failures {
+		if !b.open {
+			slog.Warn("ocr circuit opened", "provider", b.Name(), "failures", b.failures)
+		}
+		b.open, b.openedAt = true, time.Now()
+	}
+	b.probing = false
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-backend/config"
+)
+
+// scriptedProvider answers each Recognize with the next of errs.
+type scriptedProvider struct {
+	errs  []error
+	calls int
+}
+
+func (p *scriptedProvider) Name() string               { return "scripted" }
+func (p *scriptedProvider) Ping(context.Context) error { return nil }
+
+func (p *scriptedProvider) Recognize(context.Context, Document, []byte) (map[string]string, error) {
+	err := p.errs[p.calls]
+	p.calls++
+	return nil, err
+}
+
+func TestBreaker(t *testing.T) {
+	down := &StatusError{Status: 503}
+	bad := &StatusError{Status: 400}
+	tests := []struct {
+		name     string
+		errs     []error // backend answers, in order
+		cooldown bool    // let the cooldown pass before the last call
+		want     []bool  // whether each call was refused by the open circuit
+	}{
+		{"stays closed below the threshold", []error{down, down, nil, down, down}, false, []bool{false, false, false, false, false}},
+		{"client errors do not count", []error{bad, bad, bad, bad}, false, []bool{false, false, false, false}},
+		{"opens after consecutive failures", []error{down, down, down}, false, []bool{false, false, false, true}},
+		{"probe closes it again", []error{down, down, down, nil}, true, []bool{false, false, false, false}},
+		{"failed probe reopens it", []error{down, down, down, down}, true, []bool{false, false, false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &scriptedProvider{errs: tt.errs}
+			b := newBreakerProvider(backend, config.BreakerConfig{Enabled: true, Failures: 3, Cooldown: time.Hour})
+			for i, refused := range tt.want {
+				if tt.cooldown && i == len(tt.errs)-1 {
+					b.mu.Lock()
+					b.openedAt = time.Now().Add(-2 * time.Hour)
+					b.mu.Unlock()
+				}
+				_, err := b.Recognize(context.Background(), DocumentFront, nil)
+				var open *CircuitOpenError
+				if got := errors.As(err, &open); got != refused {
+					t.Fatalf("call %d: refused = %v, want %v (err %v)", i, got, refused, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerOneProbeAtATime(t *testing.T) {
+	b := newBreakerProvider(&scriptedProvider{}, config.BreakerConfig{Failures: 1, Cooldown: time.Hour})
+	b.open, b.openedAt = true, time.Now().Add(-2*time.Hour)
+	if _, ok := b.allow(); !ok {
+		t.Fatal("first call after the cooldown was not let through")
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("second call was let through while the probe is running")
+	}
+}
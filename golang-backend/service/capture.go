@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang-backend/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type captureKey struct{}
+
+// Capture collects the raw OCR labels, the uploaded and cropped images and
+// the hash of the image sent to OCR while a scan runs, for callers that
+// persist scans.
+type Capture struct {
+	mu     sync.Mutex
+	hashes map[Document]string
+	fields map[Document]map[string]string
+	images map[string][]byte
+}
+
+// WithCapture returns a context whose scans record into the returned Capture.
+func WithCapture(ctx context.Context) (context.Context, *Capture) {
+	c := &Capture{
+		hashes: make(map[Document]string),
+		fields: make(map[Document]map[string]string),
+		images: make(map[string][]byte),
+	}
+	return context.WithValue(ctx, captureKey{}, c), c
+}
+
+func (c *Capture) record(doc Document, p *prepared, fields map[string]string) {
+	sum := sha256.Sum256(p.image)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hashes[doc] = hex.EncodeToString(sum[:])
+	c.images[string(doc)] = p.upload
+	if p.card != nil {
+		c.images[string(doc)+"_cropped"] = p.image
+	}
+	if fields != nil {
+		c.fields[doc] = fields
+	}
+}
+
+// Document lists the captured document types, comma separated.
+func (c *Capture) Document() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	docs := make([]string, 0, len(c.hashes))
+	for d := range c.hashes {
+		docs = append(docs, string(d))
+	}
+	sort.Strings(docs)
+	return strings.Join(docs, ",")
+}
+
+// ImageSHA256 is the hash of the front image when there is one, otherwise of
+// the only image scanned.
+func (c *Capture) ImageSHA256() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if h, ok := c.hashes[DocumentFront]; ok {
+		return h
+	}
+	for _, h := range c.hashes {
+		return h
+	}
+	return ""
+}
+
+// Fields returns the raw OCR labels keyed by document type.
+func (c *Capture) Fields() map[Document]map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[Document]map[string]string, len(c.fields))
+	for d, f := range c.fields {
+		out[d] = f
+	}
+	return out
+}
+
+// Images returns the uploaded images keyed by document type, plus the
+// cropped card under "<document>_cropped" when card detection found one.
+func (c *Capture) Images() map[string][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string][]byte, len(c.images))
+	for name, b := range c.images {
+		out[name] = b
+	}
+	return out
+}
+
+// recognizeImage calls the OCR provider and records the exchange on the
+// context's Capture, if any, before normalizing the fields.
+func recognizeImage(ctx context.Context, doc Document, p *prepared) (map[string]string, error) {
+	metrics.ObserveForwarded(int64(len(p.image)))
+	ctx, span := tracer.Start(ctx, "ocr.recognize", trace.WithAttributes(
+		attribute.String("ocr.provider", provider.Name()),
+		attribute.String("ocr.document", string(doc)),
+		attribute.Int("ocr.image_bytes", len(p.image)),
+	))
+	fields, err := provider.Recognize(ctx, doc, p.image)
+	endSpan(span, err)
+	if c, ok := ctx.Value(captureKey{}).(*Capture); ok {
+		c.record(doc, p, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalizeFields(fields), nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import "strings"
+
+type ThaiIDCard struct {
+	IDNumber        string       `json:"id_number"`
+	IDValid         bool         `json:"id_valid"`
+	NameTH          string       `json:"name_th"`
+	NameEN          string       `json:"name_en"`
+	NameTHParts     *PersonName  `json:"name_th_parts,omitempty"`
+	NameENParts     *PersonName  `json:"name_en_parts,omitempty"`
+	Title           string       `json:"title,omitempty"`
+	TitleRaw        string       `json:"title_raw,omitempty"`
+	Gender          string       `json:"gender"`
+	BirthDate       string       `json:"birth_date"`
+	Address         string       `json:"address"`
+	AddressParts    *ThaiAddress `json:"address_parts,omitempty"`
+	IssueDate       string       `json:"issue_date"`
+	ExpiryDate      string       `json:"expiry_date"`
+	Expired         bool         `json:"expired"`
+	Lifetime        bool         `json:"lifetime"`
+	DaysUntilExpiry *int         `json:"days_until_expiry,omitempty"`
+	Age             *int         `json:"age,omitempty"`
+	AgeCheckPassed  *bool        `json:"age_check_passed,omitempty"`
+
+	Dates struct {
+		Birth  *CardDate `json:"birth,omitempty"`
+		Issue  *CardDate `json:"issue,omitempty"`
+		Expiry *CardDate `json:"expiry,omitempty"`
+	} `json:"dates"`
+
+	CardRegion   *CardRegion        `json:"card_region,omitempty"`
+	Quality      *QualityReport     `json:"quality,omitempty"`
+	Confidence   map[string]float64 `json:"confidence,omitempty"`
+	CaptureType  string             `json:"capture_type,omitempty"`
+	Status       string             `json:"status"`
+	ReviewFields []string           `json:"review_fields,omitempty"`
+	Warnings     []string           `json:"warnings,omitempty"`
+	Photo        *Photo             `json:"photo,omitempty"`
+	// PriorScanCount is how many earlier stored scans have the same citizen
+	// ID, up to ten. PriorScans lists them for callers that may read scans
+	// of every key.
+	PriorScanCount int         `json:"prior_scan_count,omitempty"`
+	PriorScans     []PriorScan `json:"prior_scans,omitempty"`
+	// OverallConfidence is the mean confidence of the fields that have a
+	// threshold.
+	OverallConfidence float64 `json:"overall_confidence"`
+	// RawFields holds what OCR read for the labels normalization changed.
+	RawFields map[string]string `json:"raw_fields,omitempty"`
+}
+
+// newThaiIDCard maps the label/text pairs produced by the OCR service onto a
+// ThaiIDCard. Label names follow the YOLO text detector classes.
+func newThaiIDCard(fields map[string]string) *ThaiIDCard {
+	card := &ThaiIDCard{
+		IDNumber:    strings.ReplaceAll(first(fields, "id_card", "id_number"), " ", ""),
+		NameTHParts: thaiName(fields),
+		NameENParts: englishName(fields),
+		BirthDate:   first(fields, "date_of_birth_en", "date_of_birth_th"),
+		Address:     first(fields, "address", "address_th"),
+		IssueDate:   first(fields, "date_of_issue_en", "date_of_issue_th"),
+		ExpiryDate:  first(fields, "date_of_expity_en", "date_of_expity_th", "date_of_expiry_en", "date_of_expiry_th"),
+	}
+	card.NameTH, card.NameEN = card.NameTHParts.String(), card.NameENParts.String()
+	card.Title, card.TitleRaw, card.Gender = personTitle(card.NameTHParts, card.NameENParts)
+	card.RawFields = originalFields(fields)
+	card.AddressParts = ParseThaiAddress(card.Address)
+	card.Dates.Birth = parseFirstDate(fields, "date_of_birth_th", "date_of_birth_en")
+	card.Dates.Issue = parseFirstDate(fields, "date_of_issue_th", "date_of_issue_en")
+	card.Dates.Expiry = parseFirstDate(fields, "date_of_expity_th", "date_of_expity_en", "date_of_expiry_th", "date_of_expiry_en")
+	card.Lifetime = lifetimeExpiry(fields, "date_of_expity_th", "date_of_expity_en", "date_of_expiry_th", "date_of_expiry_en")
+	return card
+}
+
+func first(fields map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := strings.TrimSpace(fields[k]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
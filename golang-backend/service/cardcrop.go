@@ -0,0 +1,242 @@
+package service
+
+import (
+	"image"
+	"math"
+)
+
+// ID-1 cards (ISO/IEC 7810) are 85.60 x 53.98 mm.
+const cardAspect = 85.60 / 53.98
+
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// CardRegion locates the card in the uploaded image. Corners are in source
+// pixel coordinates, clockwise from top-left.
+type CardRegion struct {
+	Corners [4]Point `json:"corners"`
+	Box     struct {
+		X      int `json:"x"`
+		Y      int `json:"y"`
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"bounding_box"`
+}
+
+// detectCard finds the dominant card-shaped quadrilateral in img using an
+// edge map. It returns nil when nothing plausible is found.
+func detectCard(img *image.NRGBA) *CardRegion {
+	small := downscale(img, 640)
+	scale := float64(img.Rect.Dx()) / float64(small.Rect.Dx())
+	w, h := small.Rect.Dx(), small.Rect.Dy()
+
+	gray := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gray[y*w+x] = float64(luma(small.Pix[y*small.Stride+x*4:]))
+		}
+	}
+	edges := edgeMask(gray, w, h)
+	comp := largestComponent(edges, w, h)
+	if len(comp) == 0 {
+		return nil
+	}
+
+	// extreme points along the diagonals approximate the corners
+	tl, tr, br, bl := comp[0], comp[0], comp[0], comp[0]
+	for _, p := range comp {
+		if p.X+p.Y < tl.X+tl.Y {
+			tl = p
+		}
+		if p.X-p.Y > tr.X-tr.Y {
+			tr = p
+		}
+		if p.X+p.Y > br.X+br.Y {
+			br = p
+		}
+		if p.Y-p.X > bl.Y-bl.X {
+			bl = p
+		}
+	}
+	quad := [4]Point{tl, tr, br, bl}
+	if !plausibleCard(quad, w, h) {
+		return nil
+	}
+
+	r := &CardRegion{}
+	minX, minY, maxX, maxY := math.MaxInt, math.MaxInt, 0, 0
+	for i, p := range quad {
+		q := Point{X: int(float64(p.X) * scale), Y: int(float64(p.Y) * scale)}
+		r.Corners[i] = q
+		minX, minY, maxX, maxY = min(minX, q.X), min(minY, q.Y), max(maxX, q.X), max(maxY, q.Y)
+	}
+	box := image.Rect(minX, minY, maxX, maxY).Intersect(img.Rect)
+	r.Box.X, r.Box.Y = box.Min.X, box.Min.Y
+	r.Box.Width, r.Box.Height = box.Dx(), box.Dy()
+	return r
+}
+
+// edgeMask thresholds the Sobel gradient magnitude at its 90th percentile.
+func edgeMask(gray []float64, w, h int) []bool {
+	mag := make([]float64, w*h)
+	var hist [256]int
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			at := func(dx, dy int) float64 { return gray[(y+dy)*w+x+dx] }
+			gx := at(1, -1) + 2*at(1, 0) + at(1, 1) - at(-1, -1) - 2*at(-1, 0) - at(-1, 1)
+			gy := at(-1, 1) + 2*at(0, 1) + at(1, 1) - at(-1, -1) - 2*at(0, -1) - at(1, -1)
+			m := math.Min(math.Hypot(gx, gy)/4, 255)
+			mag[y*w+x] = m
+			hist[int(m)]++
+		}
+	}
+	threshold := float64(percentile(hist[:], (w*h)*9/10))
+	mask := make([]bool, w*h)
+	for i, m := range mag {
+		mask[i] = m > threshold && m > 20
+	}
+	return mask
+}
+
+// largestComponent returns the 8-connected edge component with the largest
+// bounding box.
+func largestComponent(mask []bool, w, h int) []Point {
+	seen := make([]bool, len(mask))
+	var best []Point
+	bestArea := 0
+	var stack []int
+	for start := range mask {
+		if !mask[start] || seen[start] {
+			continue
+		}
+		var comp []Point
+		minX, minY, maxX, maxY := w, h, 0, 0
+		stack = append(stack[:0], start)
+		seen[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%w, i/w
+			comp = append(comp, Point{x, y})
+			minX, minY, maxX, maxY = min(minX, x), min(minY, y), max(maxX, x), max(maxY, y)
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+					if j := ny*w + nx; mask[j] && !seen[j] {
+						seen[j] = true
+						stack = append(stack, j)
+					}
+				}
+			}
+		}
+		if area := (maxX - minX) * (maxY - minY); area > bestArea {
+			best, bestArea = comp, area
+		}
+	}
+	return best
+}
+
+func plausibleCard(q [4]Point, w, h int) bool {
+	area := 0.0
+	for i := range q {
+		j := (i + 1) % 4
+		area += float64(q[i].X*q[j].Y - q[j].X*q[i].Y)
+	}
+	area = math.Abs(area) / 2
+	if area < imageSettings.CardDetection.MinAreaRatio*float64(w*h) {
+		return false
+	}
+
+	width := (dist(q[0], q[1]) + dist(q[3], q[2])) / 2
+	height := (dist(q[0], q[3]) + dist(q[1], q[2])) / 2
+	if width == 0 || height == 0 {
+		return false
+	}
+	ratio := math.Max(width, height) / math.Min(width, height)
+	return math.Abs(ratio-cardAspect) < 0.35
+}
+
+func dist(a, b Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// warpCard maps the quadrilateral r out of img onto an upright rectangle of
+// the configured width with the card's aspect ratio.
+func warpCard(img *image.NRGBA, r *CardRegion) *image.NRGBA {
+	q := r.Corners
+	ow := imageSettings.CardDetection.OutputWidth
+	oh := int(float64(ow) / cardAspect)
+	if dist(q[0], q[3]) > dist(q[0], q[1]) { // portrait: keep orientation
+		ow, oh = oh, ow
+	}
+
+	src := [4][2]float64{}
+	for i, p := range q {
+		src[i] = [2]float64{float64(p.X), float64(p.Y)}
+	}
+	dst := [4][2]float64{{0, 0}, {float64(ow - 1), 0}, {float64(ow - 1), float64(oh - 1)}, {0, float64(oh - 1)}}
+	hm, ok := homography(dst, src)
+	if !ok {
+		return img
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, ow, oh))
+	sw, sh := img.Rect.Dx(), img.Rect.Dy()
+	for y := 0; y < oh; y++ {
+		for x := 0; x < ow; x++ {
+			fx, fy := float64(x), float64(y)
+			d := hm[6]*fx + hm[7]*fy + 1
+			sx := int((hm[0]*fx + hm[1]*fy + hm[2]) / d)
+			sy := int((hm[3]*fx + hm[4]*fy + hm[5]) / d)
+			if sx < 0 || sy < 0 || sx >= sw || sy >= sh {
+				continue
+			}
+			si := sy*img.Stride + sx*4
+			di := y*out.Stride + x*4
+			copy(out.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+	return out
+}
+
+// homography solves for the 3x3 projective transform (h33 = 1) taking the
+// four from points onto the four to points.
+func homography(from, to [4][2]float64) ([8]float64, bool) {
+	var a [8][9]float64
+	for i := 0; i < 4; i++ {
+		x, y, u, v := from[i][0], from[i][1], to[i][0], to[i][1]
+		a[2*i] = [9]float64{x, y, 1, 0, 0, 0, -u * x, -u * y, u}
+		a[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -v * x, -v * y, v}
+	}
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for r := col + 1; r < 8; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-9 {
+			return [8]float64{}, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for r := 0; r < 8; r++ {
+			if r == col {
+				continue
+			}
+			f := a[r][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[r][k] -= f * a[col][k]
+			}
+		}
+	}
+	var h [8]float64
+	for i := range h {
+		h[i] = a[i][8] / a[i][i]
+	}
+	return h, true
+}
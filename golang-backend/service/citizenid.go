@@ -0,0 +1,19 @@
+package service
+
+// ValidCitizenID reports whether id is a 13-digit Thai citizen ID whose last
+// digit matches the mod-11 checksum of the first twelve.
+func ValidCitizenID(id string) bool {
+	if len(id) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(id[i]-'0') * (13 - i)
+		}
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
@@ -0,0 +1,27 @@
+package service
+
+import "testing"
+
+func TestValidCitizenID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"1101700230708", true},
+		{"3100500123458", true},
+		{"1234567890121", true},
+		{"9999999999994", true},
+		{"1101700230705", false}, // wrong check digit
+		{"1101700230780", false}, // transposed digits
+		{"110170023070", false},  // too short
+		{"11017002307080", false},
+		{"1 1017 00230 70 8", false}, // separators are stripped before validation
+		{"110170023070O", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidCitizenID(tt.id); got != tt.want {
+			t.Errorf("ValidCitizenID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"golang-backend/config"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// newHTTPClient builds the pooled client used for OCR and verification
+// backends. Per-call deadlines come from the request context. Each call is
+// a client span and carries the trace context to the backend.
+func newHTTPClient(cfg config.HTTPClientConfig) *http.Client {
+	return &http.Client{Transport: otelhttp.NewTransport(newTransport(cfg))}
+}
+
+func newTransport(cfg config.HTTPClientConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// newOCRClient returns the shared client, or a copy of it presenting the
+// client certificate and trusting the CAs configured in cfg.
+func newOCRClient(cfg config.OCRTLSConfig) (*http.Client, error) {
+	if cfg.CAFile == "" && cfg.CertFile == "" && cfg.ServerName == "" {
+		return client, nil
+	}
+	tc := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: cfg.ServerName}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ocr ca: %w", err)
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ocr ca: no certificates in %s", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("ocr client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	t := newTransport(clientSettings)
+	t.TLSClientConfig = tc
+	return &http.Client{Transport: otelhttp.NewTransport(t)}, nil
+}
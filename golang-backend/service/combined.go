@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"golang-backend/logging"
+)
+
+// ThaiIDCardFull merges both sides of a card into one record.
+type ThaiIDCardFull struct {
+	ThaiIDCard
+	ThaiIDCardBack
+	IDNumberConsistent bool          `json:"id_number_consistent"`
+	Verification       *Verification `json:"verification,omitempty"`
+	Warnings           []string      `json:"warnings,omitempty"`
+}
+
+// ScanFull scans the front and back of a card concurrently and cross-checks
+// the results.
+func ScanFull(ctx context.Context, front, back io.Reader) (*ThaiIDCardFull, error) {
+	var (
+		wg       sync.WaitGroup
+		card     *ThaiIDCard
+		backSide *ThaiIDCardBack
+		frontErr error
+		backErr  error
+		err      error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer Recover(ctx, &frontErr)
+		card, frontErr = Scan(ctx, front)
+	}()
+	go func() {
+		defer wg.Done()
+		defer Recover(ctx, &backErr)
+		backSide, backErr = ScanBack(ctx, back)
+	}()
+	wg.Wait()
+	if frontErr != nil {
+		return nil, frontErr
+	}
+	if backErr != nil {
+		return nil, backErr
+	}
+
+	full := &ThaiIDCardFull{ThaiIDCard: *card, ThaiIDCardBack: *backSide}
+	full.crossCheck()
+	if VerificationEnabled() {
+		if full.Verification, err = Verify(ctx, full.verifyRequest()); err != nil {
+			logging.FromContext(ctx).Warn("dopa verification failed", "error", err)
+			full.Warnings = append(full.Warnings, "verification_unavailable")
+		} else if !full.Verification.Verified {
+			full.Warnings = append(full.Warnings, "verification_failed")
+		}
+	}
+	return full, nil
+}
+
+func (f *ThaiIDCardFull) verifyRequest() VerifyRequest {
+	r := VerifyRequest{
+		IDNumber:  f.ThaiIDCard.IDNumber,
+		BirthDate: f.Dates.Birth,
+		LaserCode: f.LaserCode,
+	}
+	if f.NameTHParts != nil {
+		r.FirstName, r.LastName = f.NameTHParts.First, f.NameTHParts.Last
+	}
+	return r
+}
+
+func (f *ThaiIDCardFull) crossCheck() {
+	f.Warnings = append(f.Warnings, f.ThaiIDCard.Warnings...)
+	f.IDNumberConsistent = f.IDValid
+	if !f.IDValid {
+		f.Warnings = append(f.Warnings, "front id number fails checksum")
+	}
+	if f.ThaiIDCardBack.IDNumber != "" && f.ThaiIDCardBack.IDNumber != f.ThaiIDCard.IDNumber {
+		f.IDNumberConsistent = false
+		f.Warnings = append(f.Warnings, "id number on back does not match front")
+	}
+	if !f.LaserCodeValid {
+		f.Warnings = append(f.Warnings, "laser code format is invalid")
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"slices"
+	"strconv"
+	"unicode"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusNeedsReview = "needs_review"
+)
+
+// confidenceLabels maps each scored card field to the OCR labels it is read
+// from.
+var confidenceLabels = map[string][]string{
+	"id_number":   {"id_card", "id_number"},
+	"name_th":     {"prefix_name_th", "first_name_th", "last_name_th", "name_th", "th_name"},
+	"name_en":     {"prefix_name_en", "first_name_en", "last_name_en", "en_prefix", "en_firstname", "en_lastname", "en_name_raw", "en_name"},
+	"birth_date":  {"date_of_birth_th", "date_of_birth_en"},
+	"issue_date":  {"date_of_issue_th", "date_of_issue_en"},
+	"expiry_date": {"date_of_expity_th", "date_of_expity_en", "date_of_expiry_th", "date_of_expiry_en"},
+	"address":     {"address", "address_th"},
+}
+
+// ocrConfidence returns the lowest confidence the OCR service reported for
+// labels, if it reported any.
+func ocrConfidence(fields map[string]string, labels []string) (float64, bool) {
+	lowest, found := 1.0, false
+	for _, l := range labels {
+		v, err := strconv.ParseFloat(fields[l+confidenceSuffix], 64)
+		if err != nil {
+			continue
+		}
+		lowest, found = min(lowest, v), true
+	}
+	return lowest, found
+}
+
+// scoreConfidence fills Confidence from the OCR service's scores capped by
+// how well each field validates in Go, then sets Status against the
+// configured thresholds.
+func (c *ThaiIDCard) scoreConfidence(fields map[string]string) {
+	c.Confidence = map[string]float64{
+		"id_number":   c.idConfidence(),
+		"name_th":     scriptConfidence(c.NameTH, unicode.Thai),
+		"name_en":     scriptConfidence(c.NameEN, unicode.Latin),
+		"birth_date":  dateConfidence(c.BirthDate, c.Dates.Birth),
+		"issue_date":  dateConfidence(c.IssueDate, c.Dates.Issue),
+		"expiry_date": dateConfidence(c.ExpiryDate, c.Dates.Expiry),
+		"address":     c.addressConfidence(),
+	}
+	c.Status = StatusOK
+	for field, score := range c.Confidence {
+		if reported, ok := ocrConfidence(fields, confidenceLabels[field]); ok {
+			score = min(score, reported)
+			c.Confidence[field] = score
+		}
+		if score < fieldThreshold(field) {
+			c.Status = StatusNeedsReview
+			c.ReviewFields = append(c.ReviewFields, field)
+		}
+	}
+	slices.Sort(c.ReviewFields)
+	c.OverallConfidence = overallConfidence(c.Confidence)
+	if c.OverallConfidence < cardSettings.MinOverallConfidence {
+		c.Status = StatusNeedsReview
+	}
+}
+
+func fieldThreshold(field string) float64 {
+	if threshold, ok := cardSettings.FieldMinConfidence[field]; ok {
+		return threshold
+	}
+	return cardSettings.MinConfidence
+}
+
+// overallConfidence is the mean of the scores of fields with a threshold.
+func overallConfidence(scores map[string]float64) float64 {
+	var sum float64
+	var scored int
+	for field, score := range scores {
+		if fieldThreshold(field) > 0 {
+			sum += score
+			scored++
+		}
+	}
+	if scored == 0 {
+		return 0
+	}
+	return sum / float64(scored)
+}
+
+func (c *ThaiIDCard) idConfidence() float64 {
+	switch {
+	case c.IDNumber == "":
+		return 0
+	case c.IDValid:
+		return 1
+	case len(c.IDNumber) == 13:
+		return 0.5
+	}
+	return 0.2
+}
+
+func (c *ThaiIDCard) addressConfidence() float64 {
+	if c.AddressParts == nil {
+		return 0
+	}
+	if _, ok := adminDivisions[c.AddressParts.Province]; ok {
+		return 0.9
+	}
+	return 0.5
+}
+
+func dateConfidence(raw string, parsed *CardDate) float64 {
+	switch {
+	case raw == "":
+		return 0
+	case parsed != nil || isLifetime(raw):
+		return 0.9
+	}
+	return 0.3
+}
+
+// scriptConfidence is lower when a name contains letters outside the
+// expected script, a common sign of a misread line.
+func scriptConfidence(s string, script *unicode.RangeTable) float64 {
+	if s == "" {
+		return 0
+	}
+	for _, r := range s {
+		if unicode.IsLetter(r) && !unicode.Is(script, r) {
+			return 0.5
+		}
+	}
+	return 0.9
+}
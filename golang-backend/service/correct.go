@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// CanRederive reports whether the fields of a stored result derived from
+// field can be recomputed once it is corrected. Passport check digits are
+// read from the MRZ and an age check's minimum is not stored, so neither
+// can.
+func CanRederive(result map[string]json.RawMessage, field string) bool {
+	switch field {
+	case "passport_number", "personal_number", "expiry_date":
+		return !has(result, "checks")
+	case "birth_date":
+		return !has(result, "checks") && !has(result, "age_check_passed")
+	}
+	return true
+}
+
+// Rederive updates the fields of a stored result that are derived from
+// field after it was corrected to value, as the scan would have set them,
+// and marks field as fully confident unless it is a citizen ID that fails
+// its checksum. Only fields already in result are
+// written, so it applies to every document type.
+func Rederive(result map[string]json.RawMessage, field, value string, now time.Time) {
+	var card ThaiIDCard
+	b, _ := json.Marshal(result)
+	_ = json.Unmarshal(b, &card)
+	set := func(key string, v any) {
+		if has(result, key) {
+			result[key], _ = json.Marshal(v)
+		}
+	}
+
+	switch field {
+	case "id_number":
+		card.IDValid = ValidCitizenID(value)
+		set("id_valid", card.IDValid)
+	case "name_th", "name_en":
+		th, en := thaiName(map[string]string{"name_th": card.NameTH}), englishName(map[string]string{"en_name": card.NameEN})
+		set("name_th_parts", th)
+		set("name_en_parts", en)
+		card.Title, card.TitleRaw, card.Gender = personTitle(th, en)
+		set("title", card.Title)
+		set("title_raw", card.TitleRaw)
+		set("gender", card.Gender)
+	case "birth_date":
+		card.Dates.Birth, _ = ParseCardDate(value)
+		card.Age = nil
+		card.checkAge(context.Background(), now)
+		set("dates", card.Dates)
+		if card.Age == nil {
+			delete(result, "age")
+		} else {
+			result["age"], _ = json.Marshal(card.Age)
+		}
+	case "issue_date":
+		card.Dates.Issue, _ = ParseCardDate(value)
+		set("dates", card.Dates)
+	case "expiry_date":
+		card.Dates.Expiry, _ = ParseCardDate(value)
+		card.Lifetime, card.Expired, card.DaysUntilExpiry = isLifetime(value), false, nil
+		card.Warnings = slices.DeleteFunc(card.Warnings, func(w string) bool { return w == "expiry_unreadable" })
+		card.checkExpiry(now)
+		set("dates", card.Dates)
+		set("lifetime", card.Lifetime)
+		set("expired", card.Expired)
+		set("days_until_expiry", card.DaysUntilExpiry)
+		set("warnings", card.Warnings)
+	case "address":
+		set("address_parts", ParseThaiAddress(value))
+	case "house_code":
+		_, valid := normalizeHouseCode(value)
+		set("house_code_valid", valid)
+	}
+
+	if _, ok := card.Confidence[field]; ok {
+		card.Confidence[field] = 1
+		if field == "id_number" {
+			card.Confidence[field] = card.idConfidence()
+		}
+		set("confidence", card.Confidence)
+		set("overall_confidence", overallConfidence(card.Confidence))
+		if review := slices.DeleteFunc(card.ReviewFields, func(f string) bool { return f == field }); len(review) > 0 {
+			set("review_fields", review)
+		} else {
+			delete(result, "review_fields")
+		}
+	}
+}
+
+func has(result map[string]json.RawMessage, key string) bool {
+	_, ok := result[key]
+	return ok
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// beOffset is the difference between Buddhist Era and Gregorian years.
+const beOffset = 543
+
+var datePattern = regexp.MustCompile(`^(\d{1,2})\s*([^\d]+?)\s*(\d{4})$`)
+
+var monthNames = map[string]time.Month{
+	"มค": 1, "กพ": 2, "มีค": 3, "เมย": 4, "พค": 5, "มิย": 6,
+	"กค": 7, "สค": 8, "กย": 9, "ตค": 10, "พย": 11, "ธค": 12,
+	"มกราคม": 1, "กุมภาพันธ์": 2, "มีนาคม": 3, "เมษายน": 4, "พฤษภาคม": 5, "มิถุนายน": 6,
+	"กรกฎาคม": 7, "สิงหาคม": 8, "กันยายน": 9, "ตุลาคม": 10, "พฤศจิกายน": 11, "ธันวาคม": 12,
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "sept": 9, "oct": 10, "nov": 11, "dec": 12,
+	"january": 1, "february": 2, "march": 3, "april": 4, "june": 6,
+	"july": 7, "august": 8, "september": 9, "october": 10, "november": 11, "december": 12,
+}
+
+// CardDate is a date read off a card in both calendars.
+type CardDate struct {
+	Raw    string `json:"raw"`
+	Day    int    `json:"day"`
+	Month  int    `json:"month"`
+	YearBE int    `json:"year_be"`
+	YearCE int    `json:"year_ce"`
+	ISO    string `json:"iso"`
+}
+
+func (d *CardDate) Time() time.Time {
+	return time.Date(d.YearCE, time.Month(d.Month), d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseCardDate parses dates such as "15 ม.ค. 2530" or "15 Jan. 1987".
+// Years above 2400 are taken as Buddhist Era.
+func ParseCardDate(s string) (*CardDate, bool) {
+	raw := strings.TrimSpace(s)
+	m := datePattern.FindStringSubmatch(raw)
+	if m == nil {
+		return nil, false
+	}
+
+	day, _ := strconv.Atoi(m[1])
+	year, _ := strconv.Atoi(m[3])
+	key := strings.ToLower(strings.NewReplacer(".", "", " ", "", ",", "").Replace(m[2]))
+	month, ok := monthNames[key]
+	if !ok {
+		return nil, false
+	}
+
+	d := &CardDate{Raw: raw, Day: day, Month: int(month)}
+	if year > 2400 {
+		d.YearBE, d.YearCE = year, year-beOffset
+	} else {
+		d.YearBE, d.YearCE = year+beOffset, year
+	}
+	t := d.Time()
+	if t.Day() != day || t.Month() != month {
+		return nil, false
+	}
+	d.ISO = t.Format(time.DateOnly)
+	return d, true
+}
+
+// parseFirstDate returns the first of the given OCR fields that parses.
+func parseFirstDate(fields map[string]string, keys ...string) *CardDate {
+	for _, k := range keys {
+		if d, ok := ParseCardDate(fields[k]); ok {
+			return d
+		}
+	}
+	return nil
+}
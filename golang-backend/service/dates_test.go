@@ -0,0 +1,36 @@
+package service
+
+import "testing"
+
+func TestParseCardDate(t *testing.T) {
+	tests := []struct {
+		in     string
+		ok     bool
+		yearBE int
+		yearCE int
+		iso    string
+	}{
+		{"15 ม.ค. 2530", true, 2530, 1987, "1987-01-15"},
+		{"15 Jan. 1987", true, 2530, 1987, "1987-01-15"},
+		{"15 มกราคม 2530", true, 2530, 1987, "1987-01-15"},
+		{"1 Sept 2000", true, 2543, 2000, "2000-09-01"},
+		{"28 พ.ย.2515", true, 2515, 1972, "1972-11-28"},
+		{" 3 DEC. 2024 ", true, 2567, 2024, "2024-12-03"},
+		{"29 ก.พ. 2543", true, 2543, 2000, "2000-02-29"}, // 2000 is a leap year
+		{"29 ก.พ. 2542", false, 0, 0, ""},                // 1999 is not
+		{"31 เม.ย. 2560", false, 0, 0, ""},               // April has 30 days
+		{"15 Foo 1987", false, 0, 0, ""},
+		{"ตลอดชีพ", false, 0, 0, ""},
+		{"", false, 0, 0, ""},
+	}
+	for _, tt := range tests {
+		d, ok := ParseCardDate(tt.in)
+		if ok != tt.ok {
+			t.Errorf("ParseCardDate(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			continue
+		}
+		if ok && (d.YearBE != tt.yearBE || d.YearCE != tt.yearCE || d.ISO != tt.iso) {
+			t.Errorf("ParseCardDate(%q) = BE %d CE %d %s, want BE %d CE %d %s", tt.in, d.YearBE, d.YearCE, d.ISO, tt.yearBE, tt.yearCE, tt.iso)
+		}
+	}
+}
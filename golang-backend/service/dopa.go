@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+)
+
+var ErrVerifyDisabled = errors.New("dopa verification is not configured")
+
+var dopaSettings = config.Default().DOPA
+
+// VerifyRequest holds the card details checked against the DOPA register.
+type VerifyRequest struct {
+	IDNumber  string
+	FirstName string
+	LastName  string
+	BirthDate *CardDate
+	LaserCode string
+}
+
+// Verification is the DOPA answer for a card. Code "0" means the card is
+// active and matches the holder.
+type Verification struct {
+	Verified    bool   `json:"verified"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// VerificationEnabled reports whether combined scans are checked with DOPA.
+func VerificationEnabled() bool {
+	return dopaSettings.Enabled && dopaSettings.URL != ""
+}
+
+const dopaEnvelope = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Body>
+<CheckCardByLaser xmlns="http://tempuri.org/">
+<PID>%s</PID>
+<FirstName>%s</FirstName>
+<LastName>%s</LastName>
+<BirthDay>%s</BirthDay>
+<Laser>%s</Laser>
+</CheckCardByLaser>
+</soap:Body>
+</soap:Envelope>`
+
+type dopaResponse struct {
+	Result struct {
+		IsError      bool   `xml:"IsError"`
+		ErrorMessage string `xml:"ErrorMessage"`
+		Code         string `xml:"Code"`
+		Desc         string `xml:"Desc"`
+	} `xml:"Body>CheckCardByLaserResponse>CheckCardByLaserResult"`
+}
+
+// Verify checks a card against the DOPA CheckCardByLaser service.
+func Verify(ctx context.Context, r VerifyRequest) (*Verification, error) {
+	if dopaSettings.URL == "" {
+		return nil, ErrVerifyDisabled
+	}
+	birthDay := ""
+	if r.BirthDate != nil {
+		birthDay = fmt.Sprintf("%04d%02d%02d", r.BirthDate.YearBE, r.BirthDate.Month, r.BirthDate.Day)
+	}
+	body := fmt.Sprintf(dopaEnvelope, escapeXML(r.IDNumber), escapeXML(r.FirstName), escapeXML(r.LastName),
+		birthDay, escapeXML(strings.ReplaceAll(r.LaserCode, "-", "")))
+
+	ctx, cancel := context.WithTimeout(ctx, dopaSettings.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dopaSettings.URL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", `"http://tempuri.org/CheckCardByLaser"`)
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dopa service returned %d", resp.StatusCode)
+	}
+
+	var out dopaResponse
+	if err = xml.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode dopa response: %w", err)
+	}
+	if out.Result.IsError {
+		return nil, fmt.Errorf("dopa service error: %s", out.Result.ErrorMessage)
+	}
+	return &Verification{
+		Verified:    out.Result.Code == "0",
+		Code:        out.Result.Code,
+		Description: out.Result.Desc,
+	}, nil
+}
+
+func escapeXML(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package service
+
+import "time"
+
+// PriorScan refers to an earlier stored scan of the same citizen ID.
+type PriorScan struct {
+	ScanID    string    `json:"scan_id"`
+	KeyID     string    `json:"key_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FlagDuplicate records that the card's citizen ID was scanned count times
+// before, and by which scans when prior is not nil.
+func (c *ThaiIDCard) FlagDuplicate(count int, prior []PriorScan) {
+	c.PriorScanCount, c.PriorScans = count, prior
+	c.Warnings = append(c.Warnings, "duplicate_identity")
+}
+
+func (c *ThaiIDCardFull) FlagDuplicate(count int, prior []PriorScan) {
+	c.PriorScanCount, c.PriorScans = count, prior
+	c.Warnings = append(c.Warnings, "duplicate_identity")
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// jpegOrientation returns the EXIF orientation tag (1-8) of a JPEG, or 1 when
+// it has none.
+func jpegOrientation(b []byte) int {
+	seg := exifSegment(b)
+	if len(seg) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(seg[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(seg[4:8]))
+	if ifd+2 > len(seg) {
+		return 1
+	}
+	n := int(order.Uint16(seg[ifd:]))
+	for i := 0; i < n; i++ {
+		e := ifd + 2 + i*12
+		if e+12 > len(seg) {
+			break
+		}
+		if order.Uint16(seg[e:]) == 0x0112 {
+			if o := int(order.Uint16(seg[e+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// exifSegment returns the TIFF payload of the first APP1 Exif segment.
+func exifSegment(b []byte) []byte {
+	var seg []byte
+	walkJPEG(b, func(marker byte, payload []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			seg = payload[6:]
+			return false
+		}
+		return true
+	})
+	return seg
+}
+
+// stripEXIF drops APP1 Exif segments from a JPEG without re-encoding it.
+func stripEXIF(b []byte) []byte {
+	if len(b) < 4 || b[0] != 0xFF || b[1] != 0xD8 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	out = append(out, 0xFF, 0xD8)
+	pos := 2
+	walkJPEG(b, func(marker byte, payload []byte) bool {
+		end := pos + 4 + len(payload)
+		if !(marker == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00"))) {
+			out = append(out, b[pos:end]...)
+		}
+		pos = end
+		return true
+	})
+	return append(out, b[pos:]...)
+}
+
+// walkJPEG calls fn for each marker segment before the start of scan, until
+// fn returns false.
+func walkJPEG(b []byte, fn func(marker byte, payload []byte) bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1] != 0xD8 {
+		return
+	}
+	pos := 2
+	for pos+4 <= len(b) && b[pos] == 0xFF {
+		marker := b[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return
+		}
+		size := int(binary.BigEndian.Uint16(b[pos+2:]))
+		if size < 2 || pos+2+size > len(b) {
+			return
+		}
+		if !fn(marker, b[pos+4:pos+2+size]) {
+			return
+		}
+		pos += 2 + size
+	}
+}
+
+// autoOrient applies the EXIF orientation of a JPEG to its pixels. The
+// re-encoded image carries no EXIF; images that need no rotation only have
+// their EXIF segment stripped.
+func autoOrient(b []byte) ([]byte, error) {
+	o := jpegOrientation(b)
+	if o == 1 {
+		return stripEXIF(b), nil
+	}
+
+	img, err := DecodeImage(b)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, orient(toNRGBA(img), o), &jpeg.Options{Quality: imageSettings.JPEGQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return dst
+}
+
+// orient maps src according to EXIF orientation o (2-8).
+func orient(src *image.NRGBA, o int) *image.NRGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			si := y*src.Stride + x*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
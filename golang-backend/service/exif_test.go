@@ -0,0 +1,84 @@
+package service
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// exifJPEG returns the start of a JPEG whose APP1 segment holds a single
+// orientation tag, in the given byte order.
+func exifJPEG(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	b := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(b[4:], uint16(len(payload)+2))
+	return append(append(b, payload...), 0xFF, 0xD9)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		want int
+	}{
+		{"intel rotated", exifJPEG(binary.LittleEndian, 6), 6},
+		{"motorola rotated", exifJPEG(binary.BigEndian, 8), 8},
+		{"upright", exifJPEG(binary.LittleEndian, 1), 1},
+		{"out of range", exifJPEG(binary.BigEndian, 9), 1},
+		{"no exif", []byte{0xFF, 0xD8, 0xFF, 0xD9}, 1},
+		{"not a jpeg", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"truncated", exifJPEG(binary.LittleEndian, 6)[:20], 1},
+	}
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.b); got != tt.want {
+			t.Errorf("%s: jpegOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image with a red pixel in its top left corner.
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	red := color.NRGBA{R: 255, A: 255}
+	src.SetNRGBA(0, 0, red)
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		x, y        int // where the red pixel ends up
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0}, // mirrored horizontally
+		{3, 3, 2, 2, 1}, // rotated 180°
+		{4, 3, 2, 0, 1}, // mirrored vertically
+		{5, 2, 3, 0, 0}, // transposed
+		{6, 2, 3, 1, 0}, // rotated 90° clockwise
+		{7, 2, 3, 1, 2}, // transversed
+		{8, 2, 3, 0, 2}, // rotated 90° counter-clockwise
+	}
+	for _, tt := range tests {
+		dst := orient(src, tt.orientation)
+		if dst.Rect.Dx() != tt.w || dst.Rect.Dy() != tt.h {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, dst.Rect.Dx(), dst.Rect.Dy(), tt.w, tt.h)
+			continue
+		}
+		if got := dst.NRGBAAt(tt.x, tt.y); got != red {
+			t.Errorf("orientation %d: pixel (%d,%d) = %v, want red", tt.orientation, tt.x, tt.y, got)
+		}
+	}
+}
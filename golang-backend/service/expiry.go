@@ -0,0 +1,46 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrCardExpired = errors.New("card has expired")
+
+// cardLocation is the time zone card dates are printed in.
+var cardLocation = time.FixedZone("ICT", 7*60*60)
+
+// isLifetime reports whether an expiry field reads "ตลอดชีพ" or "Lifetime".
+func isLifetime(s string) bool {
+	s = strings.ToLower(strings.Join(strings.Fields(s), ""))
+	return strings.Contains(s, "ตลอดชีพ") || strings.Contains(s, "lifelong") || strings.Contains(s, "lifetime")
+}
+
+// lifetimeExpiry reports whether any of the expiry labels reads as a
+// lifetime card, issued to citizens aged 70 and over.
+func lifetimeExpiry(fields map[string]string, keys ...string) bool {
+	for _, k := range keys {
+		if isLifetime(fields[k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExpiry sets Expired and DaysUntilExpiry relative to now. A card stays
+// valid through its printed expiry day; a lifetime card never expires.
+func (c *ThaiIDCard) checkExpiry(now time.Time) {
+	if c.Lifetime {
+		return
+	}
+	if c.Dates.Expiry == nil {
+		c.Warnings = append(c.Warnings, "expiry_unreadable")
+		return
+	}
+	y, m, d := now.In(cardLocation).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	days := int(c.Dates.Expiry.Time().Sub(today).Hours() / 24)
+	c.DaysUntilExpiry = &days
+	c.Expired = days < 0
+}
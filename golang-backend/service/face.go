@@ -0,0 +1,50 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"math"
+	"strings"
+)
+
+// Portrait position on the front of a Thai ID card, as fractions of the card.
+var portraitRegion = [4]float64{0.72, 0.36, 0.96, 0.92}
+
+type Photo struct {
+	ContentType string `json:"content_type"`
+	Data        string `json:"data"`
+	Source      string `json:"source"`
+}
+
+// extractPhoto returns the holder's portrait, preferring an image supplied
+// by the OCR service and otherwise cutting it out of the card in Go. The
+// Go-side crop needs a card-shaped image, either detected or uploaded.
+func extractPhoto(p *prepared, fields map[string]string) (*Photo, error) {
+	if data := first(fields, "face_image", "photo"); data != "" {
+		data = strings.TrimPrefix(data, "data:image/jpeg;base64,")
+		return &Photo{ContentType: "image/jpeg", Data: data, Source: "ocr"}, nil
+	}
+
+	img, err := p.pixels()
+	if err != nil {
+		return nil, err
+	}
+	w, h := float64(img.Rect.Dx()), float64(img.Rect.Dy())
+	if p.card == nil && math.Abs(w/h-cardAspect) > 0.2 {
+		return nil, nil
+	}
+
+	r := portraitRegion
+	rect := image.Rect(int(r[0]*w), int(r[1]*h), int(r[2]*w), int(r[3]*h))
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, img.SubImage(rect), &jpeg.Options{Quality: imageSettings.JPEGQuality}); err != nil {
+		return nil, err
+	}
+	return &Photo{
+		ContentType: "image/jpeg",
+		Data:        base64.StdEncoding.EncodeToString(buf.Bytes()),
+		Source:      "crop",
+	}, nil
+}
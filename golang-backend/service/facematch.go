@@ -0,0 +1,87 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+)
+
+var ErrFaceMatchDisabled = errors.New("face match is not configured")
+
+var faceSettings = config.Default().Face
+
+// FaceMatch is how alike the face on a card and a selfie are.
+type FaceMatch struct {
+	Similarity float64 `json:"similarity"`
+	Threshold  float64 `json:"threshold"`
+	Match      bool    `json:"match"`
+	// Liveness is set when a liveness provider is configured.
+	Liveness *Liveness `json:"liveness,omitempty"`
+}
+
+// CompareFaces asks the face comparison service whether card and selfie
+// show the same person.
+func CompareFaces(ctx context.Context, card, selfie []byte) (*FaceMatch, error) {
+	if faceSettings.URL == "" {
+		return nil, ErrFaceMatchDisabled
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		name string
+		data []byte
+	}{{"card", card}, {"selfie", selfie}} {
+		f, err := w.CreateFormFile(part.name, part.name)
+		if err != nil {
+			return nil, err
+		}
+		f.Write(part.data)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, faceSettings.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, faceSettings.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if faceSettings.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+faceSettings.APIKey)
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("face service returned %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Similarity *float64 `json:"similarity"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode face response: %w", err)
+	}
+	if out.Similarity == nil {
+		return nil, errors.New("face response has no similarity")
+	}
+	return &FaceMatch{
+		Similarity: *out.Similarity,
+		Threshold:  faceSettings.Threshold,
+		Match:      *out.Similarity >= faceSettings.Threshold,
+	}, nil
+}
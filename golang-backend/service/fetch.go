@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/netguard"
+)
+
+var (
+	ErrFetchForbidden = errors.New("url is not allowed")
+	ErrFetchTooLarge  = errors.New("remote image is too large")
+)
+
+var (
+	fetchSettings = config.Default().Fetch
+	fetchClient   = newFetchClient(fetchSettings)
+)
+
+func configureFetch(cfg *config.Config) {
+	fetchSettings = cfg.Fetch
+	fetchClient = newFetchClient(cfg.Fetch)
+}
+
+// newFetchClient builds a client whose dialer refuses private, loopback,
+// link-local and other non-public addresses, as netguard.Dialer does.
+func newFetchClient(cfg config.FetchConfig) *http.Client {
+	dialer := netguard.Dialer(5*time.Second, cfg.AllowPrivate)
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: cfg.Timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("too many redirects")
+			}
+			return checkFetchURL(req.URL)
+		},
+	}
+}
+
+func checkFetchURL(u *url.URL) error {
+	if u.Scheme != "https" && (u.Scheme != "http" || !fetchSettings.AllowHTTP) {
+		return fmt.Errorf("%w: scheme %q", ErrFetchForbidden, u.Scheme)
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return ErrFetchForbidden
+	}
+	if len(fetchSettings.AllowedHosts) > 0 && !slices.Contains(fetchSettings.AllowedHosts, strings.ToLower(u.Hostname())) {
+		return fmt.Errorf("%w: host %q", ErrFetchForbidden, u.Hostname())
+	}
+	// Hosts resolving to a private address are refused when dialled; an
+	// address given outright, by a client or a redirect, is refused here.
+	if a, err := netip.ParseAddr(u.Hostname()); err == nil && !fetchSettings.AllowPrivate && !netguard.Public(a) {
+		return fmt.Errorf("%w: address %s", ErrFetchForbidden, a)
+	}
+	return nil
+}
+
+// FetchImage downloads the image at rawURL, refusing anything larger than
+// maxBytes.
+func FetchImage(ctx context.Context, rawURL string, maxBytes int64) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFetchForbidden, err)
+	}
+	if err = checkFetchURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/jpeg, image/png, image/webp")
+
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrFetchForbidden) || errors.Is(err, netguard.ErrForbidden) {
+			return nil, ErrFetchForbidden
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote server returned %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, ErrFetchTooLarge
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > maxBytes {
+		return nil, ErrFetchTooLarge
+	}
+	return b, nil
+}
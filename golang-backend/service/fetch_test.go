@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang-backend/config"
+)
+
+// publicHost stands in for an internet host in redirect tests: the test
+// servers listen on loopback, which the guarded dialers refuse, so dial
+// routes publicHost to srv and everything else through guarded.
+const publicHost = "images.example:80"
+
+func dialPublicHost(srv *httptest.Server, guarded func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == publicHost {
+			return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+		}
+		return guarded(ctx, network, addr)
+	}
+}
+
+func TestFetchRedirectToPrivate(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal metadata"))
+	}))
+	defer internal.Close()
+	port := strconv.Itoa(internal.Listener.Addr().(*net.TCPAddr).Port)
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if to := r.URL.Query().Get("to"); to != "" {
+			http.Redirect(w, r, to, http.StatusFound)
+			return
+		}
+		w.Write([]byte("card image"))
+	}))
+	defer public.Close()
+
+	saved := fetchSettings
+	t.Cleanup(func() { configureFetch(&config.Config{Fetch: saved}) })
+	configureFetch(&config.Config{Fetch: config.FetchConfig{Timeout: 5 * time.Second, AllowHTTP: true}})
+	tr := fetchClient.Transport.(*http.Transport)
+	tr.DialContext = dialPublicHost(public, tr.DialContext)
+
+	tests := []struct {
+		name, to string
+		err      bool
+	}{
+		{"public", "", false},
+		{"redirect to a public host", "http://images.example/card.jpg", false},
+		{"redirect to loopback", internal.URL, true},
+		{"redirect to localhost", "http://localhost:" + port + "/", true},
+		{"redirect to mapped loopback", "http://[::ffff:127.0.0.1]:" + port + "/", true},
+		{"redirect to NAT64 of loopback", "http://[64:ff9b::7f00:1]:" + port + "/", true},
+		{"redirect to metadata", "http://169.254.169.254/latest/meta-data/", true},
+		{"redirect to carrier-grade NAT", "http://100.64.0.1/", true},
+	}
+	for _, tt := range tests {
+		target := "http://images.example/card.jpg"
+		if tt.to != "" {
+			target += "?to=" + url.QueryEscape(tt.to)
+		}
+		b, err := FetchImage(context.Background(), target, 1<<20)
+		if tt.err {
+			if !errors.Is(err, ErrFetchForbidden) {
+				t.Errorf("%s: got %q, %v; want ErrFetchForbidden", tt.name, b, err)
+			}
+			continue
+		}
+		if err != nil || string(b) != "card image" {
+			t.Errorf("%s: got %q, %v; want the card image", tt.name, b, err)
+		}
+	}
+}
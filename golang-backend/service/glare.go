@@ -0,0 +1,44 @@
+package service
+
+import "image"
+
+const QualityGlare = "glare"
+
+// glareRatio is the share of pixels that are both near-white and almost
+// unsaturated, the signature of specular reflection off lamination.
+func glareRatio(img *image.NRGBA) float64 {
+	small := downscale(img, 1000)
+	var glare, total int
+	for i := 0; i < len(small.Pix); i += 4 {
+		r, g, b := small.Pix[i], small.Pix[i+1], small.Pix[i+2]
+		hi, lo := max(r, g, b), min(r, g, b)
+		if lo >= 245 && hi-lo <= 12 {
+			glare++
+		}
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(glare) / float64(total)
+}
+
+// checkGlare measures glare over the (cropped) card and flags or rejects it
+// according to the configured ratios.
+func (p *prepared) checkGlare() error {
+	img, err := p.pixels()
+	if err != nil {
+		return err
+	}
+	if p.quality == nil {
+		p.quality = &QualityReport{Width: img.Rect.Dx(), Height: img.Rect.Dy()}
+	}
+
+	cfg := imageSettings.Glare
+	p.quality.GlareRatio = glareRatio(img)
+	p.quality.GlareDetected = p.quality.GlareRatio >= cfg.WarnRatio
+	if cfg.RejectRatio > 0 && p.quality.GlareRatio >= cfg.RejectRatio {
+		return &QualityError{Reason: QualityGlare, Value: p.quality.GlareRatio, Threshold: cfg.RejectRatio}
+	}
+	return nil
+}
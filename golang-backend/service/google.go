@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang-backend/config"
+)
+
+// newGoogleVisionProvider uses Cloud Vision DOCUMENT_TEXT_DETECTION with an
+// API key.
+func newGoogleVisionProvider(cfg config.GoogleVisionConfig) *textProvider {
+	return &textProvider{name: "google", detect: func(ctx context.Context, image []byte) (string, error) {
+		body, err := json.Marshal(map[string]any{
+			"requests": []any{map[string]any{
+				"image":        map[string]string{"content": base64.StdEncoding.EncodeToString(image)},
+				"features":     []any{map[string]string{"type": "DOCUMENT_TEXT_DETECTION"}},
+				"imageContext": map[string]any{"languageHints": []string{"th", "en"}},
+			}},
+		})
+		if err != nil {
+			return "", err
+		}
+		endpoint := cfg.Endpoint + "?key=" + url.QueryEscape(cfg.APIKey)
+		resp, err := doWithRetry(ctx, client, func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			return req, nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if resp.status != http.StatusOK {
+			return "", fmt.Errorf("vision returned %d: %s", resp.status, resp.body)
+		}
+
+		var out struct {
+			Responses []struct {
+				FullTextAnnotation struct {
+					Text string `json:"text"`
+				} `json:"fullTextAnnotation"`
+				Error *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"responses"`
+		}
+		if err = json.Unmarshal(resp.body, &out); err != nil {
+			return "", fmt.Errorf("decode vision response: %w", err)
+		}
+		if len(out.Responses) == 0 {
+			return "", nil
+		}
+		if e := out.Responses[0].Error; e != nil {
+			return "", fmt.Errorf("vision: %s", e.Message)
+		}
+		return out.Responses[0].FullTextAnnotation.Text, nil
+	}}
+}
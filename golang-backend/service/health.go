@@ -0,0 +1,10 @@
+package service
+
+import "context"
+
+// PingOCR checks that the configured OCR provider is reachable.
+func PingOCR(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	return provider.Ping(ctx)
+}
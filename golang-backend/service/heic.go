@@ -0,0 +1,51 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"golang-backend/config"
+)
+
+var heicSettings = config.Default().HEIC
+
+var heifBrands = map[string]bool{
+	"heic": true, "heix": true, "hevc": true, "hevx": true,
+	"heim": true, "heis": true, "mif1": true, "msf1": true,
+}
+
+// isHEIC looks for an ISO-BMFF ftyp box with a HEIF major brand.
+func isHEIC(b []byte) bool {
+	return len(b) >= 12 && string(b[4:8]) == "ftyp" && heifBrands[string(b[8:12])]
+}
+
+// convertHEIC re-encodes a HEIC/HEIF image as JPEG using libheif's
+// heif-convert (or the configured compatible converter).
+func convertHEIC(ctx context.Context, heic []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "scan-heic-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in, out := filepath.Join(dir, "in.heic"), filepath.Join(dir, "out.jpg")
+	if err = os.WriteFile(in, heic, 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, heicSettings.Timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, heicSettings.Converter, "-q", strconv.Itoa(heicSettings.Quality), in, out)
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("convert heic: %w: %s", err, stderr.Bytes())
+	}
+	return os.ReadFile(out)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// HouseRegistration holds the fields of a ทะเบียนบ้าน (Tor.Ror.14) page.
+type HouseRegistration struct {
+	HouseCode      string       `json:"house_code"`
+	HouseCodeValid bool         `json:"house_code_valid"`
+	HouseNumber    string       `json:"house_number"`
+	Address        string       `json:"address"`
+	AddressParts   *ThaiAddress `json:"address_parts,omitempty"`
+	Registrar      struct {
+		Office           string `json:"office"`
+		RegistrationDate string `json:"registration_date"`
+	} `json:"registrar"`
+	RawFields map[string]string `json:"raw_fields,omitempty"`
+}
+
+func ScanHouseRegistration(ctx context.Context, image io.Reader) (*HouseRegistration, error) {
+	fields, err := recognize(ctx, DocumentHouseRegistration, image)
+	if err != nil {
+		return nil, err
+	}
+	return newHouseRegistration(fields), nil
+}
+
+func newHouseRegistration(fields map[string]string) *HouseRegistration {
+	h := &HouseRegistration{
+		HouseNumber: first(fields, "house_number", "house_no"),
+		Address:     first(fields, "address", "address_th"),
+	}
+	h.AddressParts = ParseThaiAddress(h.Address)
+	h.HouseCode, h.HouseCodeValid = normalizeHouseCode(first(fields, "house_code", "house_id"))
+	h.Registrar.Office = first(fields, "registrar_office", "registrar")
+	h.Registrar.RegistrationDate = first(fields, "registration_date", "date_of_registration")
+	h.RawFields = originalFields(fields)
+	return h
+}
+
+// normalizeHouseCode formats the 11-digit เลขรหัสประจำบ้าน as 0000-000000-0.
+func normalizeHouseCode(s string) (string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(digits) != 11 {
+		return strings.TrimSpace(s), false
+	}
+	return digits[:4] + "-" + digits[4:10] + "-" + digits[10:], true
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+type DriverLicense struct {
+	LicenseNumber string `json:"license_number"`
+	Class         string `json:"class"`
+	IssueDate     string `json:"issue_date"`
+	ExpiryDate    string `json:"expiry_date"`
+	Lifetime      bool   `json:"lifetime"`
+	Holder        struct {
+		IDNumber  string `json:"id_number"`
+		IDValid   bool   `json:"id_valid"`
+		NameTH    string `json:"name_th"`
+		NameEN    string `json:"name_en"`
+		Title     string `json:"title,omitempty"`
+		TitleRaw  string `json:"title_raw,omitempty"`
+		Gender    string `json:"gender"`
+		BirthDate string `json:"birth_date"`
+	} `json:"holder"`
+	RawFields map[string]string `json:"raw_fields,omitempty"`
+}
+
+func ScanDriverLicense(ctx context.Context, image io.Reader) (*DriverLicense, error) {
+	fields, err := recognize(ctx, DocumentDriverLicense, image)
+	if err != nil {
+		return nil, err
+	}
+	return newDriverLicense(fields), nil
+}
+
+func newDriverLicense(fields map[string]string) *DriverLicense {
+	l := &DriverLicense{
+		LicenseNumber: strings.ReplaceAll(first(fields, "license_number", "license_no"), " ", ""),
+		Class:         first(fields, "license_class", "license_type", "class"),
+		IssueDate:     first(fields, "date_of_issue_en", "date_of_issue_th"),
+		ExpiryDate:    first(fields, "date_of_expiry_en", "date_of_expiry_th", "date_of_expity_en", "date_of_expity_th"),
+		Lifetime:      lifetimeExpiry(fields, "date_of_expiry_en", "date_of_expiry_th", "date_of_expity_en", "date_of_expity_th"),
+	}
+	l.Holder.IDNumber = strings.ReplaceAll(first(fields, "id_card", "id_number"), " ", "")
+	l.Holder.IDValid = ValidCitizenID(l.Holder.IDNumber)
+	th, en := thaiName(fields), englishName(fields)
+	l.Holder.NameTH, l.Holder.NameEN = th.String(), en.String()
+	l.Holder.Title, l.Holder.TitleRaw, l.Holder.Gender = personTitle(th, en)
+	l.Holder.BirthDate = first(fields, "date_of_birth_en", "date_of_birth_th")
+	l.RawFields = originalFields(fields)
+	return l
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/metrics"
+)
+
+// OCRBusyError is returned when a call waited QueueTimeout for one of the
+// MaxInFlight OCR slots without getting one.
+type OCRBusyError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OCRBusyError) Error() string {
+	return fmt.Sprintf("ocr service busy, retry after %s", e.RetryAfter)
+}
+
+// OCRQueueFullError is returned at once, without queueing, when Capacity
+// calls are already waiting for a slot.
+type OCRQueueFullError struct {
+	Depth, Capacity int
+}
+
+func (e *OCRQueueFullError) Error() string {
+	return fmt.Sprintf("ocr queue is full (%d/%d waiting)", e.Depth, e.Capacity)
+}
+
+// ocrLimit is the limit NewProvider last built, for OCRQueue.
+var ocrLimit atomic.Pointer[limitProvider]
+
+// OCRQueue reports how many OCR calls wait for a slot and how many may.
+// Capacity is zero when the wait queue is unbounded.
+func OCRQueue() (depth, capacity int) {
+	l := ocrLimit.Load()
+	if l == nil {
+		return 0, 0
+	}
+	return int(l.waiting.Load()), l.maxQueued
+}
+
+// limitProvider lets at most cap(slots) calls reach the provider at once.
+// The rest wait in arrival order, up to timeout, so a spike drains at the
+// rate the backend can serve instead of timing out together. Beyond
+// maxQueued waiting calls new ones are turned away immediately.
+type limitProvider struct {
+	OCRProvider
+	slots     chan struct{}
+	timeout   time.Duration
+	maxQueued int
+	waiting   atomic.Int64
+}
+
+func newLimitProvider(p OCRProvider, cfg config.ConcurrencyConfig) *limitProvider {
+	return &limitProvider{
+		OCRProvider: p,
+		slots:       make(chan struct{}, cfg.MaxInFlight),
+		timeout:     cfg.QueueTimeout,
+		maxQueued:   cfg.MaxQueued,
+	}
+}
+
+func (l *limitProvider) Recognize(ctx context.Context, doc Document, image []byte) (map[string]string, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+	return l.OCRProvider.Recognize(ctx, doc, image)
+}
+
+func (l *limitProvider) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		metrics.ObserveOCRWait(0, true)
+		metrics.AddOCRInFlight(1)
+		return nil
+	default:
+	}
+	depth := l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+	if l.maxQueued > 0 && depth > int64(l.maxQueued) {
+		metrics.ObserveOCRWait(0, false)
+		return &OCRQueueFullError{Depth: int(depth - 1), Capacity: l.maxQueued}
+	}
+	start := time.Now()
+	var deadline <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		metrics.ObserveOCRWait(time.Since(start), true)
+		metrics.AddOCRInFlight(1)
+		return nil
+	case <-deadline:
+		metrics.ObserveOCRWait(time.Since(start), false)
+		return &OCRBusyError{RetryAfter: max(l.timeout, time.Second)}
+	case <-ctx.Done():
+		metrics.ObserveOCRWait(time.Since(start), false)
+		return ctx.Err()
+	}
+}
+
+func (l *limitProvider) release() {
+	metrics.AddOCRInFlight(-1)
+	<-l.slots
+}
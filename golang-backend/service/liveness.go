@@ -0,0 +1,119 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+)
+
+// LivenessProvider scores a selfie for whether it shows a live person
+// rather than a printout or a screen.
+type LivenessProvider interface {
+	Name() string
+	Check(ctx context.Context, selfie []byte) (*Liveness, error)
+}
+
+// Liveness is a provider's verdict on a selfie.
+type Liveness struct {
+	Provider  string    `json:"provider"`
+	Score     float64   `json:"score"`
+	Threshold float64   `json:"threshold"`
+	Live      bool      `json:"live"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var livenessProvider LivenessProvider
+
+// NewLivenessProvider builds the provider named by cfg.Provider, or nil
+// when liveness is not checked.
+func NewLivenessProvider(cfg config.LivenessConfig) (LivenessProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "http":
+		if cfg.URL == "" {
+			return nil, errors.New("http liveness provider needs a url")
+		}
+		return &httpLiveness{cfg: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown liveness provider %q", cfg.Provider)
+}
+
+func SetLivenessProvider(p LivenessProvider) {
+	livenessProvider = p
+}
+
+// CheckLiveness scores selfie with the configured provider, returning nil
+// when there is none.
+func CheckLiveness(ctx context.Context, selfie []byte) (*Liveness, error) {
+	if livenessProvider == nil {
+		return nil, nil
+	}
+	return livenessProvider.Check(ctx, selfie)
+}
+
+type httpLiveness struct {
+	cfg config.LivenessConfig
+}
+
+func (p *httpLiveness) Name() string { return "http" }
+
+func (p *httpLiveness) Check(ctx context.Context, selfie []byte) (*Liveness, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	f, err := w.CreateFormFile("selfie", "selfie")
+	if err != nil {
+		return nil, err
+	}
+	f.Write(selfie)
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if p.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("liveness service returned %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Score *float64 `json:"score"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode liveness response: %w", err)
+	}
+	if out.Score == nil {
+		return nil, errors.New("liveness response has no score")
+	}
+	return &Liveness{
+		Provider:  p.Name(),
+		Score:     *out.Score,
+		Threshold: p.cfg.Threshold,
+		Live:      *out.Score >= p.cfg.Threshold,
+		CheckedAt: time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+)
+
+// mockProvider makes up well-formed fields for every document type without
+// any network call, so the frontend and integration tests can run without
+// the Python service. The same image always gives the same result.
+type mockProvider struct {
+	cfg config.MockOCRConfig
+}
+
+func newMockProvider(cfg config.MockOCRConfig) *mockProvider {
+	return &mockProvider{cfg: cfg}
+}
+
+func (p *mockProvider) Name() string { return "mock" }
+
+func (p *mockProvider) Ping(context.Context) error { return nil }
+
+type mockPerson struct {
+	prefixTH, firstTH, lastTH string
+	prefixEN, firstEN, lastEN string
+	sex                       string
+	birth, issue, expiry      time.Time
+	houseNumber, address      string
+	registrar                 string
+}
+
+func mockDate(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+var mockPeople = []mockPerson{
+	{
+		prefixTH: "นาย", firstTH: "สมชาย", lastTH: "ใจดี",
+		prefixEN: "Mr.", firstEN: "Somchai", lastEN: "Jaidee", sex: "M",
+		birth: mockDate(1987, time.January, 15), issue: mockDate(2023, time.March, 2), expiry: mockDate(2031, time.January, 14),
+		houseNumber: "99/1", address: "99/1 หมู่ที่ 5 ต.บางพูด อ.ปากเกร็ด จ.นนทบุรี", registrar: "สำนักทะเบียนอำเภอปากเกร็ด",
+	},
+	{
+		prefixTH: "นางสาว", firstTH: "สุดา", lastTH: "รักไทย",
+		prefixEN: "Miss", firstEN: "Suda", lastEN: "Rakthai", sex: "F",
+		birth: mockDate(1995, time.July, 3), issue: mockDate(2022, time.August, 19), expiry: mockDate(2030, time.July, 2),
+		houseNumber: "123", address: "123 ถ.สุขุมวิท แขวงคลองเตย เขตคลองเตย กรุงเทพมหานคร", registrar: "สำนักทะเบียนเขตคลองเตย",
+	},
+	{
+		prefixTH: "นาง", firstTH: "มาลี", lastTH: "ศรีสุข",
+		prefixEN: "Mrs.", firstEN: "Malee", lastEN: "Srisuk", sex: "F",
+		birth: mockDate(1972, time.November, 28), issue: mockDate(2024, time.May, 7), expiry: mockDate(2032, time.November, 27),
+		houseNumber: "45", address: "45 หมู่ที่ 2 ต.สุเทพ อ.เมืองเชียงใหม่ จ.เชียงใหม่", registrar: "สำนักทะเบียนอำเภอเมืองเชียงใหม่",
+	},
+}
+
+var (
+	mockMonthsTH = [12]string{"ม.ค.", "ก.พ.", "มี.ค.", "เม.ย.", "พ.ค.", "มิ.ย.", "ก.ค.", "ส.ค.", "ก.ย.", "ต.ค.", "พ.ย.", "ธ.ค."}
+	mockMonthsEN = [12]string{"Jan.", "Feb.", "Mar.", "Apr.", "May", "Jun.", "Jul.", "Aug.", "Sep.", "Oct.", "Nov.", "Dec."}
+)
+
+func (p *mockProvider) Recognize(ctx context.Context, doc Document, image []byte) (map[string]string, error) {
+	if p.cfg.Latency > 0 {
+		t := time.NewTimer(p.cfg.Latency)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	seed := sha256.Sum256([]byte("mock"))
+	if p.cfg.PerImage {
+		seed = sha256.Sum256(image)
+	}
+	rng := rand.New(rand.NewPCG(binary.BigEndian.Uint64(seed[:8]), binary.BigEndian.Uint64(seed[8:16])))
+	person := mockPeople[rng.IntN(len(mockPeople))]
+	id := mockCitizenID(rng)
+
+	switch doc {
+	case DocumentBack:
+		return map[string]string{
+			"laser_code": fmt.Sprintf("%c%c%s-%s-%s", 'A'+rng.IntN(26), 'A'+rng.IntN(26),
+				mockDigits(rng, 1), mockDigits(rng, 7), mockDigits(rng, 2)),
+		}, nil
+	case DocumentPassport:
+		return mockPassport(rng, person, id), nil
+	case DocumentHouseRegistration:
+		return map[string]string{
+			"house_code":        mockDigits(rng, 4) + "-" + mockDigits(rng, 6) + "-" + mockDigits(rng, 1),
+			"house_number":      person.houseNumber,
+			"address":           person.address,
+			"registrar_office":  person.registrar,
+			"registration_date": mockDateTH(person.issue),
+		}, nil
+	}
+
+	fields := map[string]string{
+		"id_card":          id[:1] + " " + id[1:5] + " " + id[5:10] + " " + id[10:12] + " " + id[12:],
+		"prefix_name_th":   person.prefixTH,
+		"first_name_th":    person.firstTH,
+		"last_name_th":     person.lastTH,
+		"prefix_name_en":   person.prefixEN,
+		"first_name_en":    person.firstEN,
+		"last_name_en":     person.lastEN,
+		"date_of_birth_th": mockDateTH(person.birth),
+		"date_of_birth_en": mockDateEN(person.birth),
+		"date_of_issue_th": mockDateTH(person.issue),
+		"date_of_issue_en": mockDateEN(person.issue),
+	}
+	if doc == DocumentDriverLicense {
+		fields["license_number"] = mockDigits(rng, 8)
+		fields["license_class"] = "รถยนต์ส่วนบุคคล"
+		fields["date_of_expiry_th"] = mockDateTH(person.expiry)
+		fields["date_of_expiry_en"] = mockDateEN(person.expiry)
+		return fields, nil
+	}
+	fields["date_of_expity_th"] = mockDateTH(person.expiry)
+	fields["date_of_expity_en"] = mockDateEN(person.expiry)
+	fields["address"] = person.address
+	return fields, nil
+}
+
+func mockDigits(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + rng.IntN(10))
+	}
+	return string(b)
+}
+
+// mockCitizenID returns a 13-digit ID that passes ValidCitizenID.
+func mockCitizenID(rng *rand.Rand) string {
+	id := fmt.Sprint(1+rng.IntN(8)) + mockDigits(rng, 11)
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(id[i]-'0') * (13 - i)
+	}
+	return id + fmt.Sprint((11-sum%11)%10)
+}
+
+func mockDateTH(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), mockMonthsTH[t.Month()-1], t.Year()+beOffset)
+}
+
+func mockDateEN(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), mockMonthsEN[t.Month()-1], t.Year())
+}
+
+// mockPassport builds TD3 MRZ lines with valid check digits.
+func mockPassport(rng *rand.Rand, person mockPerson, id string) map[string]string {
+	name := strings.ToUpper(person.lastEN + "<<" + person.firstEN)
+	line1 := "P<THA" + name + strings.Repeat("<", 39-len(name))
+
+	number := fmt.Sprintf("%c%c%s", 'A'+rng.IntN(26), 'A'+rng.IntN(26), mockDigits(rng, 7))
+	birth, expiry := person.birth.Format("060102"), person.issue.AddDate(10, 0, -1).Format("060102")
+	personal := id + "<"
+	line2 := number + mrzDigit(number) + "THA" + birth + mrzDigit(birth) + person.sex +
+		expiry + mrzDigit(expiry) + personal + mrzDigit(personal)
+	line2 += mrzDigit(line2[0:10] + line2[13:20] + line2[21:43])
+	return map[string]string{"mrz_line1": line1, "mrz_line2": line2}
+}
+
+func mrzDigit(s string) string {
+	weights := [3]int{7, 3, 1}
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		v, _ := mrzValue(s[i])
+		sum += v * weights[i%3]
+	}
+	return fmt.Sprint(sum % 10)
+}
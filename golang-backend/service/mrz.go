@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidMRZ = errors.New("invalid mrz")
+
+// MRZ holds the fields of a TD3 (passport) machine readable zone.
+type MRZ struct {
+	DocumentType   string `json:"document_type"`
+	IssuingCountry string `json:"issuing_country"`
+	Surname        string `json:"surname"`
+	GivenNames     string `json:"given_names"`
+	PassportNumber string `json:"passport_number"`
+	Nationality    string `json:"nationality"`
+	BirthDate      string `json:"birth_date"`
+	Sex            string `json:"sex"`
+	ExpiryDate     string `json:"expiry_date"`
+	PersonalNumber string `json:"personal_number,omitempty"`
+	Checks         struct {
+		PassportNumber bool `json:"passport_number"`
+		BirthDate      bool `json:"birth_date"`
+		ExpiryDate     bool `json:"expiry_date"`
+		PersonalNumber bool `json:"personal_number"`
+		Composite      bool `json:"composite"`
+	} `json:"checks"`
+	Valid bool `json:"valid"`
+}
+
+// ParseMRZ parses the two 44-character lines of a TD3 MRZ and verifies its
+// ICAO 9303 check digits.
+func ParseMRZ(line1, line2 string) (*MRZ, error) {
+	line1, line2 = cleanMRZLine(line1), cleanMRZLine(line2)
+	if len(line1) != 44 || len(line2) != 44 || line1[0] != 'P' {
+		return nil, fmt.Errorf("%w: expected two 44-character TD3 lines", ErrInvalidMRZ)
+	}
+
+	m := &MRZ{
+		DocumentType:   mrzText(line1[0:2]),
+		IssuingCountry: mrzText(line1[2:5]),
+		PassportNumber: mrzText(line2[0:9]),
+		Nationality:    mrzText(line2[10:13]),
+		Sex:            mrzText(line2[20:21]),
+		PersonalNumber: mrzText(line2[28:42]),
+	}
+	names := strings.SplitN(line1[5:], "<<", 2)
+	m.Surname = mrzText(names[0])
+	if len(names) == 2 {
+		m.GivenNames = mrzText(names[1])
+	}
+	m.BirthDate = mrzDate(line2[13:19], false)
+	m.ExpiryDate = mrzDate(line2[21:27], true)
+
+	m.Checks.PassportNumber = mrzCheck(line2[0:9], line2[9])
+	m.Checks.BirthDate = mrzCheck(line2[13:19], line2[19])
+	m.Checks.ExpiryDate = mrzCheck(line2[21:27], line2[27])
+	m.Checks.PersonalNumber = mrzCheck(line2[28:42], line2[42])
+	m.Checks.Composite = mrzCheck(line2[0:10]+line2[13:20]+line2[21:43], line2[43])
+	m.Valid = m.Checks.PassportNumber && m.Checks.BirthDate && m.Checks.ExpiryDate &&
+		m.Checks.PersonalNumber && m.Checks.Composite
+	return m, nil
+}
+
+func cleanMRZLine(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			return -1
+		case r == '«':
+			return '<'
+		}
+		return r
+	}, strings.ToUpper(s))
+}
+
+func mrzCheck(s string, digit byte) bool {
+	weights := [3]int{7, 3, 1}
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		v, ok := mrzValue(s[i])
+		if !ok {
+			return false
+		}
+		sum += v * weights[i%3]
+	}
+	d, ok := mrzValue(digit)
+	return ok && sum%10 == d
+}
+
+func mrzValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10, true
+	case c == '<':
+		return 0, true
+	}
+	return 0, false
+}
+
+func mrzText(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "<", " ")), " ")
+}
+
+// mrzDate converts YYMMDD to ISO-8601. Expiry dates are assumed to fall in
+// this century; birth dates in the future are moved back a century.
+func mrzDate(s string, expiry bool) string {
+	t, err := time.Parse("060102", s)
+	if err != nil {
+		return ""
+	}
+	year := 2000 + t.Year()%100
+	if !expiry && year > time.Now().Year() {
+		year -= 100
+	}
+	return time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+}
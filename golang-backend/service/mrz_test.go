@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+// The TD3 specimen from ICAO Doc 9303 part 4.
+const (
+	specimenLine1 = "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<"
+	specimenLine2 = "L898902C36UTO7408122F1204159ZE184226B<<<<<10"
+)
+
+func TestMRZCheck(t *testing.T) {
+	tests := []struct {
+		field string
+		digit byte
+		want  bool
+	}{
+		{"L898902C3", '6', true},
+		{"740812", '2', true},
+		{"120415", '9', true},
+		{"ZE184226B<<<<<", '1', true},
+		{"L898902C3674081221204159ZE184226B<<<<<1", '0', true},
+		{"<<<<<<<<<<<<<<", '0', true},
+		{"L898902C3", '7', false},
+		{"740813", '2', false},
+		{"L898902c3", '6', false}, // lower case is not an MRZ character
+		{"740812", '<', false},
+	}
+	for _, tt := range tests {
+		if got := mrzCheck(tt.field, tt.digit); got != tt.want {
+			t.Errorf("mrzCheck(%q, %q) = %v, want %v", tt.field, tt.digit, got, tt.want)
+		}
+	}
+}
+
+func TestParseMRZ(t *testing.T) {
+	tests := []struct {
+		name         string
+		line1, line2 string
+		wantErr      bool
+		wantValid    bool
+		wantNumber   string
+		wantBirth    string
+	}{
+		{"specimen", specimenLine1, specimenLine2, false, true, "L898902C3", "1974-08-12"},
+		{"spaced and lower case", "p<uto eriksson<<anna<maria<<<<<<<<<<<<<<<<<<<", "L898902C36UTO 7408122F1204159ZE184226B<<<<<10", false, true, "L898902C3", "1974-08-12"},
+		{"misread birth date", specimenLine1, "L898902C36UTO7408132F1204159ZE184226B<<<<<10", false, false, "L898902C3", "1974-08-13"},
+		{"short line", specimenLine1, specimenLine2[:43], true, false, "", ""},
+		{"not a passport", "I" + specimenLine1[1:], specimenLine2, true, false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMRZ(tt.line1, tt.line2)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMRZ) {
+					t.Fatalf("err = %v, want ErrInvalidMRZ", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Valid != tt.wantValid || m.PassportNumber != tt.wantNumber || m.BirthDate != tt.wantBirth {
+				t.Errorf("got valid=%v number=%q birth=%q, want %v %q %q", m.Valid, m.PassportNumber, m.BirthDate, tt.wantValid, tt.wantNumber, tt.wantBirth)
+			}
+			if m.Surname != "ERIKSSON" || m.GivenNames != "ANNA MARIA" {
+				t.Errorf("names = %q, %q", m.Surname, m.GivenNames)
+			}
+		})
+	}
+}
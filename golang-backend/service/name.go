@@ -0,0 +1,168 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PersonName is a name line split into title, given name and surname.
+type PersonName struct {
+	Prefix string `json:"prefix,omitempty"`
+	First  string `json:"first,omitempty"`
+	Last   string `json:"last,omitempty"`
+
+	prefixRaw string // the title as OCR read it
+}
+
+// Longer spellings come first so นางสาว is not read as นาง.
+var (
+	thaiPrefixes = []struct{ spelling, canonical string }{
+		{"นางสาว", "นางสาว"}, {"น.ส.", "นางสาว"}, {"เด็กชาย", "เด็กชาย"}, {"ด.ช.", "เด็กชาย"},
+		{"เด็กหญิง", "เด็กหญิง"}, {"ด.ญ.", "เด็กหญิง"}, {"นาง", "นาง"}, {"นาย", "นาย"},
+	}
+	englishPrefixes = map[string]string{
+		"mr": "Mr.", "mrs": "Mrs.", "miss": "Miss", "ms": "Ms.", "master": "Master",
+	}
+	titleGenders = map[string]string{
+		"นาย": GenderMale, "เด็กชาย": GenderMale, "Mr.": GenderMale, "Master": GenderMale,
+		"นาง": GenderFemale, "นางสาว": GenderFemale, "เด็กหญิง": GenderFemale,
+		"Mrs.": GenderFemale, "Miss": GenderFemale, "Ms.": GenderFemale,
+	}
+)
+
+// Genders implied by a title.
+const (
+	GenderMale    = "M"
+	GenderFemale  = "F"
+	GenderUnknown = "unknown"
+)
+
+// personTitle returns the canonical title of a name, preferring the Thai
+// one, the title as OCR read it, and the gender it implies.
+func personTitle(th, en *PersonName) (title, raw, gender string) {
+	for _, n := range []*PersonName{th, en} {
+		if n != nil && n.Prefix != "" {
+			if g, ok := titleGenders[n.Prefix]; ok {
+				return n.Prefix, n.prefixRaw, g
+			}
+			return n.Prefix, n.prefixRaw, GenderUnknown
+		}
+	}
+	return "", "", GenderUnknown
+}
+
+func (n *PersonName) String() string {
+	if n == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(n.Prefix+" "+n.First+" "+n.Last), " ")
+}
+
+func thaiName(fields map[string]string) *PersonName {
+	n := &PersonName{
+		Prefix: first(fields, "prefix_name_th"),
+		First:  first(fields, "first_name_th"),
+		Last:   first(fields, "last_name_th"),
+	}
+	if n.First == "" && n.Last == "" {
+		n.First = first(fields, "name_th", "th_name")
+	}
+	n.prefixRaw = n.Prefix
+	if n.Prefix == "" {
+		whole := n.First
+		n.Prefix, n.First = splitThaiPrefix(n.First)
+		n.prefixRaw = strings.TrimSpace(strings.TrimSuffix(whole, n.First))
+	} else if p, rest := splitThaiPrefix(n.Prefix); p != "" && rest == "" {
+		n.Prefix = p
+	}
+	return n.split()
+}
+
+// splitThaiPrefix removes a leading Thai title, which OCR often glues to
+// the given name ("นายสมชาย").
+func splitThaiPrefix(s string) (prefix, rest string) {
+	s = strings.TrimSpace(s)
+	for _, p := range thaiPrefixes {
+		if strings.HasPrefix(s, p.spelling) {
+			return p.canonical, strings.TrimSpace(s[len(p.spelling):])
+		}
+	}
+	return "", s
+}
+
+func englishName(fields map[string]string) *PersonName {
+	n := &PersonName{
+		Prefix: first(fields, "prefix_name_en", "en_prefix"),
+		First:  first(fields, "first_name_en", "en_firstname"),
+		Last:   first(fields, "last_name_en", "en_lastname"),
+	}
+	if n.First == "" && n.Last == "" {
+		n.First = first(fields, "en_name_raw", "en_name")
+	}
+	n.prefixRaw = n.Prefix
+	if n.Prefix == "" {
+		whole := n.First
+		n.Prefix, n.First = splitEnglishPrefix(n.First)
+		n.prefixRaw = strings.TrimSpace(strings.TrimSuffix(whole, n.First))
+	} else if p, _ := splitEnglishPrefix(n.Prefix); p != "" {
+		n.Prefix = p
+	}
+	n.First, n.Last = titleCase(n.First), titleCase(n.Last)
+	return n.split()
+}
+
+func splitEnglishPrefix(s string) (prefix, rest string) {
+	word, rest, _ := strings.Cut(strings.TrimSpace(s), " ")
+	if p, ok := englishPrefixes[strings.ToLower(strings.TrimSuffix(word, "."))]; ok {
+		return p, strings.TrimSpace(rest)
+	}
+	return "", strings.TrimSpace(s)
+}
+
+// split moves everything after the first word of First into Last when the
+// OCR returned the whole name in one field, and normalizes spacing.
+func (n *PersonName) split() *PersonName {
+	n.First = strings.Join(strings.Fields(n.First), " ")
+	n.Last = strings.Join(strings.Fields(n.Last), " ")
+	if n.Last == "" {
+		n.First, n.Last, _ = strings.Cut(n.First, " ")
+	}
+	if n.Prefix == "" && n.First == "" && n.Last == "" {
+		return nil
+	}
+	return n
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r := []rune(strings.ToLower(w))
+		for j := range r {
+			if j == 0 || r[j-1] == '-' || r[j-1] == '\'' {
+				r[j] = unicode.ToUpper(r[j])
+			}
+		}
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// NameSimilarity compares two names ignoring titles, case and spacing,
+// from 0 for nothing in common to 1 for the same name.
+func NameSimilarity(a, b string) float64 {
+	ra, rb := []rune(bareName(a)), []rune(bareName(b))
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(max(len(ra), len(rb)))
+}
+
+func bareName(s string) string {
+	s = normalizeText(s)
+	if p, rest := splitThaiPrefix(s); p != "" {
+		s = rest
+	} else {
+		_, s = splitEnglishPrefix(s)
+	}
+	return strings.ToLower(s)
+}
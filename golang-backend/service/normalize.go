@@ -0,0 +1,82 @@
+package service
+
+import "strings"
+
+// originalSuffix marks the text a label had before normalizeFields changed
+// it, so results can return it beside the cleaned value.
+const originalSuffix = "_original"
+
+var (
+	// invisibleChars are zero-width characters OCR engines leave between
+	// Thai glyphs.
+	invisibleChars = strings.NewReplacer("\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "")
+	// Sara am is often read as nikhahit followed by sara aa.
+	thaiSpelling = strings.NewReplacer("\u0e4d\u0e32", "\u0e33")
+
+	// Labels holding only digits, where letters are misread digits.
+	numericLabels = map[string]bool{
+		"id_card": true, "id_number": true, "license_number": true, "license_no": true,
+		"house_code": true, "house_id": true,
+	}
+	digitLookalikes = strings.NewReplacer("O", "0", "o", "0", "D", "0", "I", "1", "l", "1", "|", "1", "S", "5", "B", "8")
+
+	// Labels holding English names, where digits are misread letters.
+	englishNameLabels = map[string]bool{
+		"prefix_name_en": true, "first_name_en": true, "last_name_en": true,
+		"en_prefix": true, "en_firstname": true, "en_lastname": true, "en_name_raw": true, "en_name": true,
+	}
+	letterLookalikes = strings.NewReplacer("0", "O", "1", "l", "5", "S", "8", "B")
+)
+
+// normalizeFields cleans the OCR text of every label before it is parsed:
+// Thai digits become Arabic ones, zero-width characters are dropped, runs of
+// whitespace collapse to one space, and letters and digits mistaken for
+// each other are swapped back where a label holds only one kind. The text
+// of each changed label is kept under label+originalSuffix.
+func normalizeFields(fields map[string]string) map[string]string {
+	out := make(map[string]string, len(fields))
+	for label, text := range fields {
+		if strings.HasSuffix(label, confidenceSuffix) {
+			out[label] = text
+			continue
+		}
+		clean := normalizeText(text)
+		switch {
+		case numericLabels[label]:
+			clean = digitLookalikes.Replace(clean)
+		case englishNameLabels[label]:
+			clean = letterLookalikes.Replace(clean)
+		}
+		out[label] = clean
+		if clean != text {
+			out[label+originalSuffix] = text
+		}
+	}
+	return out
+}
+
+func normalizeText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r >= '๐' && r <= '๙' {
+			return '0' + r - '๐'
+		}
+		return r
+	}, s)
+	s = thaiSpelling.Replace(invisibleChars.Replace(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// originalFields returns the text OCR read for each label normalizeFields
+// changed, or nil when it changed none.
+func originalFields(fields map[string]string) map[string]string {
+	var out map[string]string
+	for label, text := range fields {
+		if name, ok := strings.CutSuffix(label, originalSuffix); ok {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[name] = text
+		}
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+type Passport struct {
+	MRZ
+	MRZLines []string `json:"mrz_lines"`
+	// Gender is the MRZ sex as M, F or unknown, matching the ID card.
+	Gender    string            `json:"gender"`
+	RawFields map[string]string `json:"raw_fields,omitempty"`
+}
+
+func ScanPassport(ctx context.Context, image io.Reader) (*Passport, error) {
+	fields, err := recognize(ctx, DocumentPassport, image)
+	if err != nil {
+		return nil, err
+	}
+
+	line1, line2 := first(fields, "mrz_line1", "mrz1"), first(fields, "mrz_line2", "mrz2")
+	if line1 == "" || line2 == "" {
+		lines := strings.Fields(first(fields, "mrz"))
+		if len(lines) == 2 {
+			line1, line2 = lines[0], lines[1]
+		}
+	}
+	mrz, err := ParseMRZ(line1, line2)
+	if err != nil {
+		return nil, err
+	}
+	gender := GenderUnknown
+	if mrz.Sex == GenderMale || mrz.Sex == GenderFemale {
+		gender = mrz.Sex
+	}
+	return &Passport{MRZ: *mrz, MRZLines: []string{line1, line2}, Gender: gender, RawFields: originalFields(fields)}, nil
+}
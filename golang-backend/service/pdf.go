@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang-backend/config"
+)
+
+var ErrPDFPage = errors.New("pdf page not found")
+
+var pdfSettings = config.Default().PDF
+
+type pdfPageKey struct{}
+
+// WithPDFPage selects which page of an uploaded PDF is rendered for OCR.
+func WithPDFPage(ctx context.Context, page int) context.Context {
+	return context.WithValue(ctx, pdfPageKey{}, page)
+}
+
+func pdfPage(ctx context.Context) int {
+	if page, ok := ctx.Value(pdfPageKey{}).(int); ok && page > 0 {
+		return page
+	}
+	return 1
+}
+
+// renderPDFPage rasterises one page of pdf to JPEG using poppler's pdftoppm
+// (or the configured compatible renderer).
+func renderPDFPage(ctx context.Context, pdf []byte, page int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "scan-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.pdf")
+	if err = os.WriteFile(in, pdf, 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pdfSettings.Timeout)
+	defer cancel()
+
+	if pdfSettings.Info != "" {
+		n, err := pdfPageCount(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		if page > n {
+			return nil, ErrPDFPage
+		}
+	}
+
+	p := strconv.Itoa(page)
+	out := filepath.Join(dir, "page")
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pdfSettings.Renderer,
+		"-f", p, "-l", p, "-r", strconv.Itoa(pdfSettings.DPI), "-jpeg", "-singlefile", in, out)
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("render pdf: %w: %s", err, stderr.Bytes())
+	}
+
+	b, err := os.ReadFile(out + ".jpg")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrPDFPage
+	}
+	return b, err
+}
+
+// pdfPageCount reads the "Pages:" line pdfinfo prints for the file at path.
+func pdfPageCount(ctx context.Context, path string) (int, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pdfSettings.Info, path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("pdf info: %w: %s", err, stderr.Bytes())
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if v, ok := strings.CutPrefix(line, "Pages:"); ok {
+			return strconv.Atoi(strings.TrimSpace(v))
+		}
+	}
+	return 0, errors.New("pdf info: no page count")
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang-backend/config"
+)
+
+const (
+	BalanceRoundRobin   = "round_robin"
+	BalanceLeastPending = "least_pending"
+)
+
+// instance is one replica of the Python OCR service. A nil base means the
+// configured document URLs are used unchanged.
+type instance struct {
+	base    *url.URL
+	healthy atomic.Bool
+	pending atomic.Int64
+}
+
+// instancePool spreads calls over OCR replicas and skips ones that failed
+// their last health check or call.
+type instancePool struct {
+	client    *http.Client
+	instances []*instance
+	balance   string
+	next      atomic.Uint64
+}
+
+func newInstancePool(cfg config.OCRConfig, client *http.Client) (*instancePool, error) {
+	p := &instancePool{client: client, balance: cfg.Balance}
+	for _, raw := range cfg.Instances {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, errors.New("ocr instance " + raw + " must be an absolute URL")
+		}
+		p.instances = append(p.instances, &instance{base: u})
+	}
+	if len(p.instances) == 0 {
+		p.instances = []*instance{{}}
+	}
+	for _, in := range p.instances {
+		in.healthy.Store(true)
+	}
+	return p, nil
+}
+
+// endpoint returns target moved onto the instance's scheme and host.
+func (in *instance) endpoint(target string) string {
+	if in.base == nil {
+		return target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	u.Scheme, u.Host = in.base.Scheme, in.base.Host
+	return u.String()
+}
+
+// pick returns an instance not in tried, preferring healthy ones. When every
+// instance is unhealthy it still returns one so a recovered replica is found.
+func (p *instancePool) pick(tried map[*instance]bool) *instance {
+	var candidates []*instance
+	for _, healthy := range []bool{true, false} {
+		for _, in := range p.instances {
+			if !tried[in] && in.healthy.Load() == healthy {
+				candidates = append(candidates, in)
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	if p.balance == BalanceLeastPending {
+		best := candidates[0]
+		for _, in := range candidates[1:] {
+			if in.pending.Load() < best.pending.Load() {
+				best = in
+			}
+		}
+		return best
+	}
+	return candidates[p.next.Add(1)%uint64(len(candidates))]
+}
+
+// check pings every instance at target and records the result. It returns
+// nil when at least one instance is up.
+func (p *instancePool) check(ctx context.Context, target string) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		up   bool
+		last error
+	)
+	for _, in := range p.instances {
+		wg.Add(1)
+		go func(in *instance) {
+			defer wg.Done()
+			err := ping(ctx, p.client, in.endpoint(target))
+			in.healthy.Store(err == nil)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				up = true
+			} else {
+				last = err
+			}
+		}(in)
+	}
+	wg.Wait()
+	if up {
+		return nil
+	}
+	return last
+}
+
+// watch re-checks instance health every interval for the process lifetime.
+func (p *instancePool) watch(target string, interval time.Duration) {
+	for range time.Tick(interval) {
+		ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+		_ = p.check(ctx, target)
+		cancel()
+	}
+}
+
+// ping treats any non-5xx status as reachable since the scan routes only
+// accept POST.
+func ping(ctx context.Context, client *http.Client, target string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return &StatusError{Status: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// prepared is an upload on its way to the OCR service. Pixel-level steps
+// share one decoded copy which is re-encoded as JPEG only if it changed.
+type prepared struct {
+	image   []byte
+	upload  []byte
+	img     *image.NRGBA
+	dirty   bool
+	gray    bool
+	card    *CardRegion
+	quality *QualityReport
+	// captureType is set by checkRecapture.
+	captureType string
+}
+
+// prepare converts an upload into something the OCR service can read:
+// PDFs are rendered and HEIC converted to JPEG, then JPEGs are rotated
+// upright according to their EXIF orientation. When cropCard is set and card
+// detection is enabled the card is cropped out, then the quality and glare
+// gates and the optional preprocessing steps are applied.
+func prepare(ctx context.Context, image io.Reader, cropCard bool) (_ *prepared, err error) {
+	ctx, span := tracer.Start(ctx, "scan.prepare")
+	defer func() { endSpan(span, err) }()
+	upload, err := io.ReadAll(image)
+	if err != nil {
+		return nil, err
+	}
+	b := upload
+
+	switch DetectContentType(b) {
+	case "application/pdf":
+		if pdfSettings.Enabled {
+			if b, err = renderPDFPage(ctx, b, pdfPage(ctx)); err != nil {
+				return nil, err
+			}
+		}
+	case "image/heic":
+		if heicSettings.Enabled {
+			if b, err = convertHEIC(ctx, b); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if imageSettings.AutoOrient && DetectContentType(b) == "image/jpeg" {
+		if b, err = autoOrient(b); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &prepared{image: b, upload: upload}
+	if cropCard && imageSettings.Recapture.Enabled {
+		if err = p.checkRecapture(); err != nil {
+			return nil, err
+		}
+	}
+	if cropCard && imageSettings.CardDetection.Enabled {
+		if err = p.cropCard(); err != nil {
+			return nil, err
+		}
+	}
+	if imageSettings.Quality.Enabled {
+		if err = p.checkQuality(); err != nil {
+			return nil, err
+		}
+	}
+	if imageSettings.Glare.Enabled {
+		if err = p.checkGlare(); err != nil {
+			return nil, err
+		}
+	}
+	if preprocessEnabled() {
+		if err = p.preprocess(); err != nil {
+			return nil, err
+		}
+	}
+	if limit := imageSettings.ReencodeAbove; limit > 0 && !p.dirty && int64(len(p.image)) > limit {
+		img, err := p.pixels()
+		if err != nil {
+			return nil, err
+		}
+		p.set(img)
+	}
+	if err = p.encode(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PixelLimitError is returned for an image larger than image.max_pixels.
+type PixelLimitError struct {
+	Width, Height int
+	Max           int64
+}
+
+func (e *PixelLimitError) Error() string {
+	return fmt.Sprintf("image is %dx%d, over the limit of %d pixels", e.Width, e.Height, e.Max)
+}
+
+// DecodeImage decodes b once its header shows it is within
+// image.max_pixels, so a small file cannot expand into gigabytes of pixels.
+func DecodeImage(b []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	if max := imageSettings.MaxPixels; max > 0 && int64(cfg.Width)*int64(cfg.Height) > max {
+		return nil, &PixelLimitError{Width: cfg.Width, Height: cfg.Height, Max: max}
+	}
+	img, _, err := image.Decode(bytes.NewReader(b))
+	return img, err
+}
+
+// pixels decodes the image on first use.
+func (p *prepared) pixels() (*image.NRGBA, error) {
+	if p.img == nil {
+		src, err := DecodeImage(p.image)
+		if err != nil {
+			return nil, err
+		}
+		p.img = toNRGBA(src)
+	}
+	return p.img, nil
+}
+
+func (p *prepared) set(img *image.NRGBA) {
+	p.img, p.dirty = img, true
+}
+
+func (p *prepared) encode() error {
+	if !p.dirty && !p.gray {
+		return nil
+	}
+	var out image.Image = p.img
+	if p.gray {
+		gray := image.NewGray(p.img.Rect)
+		draw.Draw(gray, gray.Rect, p.img, image.Point{}, draw.Src)
+		out = gray
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, out, &jpeg.Options{Quality: imageSettings.JPEGQuality}); err != nil {
+		return err
+	}
+	p.image, p.dirty = buf.Bytes(), false
+	return nil
+}
+
+func (p *prepared) cropCard() error {
+	img, err := p.pixels()
+	if err != nil {
+		return err
+	}
+	if p.card = detectCard(img); p.card != nil {
+		p.set(warpCard(img, p.card))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+func preprocessEnabled() bool {
+	p := imageSettings.Preprocess
+	return p.MaxLongEdge > 0 || p.Grayscale || p.Contrast || p.Deskew
+}
+
+// preprocess applies the configured downscale, deskew, contrast and
+// grayscale steps to the decoded image.
+func (p *prepared) preprocess() error {
+	img, err := p.pixels()
+	if err != nil {
+		return err
+	}
+
+	cfg := imageSettings.Preprocess
+	if cfg.MaxLongEdge > 0 {
+		img = downscale(img, cfg.MaxLongEdge)
+	}
+	if cfg.Deskew {
+		if angle := skewAngle(img); math.Abs(angle) >= 0.3 {
+			img = rotate(img, -angle)
+		}
+	}
+	if cfg.Contrast {
+		stretchContrast(img)
+	}
+	p.set(img)
+	p.gray = cfg.Grayscale
+	return nil
+}
+
+// downscale shrinks img so its longer side is at most maxEdge pixels.
+func downscale(img *image.NRGBA, maxEdge int) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if max(w, h) <= maxEdge {
+		return img
+	}
+	scale := float64(maxEdge) / float64(max(w, h))
+	dst := image.NewNRGBA(image.Rect(0, 0, max(int(float64(w)*scale), 1), max(int(float64(h)*scale), 1)))
+	draw.CatmullRom.Scale(dst, dst.Rect, img, img.Rect, draw.Src, nil)
+	return dst
+}
+
+func luma(p []uint8) uint8 {
+	return uint8((299*uint32(p[0]) + 587*uint32(p[1]) + 114*uint32(p[2])) / 1000)
+}
+
+// stretchContrast linearly maps the 1st..99th luminance percentiles onto the
+// full 0..255 range, in place.
+func stretchContrast(img *image.NRGBA) {
+	var hist [256]int
+	for i := 0; i < len(img.Pix); i += 4 {
+		hist[luma(img.Pix[i:])]++
+	}
+	total := len(img.Pix) / 4
+	lo, hi := percentile(hist[:], total/100), percentile(hist[:], total-total/100)
+	if hi-lo < 16 {
+		return
+	}
+
+	var lut [256]uint8
+	for v := range lut {
+		s := (v - lo) * 255 / (hi - lo)
+		lut[v] = uint8(min(max(s, 0), 255))
+	}
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = lut[img.Pix[i]], lut[img.Pix[i+1]], lut[img.Pix[i+2]]
+	}
+}
+
+func percentile(hist []int, rank int) int {
+	n := 0
+	for v, c := range hist {
+		n += c
+		if n > rank {
+			return v
+		}
+	}
+	return len(hist) - 1
+}
+
+// skewAngle estimates the text skew of img in degrees (positive is
+// clockwise) by searching for the shear that maximises the variance of the
+// horizontal projection profile of dark pixels.
+func skewAngle(img *image.NRGBA) float64 {
+	small := downscale(img, 600)
+	w, h := small.Rect.Dx(), small.Rect.Dy()
+
+	var sum int
+	for i := 0; i < len(small.Pix); i += 4 {
+		sum += int(luma(small.Pix[i:]))
+	}
+	threshold := uint8(sum / (w * h) * 3 / 4)
+
+	type point struct{ x, y int }
+	var dark []point
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if luma(small.Pix[y*small.Stride+x*4:]) < threshold {
+				dark = append(dark, point{x, y})
+			}
+		}
+	}
+	if len(dark) < 100 {
+		return 0
+	}
+
+	best, bestScore := 0.0, -1.0
+	rows := make([]int, h*2)
+	for a := -10.0; a <= 10.0; a += 0.25 {
+		clear(rows)
+		t := math.Tan(a * math.Pi / 180)
+		for _, p := range dark {
+			y := p.y - int(float64(p.x)*t) + h/2
+			if y >= 0 && y < len(rows) {
+				rows[y]++
+			}
+		}
+		var score float64
+		for _, r := range rows {
+			score += float64(r * r)
+		}
+		if score > bestScore {
+			best, bestScore = a, score
+		}
+	}
+	return best
+}
+
+// rotate turns img by deg degrees clockwise around its centre, filling
+// uncovered corners with white and keeping the original size.
+func rotate(img *image.NRGBA, deg float64) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dst := image.NewNRGBA(img.Rect)
+	draw.Draw(dst, dst.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	rad := deg * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	cx, cy := float64(w)/2, float64(h)/2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			sx := int(cos*dx + sin*dy + cx)
+			sy := int(-sin*dx + cos*dy + cy)
+			if sx < 0 || sy < 0 || sx >= w || sy >= h {
+				continue
+			}
+			si := sy*img.Stride + sx*4
+			di := y*dst.Stride + x*4
+			copy(dst.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,24 @@
+package service
+
+import "context"
+
+// Stages a scan reports to the function set by WithProgress, in order.
+const (
+	StagePreprocessing = "preprocessing"
+	StageOCR           = "ocr"
+	StageParsing       = "parsing"
+)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose scans call fn as they enter each
+// stage.
+func WithProgress(ctx context.Context, fn func(stage string)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progress(ctx context.Context, stage string) {
+	if fn, ok := ctx.Value(progressKey{}).(func(string)); ok {
+		fn(stage)
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+)
+
+// Document identifies which kind of document an image shows.
+type Document string
+
+const (
+	DocumentFront             Document = "front"
+	DocumentBack              Document = "back"
+	DocumentPassport          Document = "passport"
+	DocumentDriverLicense     Document = "driver_license"
+	DocumentHouseRegistration Document = "house_registration"
+)
+
+// OCRProvider extracts label/text pairs from a prepared image. Labels follow
+// the Python service's detector classes so every provider feeds the same
+// field mapping.
+type OCRProvider interface {
+	Name() string
+	Recognize(ctx context.Context, doc Document, image []byte) (map[string]string, error)
+	Ping(ctx context.Context) error
+}
+
+var provider OCRProvider = defaultProvider()
+
+func defaultProvider() OCRProvider {
+	p, _ := newPythonProvider(config.Default().OCR) // no instances to parse
+	return p
+}
+
+// NewProvider builds the provider named by cfg.Provider behind its circuit
+// breaker and concurrency limit, wrapped with cfg.Fallback when one is set.
+func NewProvider(cfg config.OCRConfig) (OCRProvider, error) {
+	primary, err := newProvider(cfg.Provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Breaker.Enabled {
+		primary = newBreakerProvider(primary, cfg.Breaker)
+	}
+	// Outside the breaker, so time spent queueing is not a backend failure.
+	if cfg.Concurrency.MaxInFlight > 0 {
+		limit := newLimitProvider(primary, cfg.Concurrency)
+		ocrLimit.Store(limit)
+		primary = limit
+	}
+	if cfg.Fallback == "" {
+		return primary, nil
+	}
+	fallback, err := newProvider(cfg.Fallback, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &fallbackProvider{primary: primary, fallback: fallback}, nil
+}
+
+func newProvider(name string, cfg config.OCRConfig) (OCRProvider, error) {
+	switch name {
+	case "", "python":
+		p, err := newPythonProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if len(cfg.Instances) > 1 && cfg.HealthInterval > 0 {
+			go p.pool.watch(cfg.URL, cfg.HealthInterval)
+		}
+		return p, nil
+	case "google":
+		return newGoogleVisionProvider(cfg.Google), nil
+	case "textract":
+		return newTextractProvider(cfg.Textract), nil
+	case "azure":
+		return newAzureProvider(cfg.Azure), nil
+	case "tesseract":
+		return newTesseractProvider(cfg.Tesseract)
+	case "mock":
+		return newMockProvider(cfg.Mock), nil
+	}
+	return nil, fmt.Errorf("unknown ocr provider %q", name)
+}
+
+func SetProvider(p OCRProvider) {
+	provider = p
+}
+
+// textProvider adapts an engine that only returns plain text by mapping the
+// text onto labels with fieldsFromText.
+type textProvider struct {
+	name   string
+	detect func(ctx context.Context, image []byte) (string, error)
+}
+
+func (p *textProvider) Name() string { return p.name }
+
+// Ping is a no-op; hosted engines have no cheap health endpoint and a scan
+// surfaces credential or network problems anyway.
+func (p *textProvider) Ping(context.Context) error { return nil }
+
+func (p *textProvider) Recognize(ctx context.Context, doc Document, image []byte) (map[string]string, error) {
+	text, err := p.detect(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	return fieldsFromText(doc, text), nil
+}
+
+// StatusError is returned when an OCR backend answers with a non-success
+// status.
+type StatusError struct {
+	Status int
+	Body   []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ocr service returned %d: %s", e.Status, e.Body)
+}
+
+// unavailable reports whether err means the backend could not serve the
+// request at all, as opposed to rejecting this image.
+func unavailable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Status >= 500
+	}
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// fallbackProvider sends a request to fallback when primary is unavailable.
+type fallbackProvider struct {
+	primary, fallback OCRProvider
+}
+
+func (p *fallbackProvider) Name() string { return p.primary.Name() }
+
+// Ping succeeds when either provider can serve scans.
+func (p *fallbackProvider) Ping(ctx context.Context) error {
+	if err := p.primary.Ping(ctx); err != nil {
+		return p.fallback.Ping(ctx)
+	}
+	return nil
+}
+
+func (p *fallbackProvider) Recognize(ctx context.Context, doc Document, image []byte) (map[string]string, error) {
+	fields, err := p.primary.Recognize(ctx, doc, image)
+	if !unavailable(err) || ctx.Err() != nil {
+		return fields, err
+	}
+	logging.FromContext(ctx).Warn("ocr provider unavailable, using fallback",
+		"provider", p.primary.Name(), "fallback", p.fallback.Name(), "error", err)
+	return p.fallback.Recognize(ctx, doc, image)
+}
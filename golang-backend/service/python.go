@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+)
+
+// pythonProvider calls the YOLO + EasyOCR service in OCR/, which exposes
+// one endpoint per document type, load balancing over its replicas.
+type pythonProvider struct {
+	urls map[Document]string
+	pool *instancePool
+}
+
+func newPythonProvider(cfg config.OCRConfig) (*pythonProvider, error) {
+	c, err := newOCRClient(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := newInstancePool(cfg, c)
+	if err != nil {
+		return nil, err
+	}
+	p := &pythonProvider{pool: pool, urls: map[Document]string{
+		DocumentFront:             cfg.URL,
+		DocumentBack:              cfg.BackURL,
+		DocumentPassport:          cfg.PassportURL,
+		DocumentDriverLicense:     cfg.DriverLicenseURL,
+		DocumentHouseRegistration: cfg.HouseRegistrationURL,
+	}}
+	if cfg.TLS.RequireHTTPS {
+		urls := []string{cfg.URL, cfg.BackURL, cfg.PassportURL, cfg.DriverLicenseURL, cfg.HouseRegistrationURL}
+		for _, raw := range append(urls, cfg.Instances...) {
+			if raw != "" && !strings.HasPrefix(raw, "https://") {
+				return nil, fmt.Errorf("ocr url %s must use https", raw)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *pythonProvider) Name() string { return "python" }
+
+// Ping checks every replica and succeeds when one answers.
+func (p *pythonProvider) Ping(ctx context.Context) error {
+	return p.pool.check(ctx, p.urls[DocumentFront])
+}
+
+// Recognize sends image to a replica, failing over to the others while
+// they are unavailable.
+func (p *pythonProvider) Recognize(ctx context.Context, doc Document, image []byte) (map[string]string, error) {
+	tried := map[*instance]bool{}
+	var err error
+	for in := p.pool.pick(tried); in != nil; in = p.pool.pick(tried) {
+		tried[in] = true
+		var fields map[string]string
+		in.pending.Add(1)
+		fields, err = p.recognize(ctx, in.endpoint(p.urls[doc]), image)
+		in.pending.Add(-1)
+		if !unavailable(err) {
+			return fields, err
+		}
+		in.healthy.Store(false)
+		logging.FromContext(ctx).Warn("ocr instance unavailable", "endpoint", in.endpoint(p.urls[doc]), "error", err)
+	}
+	return nil, err
+}
+
+// recognize uploads image to endpoint and returns the label/text pairs it
+// extracted.
+func (p *pythonProvider) recognize(ctx context.Context, endpoint string, image []byte) (map[string]string, error) {
+	requestID := logging.RequestID(ctx)
+	head, tail, contentType, err := uploadFraming()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := doWithRetry(ctx, p.pool.client, func(ctx context.Context) (*http.Request, error) {
+		body := io.MultiReader(bytes.NewReader(head), bytes.NewReader(image), bytes.NewReader(tail))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = int64(len(head) + len(image) + len(tail))
+		req.Header.Set("Content-Type", contentType)
+		if requestID != "" {
+			req.Header.Set(logging.RequestIDHeader, requestID)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.status != http.StatusOK {
+		return nil, &StatusError{Status: resp.status, Body: resp.body}
+	}
+	return decodeFields(resp.body)
+}
+
+// uploadFraming returns what goes before and after the image in a
+// multipart body holding it as "file". Each attempt reads the image in
+// place between the two rather than copying it into an assembled body.
+// The upload itself cannot be streamed through: orientation, PDF and HEIC
+// conversion and the card crop need the whole image in memory first.
+func uploadFraming() (head, tail []byte, contentType string, err error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if _, err = w.CreateFormFile("file", "upload.jpg"); err != nil {
+		return nil, nil, "", err
+	}
+	head = bytes.Clone(buf.Bytes())
+	buf.Reset()
+	if err = w.Close(); err != nil {
+		return nil, nil, "", err
+	}
+	return head, buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// confidenceSuffix marks a per-label confidence in the decoded fields. The
+// OCR service may send either "<label>_confidence" strings or a
+// "confidence" object keyed by label.
+const confidenceSuffix = "_confidence"
+
+func decodeFields(b []byte) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("decode ocr response: %w", err)
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			fields[k] = s
+			continue
+		}
+		var scores map[string]float64
+		if k == "confidence" && json.Unmarshal(v, &scores) == nil {
+			for label, score := range scores {
+				fields[label+confidenceSuffix] = strconv.FormatFloat(score, 'f', -1, 64)
+			}
+			continue
+		}
+		var n json.Number
+		if json.Unmarshal(v, &n) == nil {
+			fields[k] = n.String()
+		}
+	}
+	return fields, nil
+}
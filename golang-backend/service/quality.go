@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"image"
+)
+
+const (
+	QualityBlurry   = "blurry"
+	QualityTooDark  = "too_dark"
+	QualityTooSmall = "too_small"
+)
+
+type QualityReport struct {
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Brightness float64 `json:"brightness"`
+	Sharpness  float64 `json:"sharpness"`
+
+	GlareRatio    float64 `json:"glare_ratio"`
+	GlareDetected bool    `json:"glare_detected"`
+}
+
+// QualityError rejects an image before OCR. Reason is one of the Quality*
+// constants.
+type QualityError struct {
+	Reason    string
+	Value     float64
+	Threshold float64
+}
+
+func (e *QualityError) Error() string {
+	return fmt.Sprintf("image rejected: %s (value %.3f, threshold %.3f)", e.Reason, e.Value, e.Threshold)
+}
+
+// measureQuality scores brightness as mean luma and sharpness as the
+// variance of the Laplacian, both on a copy normalised to 1000px so the
+// thresholds do not depend on the upload resolution.
+func measureQuality(img *image.NRGBA) QualityReport {
+	r := QualityReport{Width: img.Rect.Dx(), Height: img.Rect.Dy()}
+	small := downscale(img, 1000)
+	w, h := small.Rect.Dx(), small.Rect.Dy()
+
+	gray := make([]float64, w*h)
+	var sum float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := float64(luma(small.Pix[y*small.Stride+x*4:]))
+			gray[y*w+x] = v
+			sum += v
+		}
+	}
+	r.Brightness = sum / float64(w*h)
+
+	var n, mean, m2 float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			lap := gray[i-w] + gray[i+w] + gray[i-1] + gray[i+1] - 4*gray[i]
+			n++
+			d := lap - mean
+			mean += d / n
+			m2 += d * (lap - mean)
+		}
+	}
+	if n > 1 {
+		r.Sharpness = m2 / (n - 1)
+	}
+	return r
+}
+
+func (r QualityReport) check() error {
+	cfg := imageSettings.Quality
+	if short := min(r.Width, r.Height); short < cfg.MinShortEdge {
+		return &QualityError{Reason: QualityTooSmall, Value: float64(short), Threshold: float64(cfg.MinShortEdge)}
+	}
+	if r.Brightness < cfg.MinBrightness {
+		return &QualityError{Reason: QualityTooDark, Value: r.Brightness, Threshold: cfg.MinBrightness}
+	}
+	if r.Sharpness < cfg.MinSharpness {
+		return &QualityError{Reason: QualityBlurry, Value: r.Sharpness, Threshold: cfg.MinSharpness}
+	}
+	return nil
+}
+
+func (p *prepared) checkQuality() error {
+	img, err := p.pixels()
+	if err != nil {
+		return err
+	}
+	report := measureQuality(img)
+	p.quality = &report
+	return report.check()
+}
+
+// CheckFrame applies the quality and glare checks to a camera frame without
+// calling OCR, whether or not they are enabled for uploads, so a live scan
+// only sends usable frames to the provider.
+func CheckFrame(frame []byte) (*QualityReport, error) {
+	p := &prepared{image: frame, upload: frame}
+	if err := p.checkQuality(); err != nil {
+		return p.quality, err
+	}
+	err := p.checkGlare()
+	return p.quality, err
+}
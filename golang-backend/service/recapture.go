@@ -0,0 +1,155 @@
+package service
+
+import (
+	"image"
+	"math"
+)
+
+const QualityRecapture = "recapture"
+
+// Capture types: a physical card, a photo of a screen showing one, or a
+// photocopy.
+const (
+	CaptureCard      = "card"
+	CaptureScreen    = "screen"
+	CapturePhotocopy = "photocopy"
+)
+
+// recapture holds the cues classifyCapture weighed.
+type recapture struct {
+	captureType string
+	moire       float64
+	saturation  float64
+	bezel       bool
+}
+
+// classifyCapture looks at the whole frame for signs it is not a physical
+// card: moiré from photographing a screen's pixel grid, a dark uniform
+// screen bezel around the picture, or the colourless print of a photocopy.
+// Thai cards are printed in colour, so near-grey frames are copies.
+func classifyCapture(img *image.NRGBA) recapture {
+	small := downscale(img, 1000)
+	w, h := small.Rect.Dx(), small.Rect.Dy()
+	gray := make([]float64, w*h)
+	var sat float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := small.Pix[y*small.Stride+x*4:]
+			gray[y*w+x] = float64(luma(p))
+			sat += float64(max(p[0], p[1], p[2])-min(p[0], p[1], p[2])) / 255
+		}
+	}
+	r := recapture{captureType: CaptureCard, moire: moireScore(gray, w, h), bezel: hasBezel(gray, w, h)}
+	if w*h > 0 {
+		r.saturation = sat / float64(w*h)
+	}
+
+	cfg := imageSettings.Recapture
+	switch {
+	case r.moire >= cfg.MoireThreshold || r.bezel:
+		r.captureType = CaptureScreen
+	case r.saturation < cfg.MinSaturation:
+		r.captureType = CapturePhotocopy
+	}
+	return r
+}
+
+// moireScore is the strongest normalised autocorrelation of the horizontal
+// and vertical gradients at lags of 2 to 8 pixels. Edges and text give
+// isolated or paired opposite gradients that correlate weakly or
+// negatively; a regular pattern correlates strongly at its period.
+func moireScore(gray []float64, w, h int) float64 {
+	const minLag, maxLag = 2, 8
+	var best float64
+	for _, step := range [2][2]int{{1, w}, {w, 1}} { // along rows, along columns
+		along, lines := w, h
+		if step[0] != 1 {
+			along, lines = h, w
+		}
+		if along <= maxLag+1 {
+			continue
+		}
+		var energy float64
+		corr := make([]float64, maxLag+1)
+		d := make([]float64, along-1)
+		for l := 0; l < lines; l++ {
+			for i := range d {
+				d[i] = gray[l*step[1]+(i+1)*step[0]] - gray[l*step[1]+i*step[0]]
+				energy += d[i] * d[i]
+			}
+			for k := minLag; k <= maxLag; k++ {
+				for i := 0; i+k < len(d); i++ {
+					corr[k] += d[i] * d[i+k]
+				}
+			}
+		}
+		if energy == 0 {
+			continue
+		}
+		for k := minLag; k <= maxLag; k++ {
+			best = max(best, corr[k]/energy)
+		}
+	}
+	return best
+}
+
+// hasBezel reports whether every edge of the frame is a dark, even band
+// around a brighter picture, as when a monitor or phone is photographed
+// with its frame in view.
+func hasBezel(gray []float64, w, h int) bool {
+	bw, bh := max(w/50, 1), max(h/50, 1)
+	if w <= 4*bw || h <= 4*bh {
+		return false
+	}
+	bands := [4]image.Rectangle{
+		image.Rect(0, 0, w, bh), image.Rect(0, h-bh, w, h),
+		image.Rect(0, 0, bw, h), image.Rect(w-bw, 0, w, h),
+	}
+	for _, b := range bands {
+		mean, sd := regionStats(gray, w, b)
+		if mean > 40 || sd > 12 {
+			return false
+		}
+	}
+	mean, _ := regionStats(gray, w, image.Rect(2*bw, 2*bh, w-2*bw, h-2*bh))
+	return mean > 80
+}
+
+func regionStats(gray []float64, w int, r image.Rectangle) (mean, sd float64) {
+	var sum, sq, n float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := gray[y*w+x]
+			sum += v
+			sq += v * v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	mean = sum / n
+	return mean, math.Sqrt(max(sq/n-mean*mean, 0))
+}
+
+// checkRecapture classifies the frame before the card is cropped out of
+// it, so the bezel is still in view, and rejects recaptures when
+// configured to.
+func (p *prepared) checkRecapture() error {
+	img, err := p.pixels()
+	if err != nil {
+		return err
+	}
+	r := classifyCapture(img)
+	p.captureType = r.captureType
+	if !imageSettings.Recapture.Reject {
+		return nil
+	}
+	switch r.captureType {
+	case CaptureScreen:
+		return &QualityError{Reason: QualityRecapture, Value: r.moire, Threshold: imageSettings.Recapture.MoireThreshold}
+	case CapturePhotocopy:
+		return &QualityError{Reason: QualityRecapture, Value: r.saturation, Threshold: imageSettings.Recapture.MinSaturation}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"golang-backend/logging"
+)
+
+var ErrPanic = errors.New("panic")
+
+// Recover sets *err to ErrPanic if the calling goroutine panics, logging
+// the stack. Goroutines started for a request defer it, as a panic there
+// is out of reach of the recovery middleware and would stop the server.
+func Recover(ctx context.Context, err *error) {
+	if p := recover(); p != nil {
+		logging.FromContext(ctx).Error("panic recovered", "panic", p, "stack", string(debug.Stack()))
+		*err = fmt.Errorf("%w: %v", ErrPanic, p)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"syscall"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/metrics"
+)
+
+var retry = config.Default().OCR.Retry
+
+// response is a fully read HTTP response.
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// doWithRetry sends the request built by newReq, retrying transient failures
+// (connection refused, 502/503/504) with exponential backoff and jitter. The
+// response body is fully read so the connection can be reused.
+func doWithRetry(ctx context.Context, client *http.Client, newReq func(context.Context) (*http.Request, error)) (*response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := doOnce(ctx, client, newReq)
+		if attempt+1 >= retry.MaxAttempts || !retryable(resp, err) {
+			return resp, err
+		}
+
+		t := time.NewTimer(backoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// doOnce performs a single attempt bounded by the configured per-call timeout.
+func doOnce(ctx context.Context, client *http.Client, newReq func(context.Context) (*http.Request, error)) (*response, error) {
+	if callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, callTimeout)
+		defer cancel()
+	}
+
+	req, err := newReq(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		metrics.ObserveOCR(0, time.Since(start))
+		return nil, err
+	}
+	defer resp.Body.Close()
+	metrics.ObserveOCR(resp.StatusCode, time.Since(start))
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &response{status: resp.StatusCode, header: resp.Header, body: b}, nil
+}
+
+func retryable(resp *response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNREFUSED)
+	}
+	switch resp.status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func backoff(attempt int) time.Duration {
+	d := retry.InitialBackoff << attempt
+	if d <= 0 || d > retry.MaxBackoff {
+		d = retry.MaxBackoff
+	}
+	if retry.Jitter > 0 {
+		d += time.Duration(rand.Float64() * retry.Jitter * float64(d))
+	}
+	return d
+}
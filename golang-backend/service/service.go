@@ -1,45 +1,78 @@
 package service
 
 import (
-	"bytes"
+	"context"
 	"io"
-	"mime/multipart"
-	"net/http"
+	"time"
+
+	"golang-backend/config"
 )
 
-func Scan(image multipart.File) (*string, error) {
-	url := "http://127.0.0.1:5000/ocr/thai-id/"
+var (
+	healthTimeout  = config.Default().OCR.HealthTimeout
+	callTimeout    = config.Default().OCR.Timeout
+	clientSettings = config.Default().HTTPClient
+	client         = newHTTPClient(clientSettings)
+	imageSettings  = config.Default().Image
+	cardSettings   = config.Default().Card
+)
 
-	var body bytes.Buffer
-	w := multipart.NewWriter(&body)
+func Configure(cfg *config.Config) {
+	healthTimeout = cfg.OCR.HealthTimeout
+	clientSettings = cfg.HTTPClient
+	client = newHTTPClient(clientSettings)
+	retry = cfg.OCR.Retry
+	configureFetch(cfg)
+	pdfSettings = cfg.PDF
+	heicSettings = cfg.HEIC
+	imageSettings = cfg.Image
+	cardSettings = cfg.Card
+	dopaSettings = cfg.DOPA
+	faceSettings = cfg.Face
+	callTimeout = cfg.OCR.Timeout
+}
 
-	fw, err := w.CreateFormFile("file", "upload.jpg")
+func Scan(ctx context.Context, image io.Reader) (*ThaiIDCard, error) {
+	progress(ctx, StagePreprocessing)
+	p, err := prepare(ctx, image, true)
 	if err != nil {
 		return nil, err
 	}
-	if _, err = io.Copy(fw, image); err != nil {
+	progress(ctx, StageOCR)
+	fields, err := recognizeImage(ctx, DocumentFront, p)
+	if err != nil {
 		return nil, err
 	}
-	if err = w.Close(); err != nil { // finalize boundary
-		return nil, err
+	progress(ctx, StageParsing)
+	card := newThaiIDCard(fields)
+	card.IDValid = ValidCitizenID(card.IDNumber)
+	card.checkExpiry(time.Now())
+	card.checkAge(ctx, time.Now())
+	card.scoreConfidence(fields)
+	if card.Expired && cardSettings.RejectExpired {
+		return nil, ErrCardExpired
 	}
-
-	req, err := http.NewRequest("POST", url, &body)
-	if err != nil {
-		return nil, err
+	card.CardRegion = p.card
+	card.Quality = p.quality
+	if p.quality != nil && p.quality.GlareDetected {
+		card.Warnings = append(card.Warnings, "glare_detected")
 	}
-	req.Header.Set("Content-Type", w.FormDataContentType())
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+	if card.CaptureType = p.captureType; card.CaptureType == CaptureScreen || card.CaptureType == CapturePhotocopy {
+		card.Warnings = append(card.Warnings, "recapture_suspected")
+	}
+	if imageSettings.Photo {
+		if card.Photo, err = extractPhoto(p, fields); err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
+	return card, nil
+}
 
-	b, err := io.ReadAll(resp.Body)
+// recognize prepares image and sends it to the configured OCR provider.
+func recognize(ctx context.Context, doc Document, image io.Reader) (map[string]string, error) {
+	p, err := prepare(ctx, image, false)
 	if err != nil {
 		return nil, err
 	}
-	res := string(b)
-	return &res, nil
+	return recognizeImage(ctx, doc, p)
 }
@@ -0,0 +1,11 @@
+package service
+
+import "net/http"
+
+// DetectContentType extends http.DetectContentType with HEIC detection.
+func DetectContentType(b []byte) string {
+	if isHEIC(b) {
+		return "image/heic"
+	}
+	return http.DetectContentType(b)
+}
@@ -0,0 +1,33 @@
+//go:build tesseract
+
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/otiai10/gosseract/v2"
+
+	"golang-backend/config"
+)
+
+// newTesseractProvider runs libtesseract in-process. A client is created per
+// call since gosseract clients are not safe for concurrent use.
+func newTesseractProvider(cfg config.TesseractConfig) (OCRProvider, error) {
+	return &textProvider{name: "tesseract", detect: func(ctx context.Context, image []byte) (string, error) {
+		c := gosseract.NewClient()
+		defer c.Close()
+		if cfg.DataPath != "" {
+			if err := c.SetTessdataPrefix(cfg.DataPath); err != nil {
+				return "", err
+			}
+		}
+		if err := c.SetLanguage(strings.Split(cfg.Languages, "+")...); err != nil {
+			return "", err
+		}
+		if err := c.SetImageFromBytes(image); err != nil {
+			return "", err
+		}
+		return c.Text()
+	}}, nil
+}
@@ -0,0 +1,13 @@
+//go:build !tesseract
+
+package service
+
+import (
+	"errors"
+
+	"golang-backend/config"
+)
+
+func newTesseractProvider(config.TesseractConfig) (OCRProvider, error) {
+	return nil, errors.New("built without tesseract support, rebuild with -tags tesseract")
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	textIDNumber  = regexp.MustCompile(`\b\d[ -]?\d{4}[ -]?\d{5}[ -]?\d{2}[ -]?\d\b`)
+	textLaserCode = regexp.MustCompile(`\b[A-Z]{2}\d[ -]?\d{7}[ -]?\d{2}\b`)
+	textHouseCode = regexp.MustCompile(`\b\d{4}[ -]?\d{6}[ -]?\d\b`)
+	textLicense   = regexp.MustCompile(`\b\d{8}\b`)
+	textMRZ       = regexp.MustCompile(`^[A-Z0-9<]{44}$`)
+	textDate      = regexp.MustCompile(`\d{1,2}\s*[^\d\s]+\.?\s*\d{4}`)
+)
+
+// textLabel finds a field by the caption printed next to it. Captions
+// followed by a value on the same line capture it; date captions fall back
+// to the neighbouring lines, where cards print the date above or below.
+type textLabel struct {
+	label   string
+	caption *regexp.Regexp
+	date    bool
+	last    bool // take the last date when a line holds two
+}
+
+var frontLabels = []textLabel{
+	{label: "name_th", caption: regexp.MustCompile(`ชื่อตัวและชื่อสกุล\s*(.*)`)},
+	{label: "last_name_en", caption: regexp.MustCompile(`(?i)^last\s*name\s*(.*)`)},
+	{label: "first_name_en", caption: regexp.MustCompile(`(?i)^name\s*(.*)`)},
+	{label: "date_of_birth_th", caption: regexp.MustCompile(`เกิดวันที่\s*(.*)`), date: true},
+	{label: "date_of_birth_en", caption: regexp.MustCompile(`(?i)date\s*of\s*birth\s*(.*)`), date: true},
+	{label: "date_of_issue_th", caption: regexp.MustCompile(`วันออกบัตร\s*(.*)`), date: true},
+	{label: "date_of_issue_en", caption: regexp.MustCompile(`(?i)date\s*of\s*issue\s*(.*)`), date: true},
+	{label: "date_of_expity_th", caption: regexp.MustCompile(`วันบัตรหมดอายุ\s*(.*)`), date: true, last: true},
+	{label: "date_of_expity_en", caption: regexp.MustCompile(`(?i)date\s*of\s*expiry\s*(.*)`), date: true, last: true},
+}
+
+// fieldsFromText maps the plain text returned by hosted OCR engines onto the
+// labels the Python service produces.
+func fieldsFromText(doc Document, text string) map[string]string {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.Join(strings.Fields(l), " "); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	fields := map[string]string{}
+	match := func(label string, re *regexp.Regexp) {
+		if m := re.FindString(text); m != "" {
+			fields[label] = m
+		}
+	}
+
+	switch doc {
+	case DocumentBack:
+		match("laser_code", textLaserCode)
+		match("id_card", textIDNumber)
+	case DocumentPassport:
+		var mrz []string
+		for _, l := range lines {
+			if l = strings.ReplaceAll(l, " ", ""); textMRZ.MatchString(l) {
+				mrz = append(mrz, l)
+			}
+		}
+		if len(mrz) >= 2 {
+			fields["mrz_line1"], fields["mrz_line2"] = mrz[len(mrz)-2], mrz[len(mrz)-1]
+		}
+	case DocumentHouseRegistration:
+		match("house_code", textHouseCode)
+		captioned(fields, lines, "house_number", regexp.MustCompile(`บ้านเลขที่\s*(\S+)`))
+		address(fields, lines)
+	default:
+		match("id_card", textIDNumber)
+		if doc == DocumentDriverLicense {
+			match("license_number", textLicense)
+		}
+		for _, l := range frontLabels {
+			if l.date {
+				captionedDate(fields, lines, l)
+			} else {
+				captioned(fields, lines, l.label, l.caption)
+			}
+		}
+		address(fields, lines)
+	}
+	return fields
+}
+
+func captioned(fields map[string]string, lines []string, label string, caption *regexp.Regexp) {
+	for _, l := range lines {
+		if m := caption.FindStringSubmatch(l); m != nil && m[1] != "" {
+			fields[label] = m[1]
+			return
+		}
+	}
+}
+
+func captionedDate(fields map[string]string, lines []string, l textLabel) {
+	for i, line := range lines {
+		m := l.caption.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, candidate := range []string{m[1], neighbour(lines, i-1), neighbour(lines, i+1)} {
+			if l.last && isLifetime(candidate) {
+				fields[l.label] = candidate
+				return
+			}
+			dates := textDate.FindAllString(candidate, -1)
+			if len(dates) == 0 {
+				continue
+			}
+			if l.last {
+				fields[l.label] = dates[len(dates)-1]
+			} else {
+				fields[l.label] = dates[0]
+			}
+			return
+		}
+	}
+}
+
+func neighbour(lines []string, i int) string {
+	if i < 0 || i >= len(lines) {
+		return ""
+	}
+	return lines[i]
+}
+
+// address joins the ที่อยู่ line with the following line, which holds the
+// district and province on both card and house registration layouts.
+func address(fields map[string]string, lines []string) {
+	for i, l := range lines {
+		rest, ok := strings.CutPrefix(l, "ที่อยู่")
+		if !ok {
+			continue
+		}
+		parts := []string{strings.TrimSpace(rest)}
+		if next := neighbour(lines, i+1); next != "" && !textDate.MatchString(next) && !strings.Contains(next, "วัน") {
+			parts = append(parts, next)
+		}
+		fields["address"] = strings.TrimSpace(strings.Join(parts, " "))
+		return
+	}
+}
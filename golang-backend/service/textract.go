@@ -0,0 +1,69 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/sigv4"
+)
+
+// newTextractProvider calls AWS Textract DetectDocumentText, signing
+// requests with SigV4.
+func newTextractProvider(cfg config.TextractConfig) *textProvider {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://textract." + cfg.Region + ".amazonaws.com/"
+	}
+	return &textProvider{name: "textract", detect: func(ctx context.Context, image []byte) (string, error) {
+		body, err := json.Marshal(map[string]any{
+			"Document": map[string]string{"Bytes": base64.StdEncoding.EncodeToString(image)},
+		})
+		if err != nil {
+			return "", err
+		}
+		resp, err := doWithRetry(ctx, client, func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+			req.Header.Set("X-Amz-Target", "Textract.DetectDocumentText")
+			sigv4.Sign(req, body, sigv4.Credentials{
+				AccessKeyID:     cfg.AccessKeyID,
+				SecretAccessKey: cfg.SecretAccessKey,
+				SessionToken:    cfg.SessionToken,
+			}, cfg.Region, "textract", time.Now())
+			return req, nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if resp.status != http.StatusOK {
+			return "", fmt.Errorf("textract returned %d: %s", resp.status, resp.body)
+		}
+
+		var out struct {
+			Blocks []struct {
+				BlockType string `json:"BlockType"`
+				Text      string `json:"Text"`
+			} `json:"Blocks"`
+		}
+		if err = json.Unmarshal(resp.body, &out); err != nil {
+			return "", fmt.Errorf("decode textract response: %w", err)
+		}
+		var lines []string
+		for _, b := range out.Blocks {
+			if b.BlockType == "LINE" {
+				lines = append(lines, b.Text)
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}}
+}
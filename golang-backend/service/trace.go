@@ -0,0 +1,19 @@
+package service
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of a scan's stages, beneath the request's span.
+var tracer = otel.Tracer("golang-backend/service")
+
+// endSpan marks span failed when err is set, then ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
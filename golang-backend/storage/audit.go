@@ -0,0 +1,55 @@
+package storage
+
+import "time"
+
+const (
+	AuditScan    = "scan.create"
+	AuditRead    = "scan.read"
+	AuditList    = "scan.list"
+	AuditCorrect = "scan.correct"
+	AuditDelete  = "scan.delete"
+	AuditReview  = "scan.review"
+	AuditExport  = "scan.export"
+	AuditSearch  = "scan.search"
+	AuditPurge   = "scan.purge"
+	AuditReport  = "scan.report"
+	AuditWatch   = "scan.watch"
+
+	AuditTenantUpdate = "tenant.update"
+	AuditKeyCreate    = "key.create"
+	AuditKeyRotate    = "key.rotate"
+	AuditKeyRevoke    = "key.revoke"
+
+	AuditSucceeded = "succeeded"
+	AuditDenied    = "denied"
+	AuditFailed    = "failed"
+
+	retentionActor = "system:retention"
+)
+
+// AuditEvent is one append-only audit log entry. Actor is the API key ID of
+// the caller, or "system:..." for background work. Status is the HTTP
+// status for events recorded from requests.
+type AuditEvent struct {
+	ID       int64     `json:"id"`
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor,omitempty"`
+	Action   string    `json:"action"`
+	ScanID   string    `json:"scan_id,omitempty"`
+	Route    string    `json:"route,omitempty"`
+	Outcome  string    `json:"outcome"`
+	Status   int       `json:"status,omitempty"`
+	ClientIP string    `json:"client_ip,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+// AuditFilter selects audit events for ListAudit, newest first.
+type AuditFilter struct {
+	From   time.Time
+	To     time.Time
+	Actor  string
+	Action string
+	ScanID string
+	Limit  int
+	Offset int
+}
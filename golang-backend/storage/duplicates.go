@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+
+	"golang-backend/service"
+)
+
+// maxPriorScans caps the earlier scans listed on a duplicate.
+const maxPriorScans = 10
+
+// CheckDuplicate looks for earlier scans in s's tenant with the same
+// citizen ID. When there are any, result is flagged with their number, and
+// with the scans themselves when listPrior is set, and stored in s again,
+// so the saved and returned results agree. Callers that may only read
+// their own key's scans pass listPrior false, so the IDs and keys of other
+// keys' scans are not disclosed. Results without a FlagDuplicate method are
+// left alone.
+func CheckDuplicate(ctx context.Context, r Repository, s *Scan, result any, listPrior bool) error {
+	f, ok := result.(interface {
+		FlagDuplicate(int, []service.PriorScan)
+	})
+	if !ok || r == nil || s.IDHash == "" {
+		return nil
+	}
+	prior, err := r.List(ctx, Filter{IDHash: s.IDHash, Tenant: s.Tenant, Limit: maxPriorScans})
+	if err != nil || len(prior) == 0 {
+		return err
+	}
+	var refs []service.PriorScan
+	if listPrior {
+		refs = make([]service.PriorScan, len(prior))
+		for i, p := range prior {
+			refs[i] = service.PriorScan{ScanID: p.ID, KeyID: p.KeyID, CreatedAt: p.CreatedAt}
+		}
+	}
+	f.FlagDuplicate(len(prior), refs)
+	s.SetResult(result, nil)
+	return nil
+}
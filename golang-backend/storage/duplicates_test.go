@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang-backend/service"
+)
+
+func TestCheckDuplicate(t *testing.T) {
+	ctx := context.Background()
+	r := newMemRepository()
+	now := time.Now()
+	for i, key := range []string{"k1", "k2"} {
+		r.Save(ctx, &Scan{ID: key + "-scan", KeyID: key, Tenant: "t1", IDHash: "h1", CreatedAt: now.Add(time.Duration(i) * time.Second)})
+	}
+	r.Save(ctx, &Scan{ID: "other-tenant", KeyID: "k1", Tenant: "t2", IDHash: "h1", CreatedAt: now})
+
+	tests := []struct {
+		name      string
+		listPrior bool
+		wantIDs   []string
+	}{
+		{"may read every key", true, []string{"k2-scan", "k1-scan"}},
+		{"own key only", false, nil},
+	}
+	for _, tt := range tests {
+		card := &service.ThaiIDCard{}
+		s := &Scan{KeyID: "k1", Tenant: "t1", IDHash: "h1"}
+		if err := CheckDuplicate(ctx, r, s, card, tt.listPrior); err != nil {
+			t.Fatal(err)
+		}
+		if card.PriorScanCount != 2 || len(card.Warnings) != 1 {
+			t.Errorf("%s: count %d warnings %v, want 2 and duplicate_identity", tt.name, card.PriorScanCount, card.Warnings)
+		}
+		var ids []string
+		for _, p := range card.PriorScans {
+			ids = append(ids, p.ScanID)
+		}
+		if len(ids) != len(tt.wantIDs) || len(ids) > 0 && (ids[0] != tt.wantIDs[0] || ids[1] != tt.wantIDs[1]) {
+			t.Errorf("%s: prior scans %v, want %v", tt.name, ids, tt.wantIDs)
+		}
+	}
+
+	card := &service.ThaiIDCard{}
+	if err := CheckDuplicate(ctx, r, &Scan{Tenant: "t1", IDHash: "h2"}, card, true); err != nil || card.PriorScanCount != 0 || card.Warnings != nil {
+		t.Errorf("first scan of a person flagged: %+v, %v", card, err)
+	}
+}
@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang-backend/config"
+)
+
+// encPrefix marks an encrypted value: "enc:v1:<key id>:<base64 nonce and
+// ciphertext>" in columns, stored as a JSON string so JSONB accepts it,
+// and the same bytes as the body of encrypted images. The additional data
+// is the key ID and where the value belongs, such as the scan ID and
+// column, so a value moved to another row or column fails to decrypt.
+const encPrefix = "enc:v1:"
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring holds the AES-256-GCM keys. The primary key encrypts; any key
+// decrypts values that name it.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring parses cfg.Keys, unwrapping them through KMS when configured.
+// It returns nil when no keys are set.
+func NewKeyring(ctx context.Context, cfg config.EncryptionConfig) (*Keyring, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, nil
+	}
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for i, entry := range cfg.Keys {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("encryption key %d: want id:base64key", i)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", id, err)
+		}
+		if cfg.KMS.Region != "" {
+			if key, err = kmsDecrypt(ctx, cfg.KMS, key); err != nil {
+				return nil, fmt.Errorf("encryption key %s: %w", id, err)
+			}
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %s: want 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if k.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			k.primary = id
+		}
+	}
+	return k, nil
+}
+
+// Seal encrypts plain for the place named by where, which Open must be
+// given again. A nil Keyring returns plain unchanged.
+func (k *Keyring) Seal(plain []byte, where string) []byte {
+	if k == nil {
+		return plain
+	}
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+	sealed := aead.Seal(nonce, nonce, plain, additionalData(k.primary, where))
+	return []byte(encPrefix + k.primary + ":" + base64.StdEncoding.EncodeToString(sealed))
+}
+
+// Open decrypts b sealed for where, returning it unchanged when it was
+// stored before encryption was turned on.
+func (k *Keyring) Open(b []byte, where string) ([]byte, error) {
+	if !bytes.HasPrefix(b, []byte(encPrefix)) {
+		return b, nil
+	}
+	if k == nil {
+		return nil, ErrUnknownKey
+	}
+	id, encoded, _ := strings.Cut(string(b[len(encPrefix):]), ":")
+	aead, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted value")
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, sealed[:n], sealed[n:], additionalData(id, where))
+}
+
+func additionalData(id, where string) []byte {
+	return []byte(id + "\x00" + where)
+}
+
+func (k *Keyring) sealJSON(v json.RawMessage, where string) json.RawMessage {
+	if len(v) == 0 {
+		return v
+	}
+	b, _ := json.Marshal(string(k.Seal(v, where)))
+	return b
+}
+
+func (k *Keyring) openJSON(v json.RawMessage, where string) (json.RawMessage, error) {
+	var s string
+	if len(v) == 0 || v[0] != '"' || json.Unmarshal(v, &s) != nil || !strings.HasPrefix(s, encPrefix) {
+		return v, nil
+	}
+	return k.Open([]byte(s), where)
+}
+
+// column names the place of a scan's column for Seal and Open.
+func column(scanID, name string) string {
+	return "scan:" + scanID + ":" + name
+}
+
+// encryptedRepository encrypts the result, raw OCR output, error, liveness
+// check, review note and corrections of scans on the way in and decrypts
+// them on the way out. Rows saved again, for example by a correction, move
+// to the current primary key. Consent holds no personal data and is kept
+// readable so the lawful basis of a scan can be shown without the keys.
+type encryptedRepository struct {
+	Repository
+	keys *Keyring
+}
+
+// WithEncryption wraps r; a nil keys returns r unchanged.
+func WithEncryption(r Repository, keys *Keyring) Repository {
+	if r == nil || keys == nil {
+		return r
+	}
+	return &encryptedRepository{Repository: r, keys: keys}
+}
+
+func (r *encryptedRepository) Save(ctx context.Context, s *Scan) error {
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	enc := *s
+	enc.Result = r.keys.sealJSON(s.Result, column(s.ID, "result"))
+	enc.RawOCR = r.keys.sealJSON(s.RawOCR, column(s.ID, "raw_ocr"))
+	enc.Liveness = r.keys.sealJSON(s.Liveness, column(s.ID, "liveness"))
+	if s.Error != "" {
+		enc.Error = string(r.keys.Seal([]byte(s.Error), column(s.ID, "error")))
+	}
+	if s.Review != nil && s.Review.Note != "" {
+		review := *s.Review
+		review.Note = string(r.keys.Seal([]byte(review.Note), column(s.ID, "review.note")))
+		enc.Review = &review
+	}
+	if len(s.Corrections) > 0 {
+		enc.Corrections = make([]Correction, len(s.Corrections))
+		for i, c := range s.Corrections {
+			where := column(s.ID, fmt.Sprintf("corrections.%d.", i))
+			c.Original = string(r.keys.Seal([]byte(c.Original), where+"original"))
+			c.Value = string(r.keys.Seal([]byte(c.Value), where+"value"))
+			if c.Note != "" {
+				c.Note = string(r.keys.Seal([]byte(c.Note), where+"note"))
+			}
+			enc.Corrections[i] = c
+		}
+	}
+	if err := r.Repository.Save(ctx, &enc); err != nil {
+		return err
+	}
+	s.ID, s.CreatedAt, s.UpdatedAt = enc.ID, enc.CreatedAt, enc.UpdatedAt
+	return nil
+}
+
+func (r *encryptedRepository) Get(ctx context.Context, id string) (*Scan, error) {
+	s, err := r.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s, r.decrypt(s)
+}
+
+func (r *encryptedRepository) List(ctx context.Context, f Filter) ([]*Scan, error) {
+	scans, err := r.Repository.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scans {
+		if err = r.decrypt(s); err != nil {
+			return nil, err
+		}
+	}
+	return scans, nil
+}
+
+func (r *encryptedRepository) Walk(ctx context.Context, f Filter, fn func(*Scan) error) error {
+	return r.Repository.Walk(ctx, f, func(s *Scan) error {
+		if err := r.decrypt(s); err != nil {
+			return err
+		}
+		return fn(s)
+	})
+}
+
+func (r *encryptedRepository) decrypt(s *Scan) error {
+	if err := r.open(s); err != nil {
+		return fmt.Errorf("decrypt scan %s: %w", s.ID, err)
+	}
+	return nil
+}
+
+func (r *encryptedRepository) open(s *Scan) error {
+	var err error
+	if s.Result, err = r.keys.openJSON(s.Result, column(s.ID, "result")); err != nil {
+		return err
+	}
+	if s.RawOCR, err = r.keys.openJSON(s.RawOCR, column(s.ID, "raw_ocr")); err != nil {
+		return err
+	}
+	if s.Liveness, err = r.keys.openJSON(s.Liveness, column(s.ID, "liveness")); err != nil {
+		return err
+	}
+	if s.Error, err = r.openString(s.Error, column(s.ID, "error")); err != nil {
+		return err
+	}
+	if s.Review != nil {
+		if s.Review.Note, err = r.openString(s.Review.Note, column(s.ID, "review.note")); err != nil {
+			return err
+		}
+	}
+	for i := range s.Corrections {
+		c := &s.Corrections[i]
+		where := column(s.ID, fmt.Sprintf("corrections.%d.", i))
+		if c.Original, err = r.openString(c.Original, where+"original"); err != nil {
+			return err
+		}
+		if c.Value, err = r.openString(c.Value, where+"value"); err != nil {
+			return err
+		}
+		if c.Note, err = r.openString(c.Note, where+"note"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *encryptedRepository) openString(v, where string) (string, error) {
+	b, err := r.keys.Open([]byte(v), where)
+	return string(b), err
+}
+
+// encryptedImages encrypts image bodies before they reach the object store.
+type encryptedImages struct {
+	ImageStore
+	keys *Keyring
+}
+
+// EncryptImages wraps images; a nil keys returns images unchanged.
+func EncryptImages(images ImageStore, keys *Keyring) ImageStore {
+	if images == nil || keys == nil {
+		return images
+	}
+	return &encryptedImages{ImageStore: images, keys: keys}
+}
+
+func (e *encryptedImages) Put(ctx context.Context, key, _ string, body []byte) error {
+	return e.ImageStore.Put(ctx, key, "application/octet-stream", e.keys.Seal(body, "image:"+key))
+}
+
+func (e *encryptedImages) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := e.ImageStore.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return e.keys.Open(b, "image:"+key)
+}
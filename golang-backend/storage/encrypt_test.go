@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"golang-backend/config"
+)
+
+func testKeyring(t *testing.T, ids ...string) *Keyring {
+	t.Helper()
+	var keys []string
+	for _, id := range ids {
+		keys = append(keys, id+":"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte(id[:1]), 32)))
+	}
+	k, err := NewKeyring(context.Background(), config.EncryptionConfig{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestKeyring(t *testing.T) {
+	old, current := testKeyring(t, "a1"), testKeyring(t, "b2", "a1")
+	sealed := old.Seal([]byte("1101700230708"), "scan:1:result")
+	flipped := bytes.Clone(sealed)
+	flipped[len(flipped)-10] ^= 1
+
+	tests := []struct {
+		name  string
+		keys  *Keyring
+		value []byte
+		where string
+		want  string
+		err   bool
+	}{
+		{"round trip", old, sealed, "scan:1:result", "1101700230708", false},
+		{"rotated", current, sealed, "scan:1:result", "1101700230708", false},
+		{"other scan", old, sealed, "scan:2:result", "", true},
+		{"other column", old, sealed, "scan:1:raw_ocr", "", true},
+		{"tampered", old, flipped, "scan:1:result", "", true},
+		{"unknown key", testKeyring(t, "c3"), sealed, "scan:1:result", "", true},
+		{"no keyring", nil, sealed, "scan:1:result", "", true},
+		{"stored in clear", old, []byte("plain"), "scan:1:result", "plain", false},
+	}
+	for _, tt := range tests {
+		got, err := tt.keys.Open(tt.value, tt.where)
+		if (err != nil) != tt.err || string(got) != tt.want {
+			t.Errorf("%s: Open = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+	if _, err := testKeyring(t, "c3").Open(sealed, "scan:1:result"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key: error %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestEncryptedRepository(t *testing.T) {
+	ctx := context.Background()
+	mem := newMemRepository()
+	repo := WithEncryption(mem, testKeyring(t, "a1"))
+	newScan := func() *Scan {
+		return &Scan{
+			Result:      json.RawMessage(`{"id_number":"1101700230708"}`),
+			RawOCR:      json.RawMessage(`{"name_th":"นาย สมชาย ใจดี"}`),
+			Error:       "ocr failed on 1101700230708",
+			Liveness:    json.RawMessage(`{"live":true,"score":0.93}`),
+			Review:      &Review{Decision: "approved", Note: "matches 1101700230708"},
+			Corrections: []Correction{{Field: "id_number", Original: "1101700230700", Value: "1101700230708", Note: "typo"}},
+			Consent:     &Consent{Purpose: "kyc", Version: "1"},
+		}
+	}
+
+	s := newScan()
+	if err := repo.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := json.Marshal(mem.scans[s.ID])
+	for _, plain := range []string{"1101700230708", "1101700230700", "สมชาย", "0.93", "typo"} {
+		if bytes.Contains(stored, []byte(plain)) {
+			t.Errorf("stored scan holds %q in clear: %s", plain, stored)
+		}
+	}
+	if mem.scans[s.ID].Consent.Purpose != "kyc" {
+		t.Errorf("consent stored as %+v, want it readable", mem.scans[s.ID].Consent)
+	}
+
+	got, err := repo.Get(ctx, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(s)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("Get after Save\n got %s\nwant %s", gotJSON, wantJSON)
+	}
+
+	other := newScan()
+	if err := repo.Save(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	swaps := []struct {
+		name string
+		swap func(dst, src *Scan)
+	}{
+		{"result from another scan", func(dst, src *Scan) { dst.Result = src.Result }},
+		{"raw OCR into result", func(dst, _ *Scan) { dst.Result = dst.RawOCR }},
+		{"error from another scan", func(dst, src *Scan) { dst.Error = src.Error }},
+		{"correction value into original", func(dst, _ *Scan) { dst.Corrections[0].Original = dst.Corrections[0].Value }},
+		{"review note from another scan", func(dst, src *Scan) { dst.Review.Note = src.Review.Note }},
+	}
+	saved := mem.scans[s.ID]
+	for _, tt := range swaps {
+		tampered := clone(saved)
+		tt.swap(tampered, mem.scans[other.ID])
+		mem.scans[s.ID] = tampered
+		if _, err := repo.Get(ctx, s.ID); err == nil {
+			t.Errorf("%s: Get succeeded, want a decryption error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/service"
+)
+
+// imageRepository uploads a scan's captured images before saving the
+// record, so a saved scan always references objects that exist.
+type imageRepository struct {
+	Repository
+	images ImageStore
+	cfg    config.ImageStoreConfig
+}
+
+// WithImages wraps r so saved scans keep their images in images; a nil
+// images returns r unchanged.
+func WithImages(r Repository, images ImageStore, cfg config.ImageStoreConfig) Repository {
+	if r == nil || images == nil {
+		return r
+	}
+	return &imageRepository{Repository: r, images: images, cfg: cfg}
+}
+
+func (r *imageRepository) Save(ctx context.Context, s *Scan) error {
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now().UTC()
+	}
+	names := make([]string, 0, len(s.uploads))
+	for name := range s.uploads {
+		if r.cfg.Cropped || !strings.HasSuffix(name, "_cropped") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		body := s.uploads[name]
+		contentType := service.DetectContentType(body)
+		key := fmt.Sprintf("%s%s/%s/%s%s", r.cfg.Prefix, s.CreatedAt.Format("2006/01/02"), s.ID, name, extension(contentType))
+		if err := r.images.Put(ctx, key, contentType, body); err != nil {
+			return fmt.Errorf("store %s image: %w", name, err)
+		}
+		if s.Images == nil {
+			s.Images = make(map[string]string)
+		}
+		s.Images[name] = key
+	}
+	s.uploads = nil
+	return r.Repository.Save(ctx, s)
+}
+
+// Delete removes the scan's images before its record, so a failure leaves
+// the record in place to be retried.
+func (r *imageRepository) Delete(ctx context.Context, id string) error {
+	s, err := r.Repository.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	for name, key := range s.Images {
+		if err = r.images.Delete(ctx, key); err != nil {
+			return fmt.Errorf("delete %s image: %w", name, err)
+		}
+	}
+	return r.Repository.Delete(ctx, id)
+}
+
+func extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "image/heic":
+		return ".heic"
+	case "application/pdf":
+		return ".pdf"
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/sigv4"
+)
+
+// kmsDecrypt unwraps a data key with AWS KMS Decrypt.
+func kmsDecrypt(ctx context.Context, cfg config.KMSConfig, blob []byte) ([]byte, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://kms." + cfg.Region + ".amazonaws.com/"
+	}
+	body, err := json.Marshal(map[string][]byte{"CiphertextBlob": blob})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	sigv4.Sign(req, body, sigv4.Credentials{
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    cfg.SessionToken,
+	}, cfg.Region, "kms", time.Now())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kms returned %d: %s", resp.StatusCode, b)
+	}
+	var out struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	if err = json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("decode kms response: %w", err)
+	}
+	return out.Plaintext, nil
+}
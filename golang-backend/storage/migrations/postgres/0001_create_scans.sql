@@ -0,0 +1,19 @@
+CREATE TABLE scans (
+    id           TEXT PRIMARY KEY,
+    request_id   TEXT NOT NULL DEFAULT '',
+    key_id       TEXT NOT NULL DEFAULT '',
+    client_ip    TEXT NOT NULL DEFAULT '',
+    route        TEXT NOT NULL,
+    document     TEXT NOT NULL,
+    status       TEXT NOT NULL,
+    error        TEXT NOT NULL DEFAULT '',
+    image_sha256 TEXT NOT NULL DEFAULT '',
+    result       JSONB,
+    confidence   JSONB,
+    raw_ocr      JSONB,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX scans_created_at_idx ON scans (created_at);
+CREATE INDEX scans_image_sha256_idx ON scans (image_sha256);
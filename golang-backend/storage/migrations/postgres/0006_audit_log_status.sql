@@ -0,0 +1,7 @@
+ALTER TABLE audit_log ADD COLUMN status INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX audit_log_actor_time_idx ON audit_log (actor, time);
+
+-- The audit trail is append-only.
+CREATE RULE audit_log_no_update AS ON UPDATE TO audit_log DO INSTEAD NOTHING;
+CREATE RULE audit_log_no_delete AS ON DELETE TO audit_log DO INSTEAD NOTHING;
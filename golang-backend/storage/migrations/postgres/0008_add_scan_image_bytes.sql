@@ -0,0 +1 @@
+ALTER TABLE scans ADD COLUMN image_bytes BIGINT NOT NULL DEFAULT 0;
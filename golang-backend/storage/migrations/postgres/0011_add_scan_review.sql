@@ -0,0 +1 @@
+ALTER TABLE scans ADD COLUMN review JSONB;
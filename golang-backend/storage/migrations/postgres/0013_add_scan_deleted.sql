@@ -0,0 +1,2 @@
+ALTER TABLE scans ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE scans ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
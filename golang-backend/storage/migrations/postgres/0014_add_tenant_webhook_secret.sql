@@ -0,0 +1 @@
+ALTER TABLE tenants ADD COLUMN webhook_secret TEXT NOT NULL DEFAULT '';
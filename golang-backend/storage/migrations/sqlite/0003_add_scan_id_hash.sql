@@ -0,0 +1,4 @@
+ALTER TABLE scans ADD COLUMN id_hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX scans_id_hash_idx ON scans (id_hash);
+CREATE INDEX scans_status_created_at_idx ON scans (status, created_at);
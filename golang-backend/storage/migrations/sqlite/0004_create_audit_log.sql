@@ -0,0 +1,14 @@
+CREATE TABLE audit_log (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    time       TIMESTAMP NOT NULL,
+    actor      TEXT NOT NULL DEFAULT '',
+    action     TEXT NOT NULL,
+    scan_id    TEXT NOT NULL DEFAULT '',
+    route      TEXT NOT NULL DEFAULT '',
+    outcome    TEXT NOT NULL,
+    client_ip  TEXT NOT NULL DEFAULT '',
+    detail     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_log_time_idx ON audit_log (time);
+CREATE INDEX audit_log_scan_id_idx ON audit_log (scan_id);
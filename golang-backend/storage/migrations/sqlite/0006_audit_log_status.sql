@@ -0,0 +1,14 @@
+ALTER TABLE audit_log ADD COLUMN status INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX audit_log_actor_time_idx ON audit_log (actor, time);
+
+-- The audit trail is append-only.
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
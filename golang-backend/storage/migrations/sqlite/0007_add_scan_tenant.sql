@@ -0,0 +1,3 @@
+ALTER TABLE scans ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX scans_tenant_created_at_idx ON scans (tenant, created_at);
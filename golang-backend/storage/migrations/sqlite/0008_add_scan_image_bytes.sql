@@ -0,0 +1 @@
+ALTER TABLE scans ADD COLUMN image_bytes INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+CREATE TABLE tenants (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL DEFAULT '',
+    daily_quota   INTEGER NOT NULL DEFAULT 0,
+    monthly_quota INTEGER NOT NULL DEFAULT 0,
+    disabled      BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMP NOT NULL,
+    updated_at    TIMESTAMP NOT NULL
+);
+
+CREATE TABLE api_keys (
+    id                  TEXT PRIMARY KEY,
+    tenant              TEXT NOT NULL DEFAULT '',
+    name                TEXT NOT NULL DEFAULT '',
+    roles               TEXT NOT NULL DEFAULT '',
+    hash                TEXT NOT NULL UNIQUE,
+    previous_hash       TEXT NOT NULL DEFAULT '',
+    previous_expires_at TIMESTAMP,
+    created_at          TIMESTAMP NOT NULL,
+    rotated_at          TIMESTAMP,
+    revoked_at          TIMESTAMP
+);
+
+CREATE INDEX api_keys_previous_hash_idx ON api_keys (previous_hash);
+CREATE INDEX api_keys_tenant_idx ON api_keys (tenant);
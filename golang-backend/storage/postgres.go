@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"golang-backend/config"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+func openPostgres(ctx context.Context, cfg config.StorageConfig) (Repository, error) {
+	db, err := sql.Open("pgx", cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxOpenConns)
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err = migrate(ctx, db, postgresMigrations, "migrations/postgres"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlRepository{db: db}, nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const purgeBatch = 100
+
+// RunRetention deletes scans older than retention every interval until ctx
+// is done, calling forget first to drop the copies of each kept elsewhere.
+func RunRetention(ctx context.Context, r Repository, retention, interval time.Duration, forget func(context.Context, *Scan) error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		n, err := Purge(ctx, r, time.Now().Add(-retention), forget)
+		if err != nil {
+			slog.Error("purge scans failed", "deleted", n, "error", err)
+		} else if n > 0 {
+			slog.Info("purged expired scans", "deleted", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Purge deletes every scan created before cutoff, together with its images
+// and, through a non-nil forget, its cached copies, and records each
+// deletion in the audit log. A scan that cannot be deleted
+// is logged, audited and skipped until the next run, so it does not hold up
+// the others; the errors are returned together once every scan was tried.
+func Purge(ctx context.Context, r Repository, cutoff time.Time, forget func(context.Context, *Scan) error) (int, error) {
+	var (
+		deleted int
+		errs    []error
+	)
+	for {
+		// Scans that failed stay at the front of the list, so skip them.
+		scans, err := r.List(ctx, Filter{To: cutoff, Limit: purgeBatch, Offset: len(errs), WithDeleted: true})
+		if err != nil {
+			return deleted, errors.Join(append(errs, err)...)
+		}
+		if len(scans) == 0 {
+			return deleted, errors.Join(errs...)
+		}
+		for _, s := range scans {
+			var err error
+			if forget != nil {
+				err = forget(ctx, s)
+			}
+			if err == nil {
+				err = r.Delete(ctx, s.ID)
+			}
+			if err != nil {
+				slog.Error("purge scan failed", "scan_id", s.ID, "error", err)
+				audit(ctx, r, &AuditEvent{Action: AuditDelete, ScanID: s.ID, Outcome: AuditFailed, Actor: retentionActor, Detail: err.Error()})
+				errs = append(errs, fmt.Errorf("scan %s: %w", s.ID, err))
+				continue
+			}
+			deleted++
+			audit(ctx, r, &AuditEvent{Action: AuditDelete, ScanID: s.ID, Outcome: AuditSucceeded, Actor: retentionActor, Detail: "retention expired"})
+		}
+	}
+}
+
+// audit appends e, logging rather than returning a failure so deletion
+// goes on without its audit record.
+func audit(ctx context.Context, r Repository, e *AuditEvent) {
+	if err := r.AppendAudit(ctx, e); err != nil {
+		slog.Error("append retention audit event failed", "scan_id", e.ScanID, "outcome", e.Outcome, "error", err)
+	}
+}
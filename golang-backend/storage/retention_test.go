@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memRepository keeps copies of scans as saved, so tests can look at and
+// tamper with what reached the database.
+type memRepository struct {
+	Repository
+	scans      map[string]*Scan
+	audit      []*AuditEvent
+	failDelete map[string]bool
+	failAudit  bool
+}
+
+func newMemRepository() *memRepository {
+	return &memRepository{scans: map[string]*Scan{}, failDelete: map[string]bool{}}
+}
+
+func clone(s *Scan) *Scan {
+	b, _ := json.Marshal(s)
+	var c Scan
+	_ = json.Unmarshal(b, &c)
+	return &c
+}
+
+func (m *memRepository) Save(_ context.Context, s *Scan) error {
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	m.scans[s.ID] = clone(s)
+	return nil
+}
+
+func (m *memRepository) Get(_ context.Context, id string) (*Scan, error) {
+	s, ok := m.scans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(s), nil
+}
+
+func (m *memRepository) List(_ context.Context, f Filter) ([]*Scan, error) {
+	var scans []*Scan
+	for _, s := range m.scans {
+		if (f.To.IsZero() || s.CreatedAt.Before(f.To)) && (f.Tenant == "" || s.Tenant == f.Tenant) && (f.IDHash == "" || s.IDHash == f.IDHash) {
+			scans = append(scans, clone(s))
+		}
+	}
+	slices.SortFunc(scans, func(a, b *Scan) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.ID, a.ID)
+	})
+	scans = scans[min(f.Offset, len(scans)):]
+	return scans[:min(f.Limit, len(scans))], nil
+}
+
+func (m *memRepository) Delete(_ context.Context, id string) error {
+	if m.failDelete[id] {
+		return errors.New("image store unavailable")
+	}
+	delete(m.scans, id)
+	return nil
+}
+
+func (m *memRepository) AppendAudit(_ context.Context, e *AuditEvent) error {
+	if m.failAudit {
+		return errors.New("audit log unavailable")
+	}
+	m.audit = append(m.audit, e)
+	return nil
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Now()
+	cutoff := now.Add(-24 * time.Hour)
+	firstBatch := make([]int, purgeBatch)
+	for i := range firstBatch {
+		firstBatch[i] = i
+	}
+	tests := []struct {
+		name       string
+		expired    int
+		failDelete []int // indexes into the expired scans, newest first
+		failAudit  bool
+		deleted    int
+	}{
+		{"nothing expired", 0, nil, false, 0},
+		{"more than a batch", purgeBatch + 5, nil, false, purgeBatch + 5},
+		{"oldest scan fails", 5, []int{4}, false, 4},
+		{"newest scan fails", 5, []int{0}, false, 4},
+		{"a whole batch fails", purgeBatch + 3, firstBatch, false, 3},
+		{"audit log down", 5, nil, true, 5},
+	}
+	for _, tt := range tests {
+		m := newMemRepository()
+		m.failAudit = tt.failAudit
+		_ = m.Save(context.Background(), &Scan{CreatedAt: now})
+		var expired []string
+		for i := 0; i < tt.expired; i++ {
+			s := &Scan{CreatedAt: cutoff.Add(-time.Duration(i+1) * time.Minute)}
+			_ = m.Save(context.Background(), s)
+			expired = append(expired, s.ID)
+		}
+		for _, i := range tt.failDelete {
+			m.failDelete[expired[i]] = true
+		}
+
+		n, err := Purge(context.Background(), m, cutoff, nil)
+		if n != tt.deleted {
+			t.Errorf("%s: deleted %d, want %d", tt.name, n, tt.deleted)
+		}
+		errs := 0
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = len(joined.Unwrap())
+		}
+		if errs != len(tt.failDelete) || (err != nil) != (len(tt.failDelete) > 0) {
+			t.Errorf("%s: error %v, want %d failures", tt.name, err, len(tt.failDelete))
+		}
+		if left := len(m.scans); left != 1+len(tt.failDelete) {
+			t.Errorf("%s: %d scans left, want %d", tt.name, left, 1+len(tt.failDelete))
+		}
+		if !tt.failAudit && len(m.audit) != tt.expired {
+			t.Errorf("%s: %d audit events, want %d", tt.name, len(m.audit), tt.expired)
+		}
+	}
+}
+
+func TestPurgeForgetsCopies(t *testing.T) {
+	cutoff := time.Now()
+	m := newMemRepository()
+	var ids []string
+	for i := 0; i < 3; i++ {
+		s := &Scan{CreatedAt: cutoff.Add(-time.Duration(i+1) * time.Hour)}
+		_ = m.Save(context.Background(), s)
+		ids = append(ids, s.ID)
+	}
+	forgot := map[string]bool{}
+	forget := func(_ context.Context, s *Scan) error {
+		if s.ID == ids[1] {
+			return errors.New("cache unavailable")
+		}
+		forgot[s.ID] = true
+		return nil
+	}
+
+	n, err := Purge(context.Background(), m, cutoff, forget)
+	if n != 2 || err == nil {
+		t.Errorf("Purge = %d, %v; want 2 and the cache error", n, err)
+	}
+	if _, ok := m.scans[ids[1]]; !ok {
+		t.Error("scan whose copies could not be forgotten was deleted")
+	}
+	if !forgot[ids[0]] || !forgot[ids[2]] {
+		t.Errorf("forgot %v, want %s and %s", forgot, ids[0], ids[2])
+	}
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/sigv4"
+)
+
+// ImageStore keeps the source images of scans.
+type ImageStore interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// s3Store talks to S3 or an S3-compatible server such as MinIO.
+type s3Store struct {
+	cfg    config.ImageStoreConfig
+	base   *url.URL
+	client *http.Client
+}
+
+// OpenImages returns the configured image store, or nil when no bucket is
+// set. With ExpireDays set the bucket lifecycle rule is installed first.
+func OpenImages(ctx context.Context, cfg config.ImageStoreConfig) (ImageStore, error) {
+	if cfg.Bucket == "" {
+		return nil, nil
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %w", err)
+	}
+	if cfg.PathStyle {
+		base.Path = "/" + cfg.Bucket
+	} else {
+		base.Host = cfg.Bucket + "." + base.Host
+	}
+	s := &s3Store{cfg: cfg, base: base, client: &http.Client{Timeout: cfg.Timeout}}
+	if cfg.ExpireDays > 0 {
+		if err = s.putLifecycle(ctx); err != nil {
+			return nil, fmt.Errorf("s3 lifecycle: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key, contentType string, body []byte) error {
+	header := http.Header{"Content-Type": {contentType}}
+	switch s.cfg.SSE {
+	case "":
+	case "aws:kms":
+		header.Set("X-Amz-Server-Side-Encryption", s.cfg.SSE)
+		if s.cfg.KMSKeyID != "" {
+			header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", s.cfg.KMSKeyID)
+		}
+	default:
+		header.Set("X-Amz-Server-Side-Encryption", s.cfg.SSE)
+	}
+	_, err := s.do(ctx, http.MethodPut, s.objectURL(key), header, body)
+	return err
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.do(ctx, http.MethodGet, s.objectURL(key), nil, nil)
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, http.MethodDelete, s.objectURL(key), nil, nil)
+	return err
+}
+
+func (s *s3Store) objectURL(key string) *url.URL {
+	u := *s.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	return &u
+}
+
+type lifecycleRule struct {
+	ID         string `xml:"ID"`
+	Status     string `xml:"Status"`
+	Prefix     string `xml:"Filter>Prefix"`
+	ExpireDays int    `xml:"Expiration>Days"`
+}
+
+type lifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Rules   []lifecycleRule `xml:"Rule"`
+}
+
+// putLifecycle replaces the bucket lifecycle configuration with a single
+// rule expiring objects under Prefix after ExpireDays.
+func (s *s3Store) putLifecycle(ctx context.Context) error {
+	body, err := xml.Marshal(lifecycleConfiguration{Rules: []lifecycleRule{{
+		ID:         "expire-scan-images",
+		Status:     "Enabled",
+		Prefix:     s.cfg.Prefix,
+		ExpireDays: s.cfg.ExpireDays,
+	}}})
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(body)
+	u := *s.base
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = "lifecycle="
+	_, err = s.do(ctx, http.MethodPut, &u, http.Header{
+		"Content-Type": {"application/xml"},
+		"Content-Md5":  {base64.StdEncoding.EncodeToString(sum[:])},
+	}, body)
+	return err
+}
+
+func (s *s3Store) do(ctx context.Context, method string, u *url.URL, header http.Header, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("X-Amz-Content-Sha256", sigv4.PayloadHash(body))
+	sigv4.Sign(req, body, sigv4.Credentials{
+		AccessKeyID:     s.cfg.AccessKeyID,
+		SecretAccessKey: s.cfg.SecretAccessKey,
+		SessionToken:    s.cfg.SessionToken,
+	}, s.cfg.Region, "s3", time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return nil, ErrImageNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("s3 %s %s returned %d: %s", method, u.Path, resp.StatusCode, b)
+	}
+	return b, nil
+}
@@ -0,0 +1,494 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sqlRepository implements Repository over database/sql. Queries use $n
+// placeholders, which both Postgres and SQLite accept.
+type sqlRepository struct {
+	db *sql.DB
+}
+
+const scanColumns = `id, request_id, key_id, tenant, client_ip, route, document, status, error,
+	image_sha256, image_bytes, id_hash, result, confidence, raw_ocr, images, consent, liveness, review, corrections, deleted_at, deleted_by, created_at, updated_at`
+
+func (r *sqlRepository) Save(ctx context.Context, s *Scan) error {
+	var images, consent, review, corrections []byte
+	if len(s.Images) > 0 {
+		images, _ = json.Marshal(s.Images)
+	}
+	if s.Consent != nil {
+		consent, _ = json.Marshal(s.Consent)
+	}
+	if s.Review != nil {
+		review, _ = json.Marshal(s.Review)
+	}
+	if len(s.Corrections) > 0 {
+		corrections, _ = json.Marshal(s.Corrections)
+	}
+	now := time.Now().UTC()
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = now
+	}
+	s.UpdatedAt = now
+	_, err := r.db.ExecContext(ctx, `INSERT INTO scans (`+scanColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, error = excluded.error,
+			image_sha256 = excluded.image_sha256, image_bytes = excluded.image_bytes, id_hash = excluded.id_hash, result = excluded.result,
+			confidence = excluded.confidence, raw_ocr = excluded.raw_ocr, images = excluded.images, consent = excluded.consent,
+			liveness = excluded.liveness, review = excluded.review, corrections = excluded.corrections, deleted_at = excluded.deleted_at, deleted_by = excluded.deleted_by, updated_at = excluded.updated_at`,
+		s.ID, s.RequestID, s.KeyID, s.Tenant, s.ClientIP, s.Route, s.Document, s.Status, s.Error,
+		s.ImageSHA256, s.ImageBytes, s.IDHash, nullJSON(s.Result), nullJSON(s.Confidence), nullJSON(s.RawOCR), nullJSON(images), nullJSON(consent), nullJSON(s.Liveness), nullJSON(review), nullJSON(corrections), s.DeletedAt, s.DeletedBy, s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+func (r *sqlRepository) Get(ctx context.Context, id string) (*Scan, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+scanColumns+` FROM scans WHERE id = $1`, id)
+	s, err := scanRow(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return s, err
+}
+
+func (r *sqlRepository) List(ctx context.Context, f Filter) ([]*Scan, error) {
+	w, order := scanWhere(f)
+	query, args := w.query(`SELECT `+scanColumns+` FROM scans`, order, f.Limit, f.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var scans []*Scan
+	for rows.Next() {
+		s, err := scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		scans = append(scans, s)
+	}
+	return scans, rows.Err()
+}
+
+// Walk reads the rows one at a time, so a large export never holds more
+// than one scan in memory.
+func (r *sqlRepository) Walk(ctx context.Context, f Filter, fn func(*Scan) error) error {
+	w, order := scanWhere(f)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+scanColumns+` FROM scans`+w.clause()+` ORDER BY `+order, w.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s, err := scanRow(rows)
+		if err != nil {
+			return err
+		}
+		if err = fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanWhere(f Filter) (w where, order string) {
+	if !f.From.IsZero() {
+		w.add("created_at >= $%d", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		w.add("created_at < $%d", f.To.UTC())
+	}
+	if f.KeyID != "" {
+		w.add("key_id = $%d", f.KeyID)
+	}
+	if f.Tenant != "" {
+		w.add("tenant = $%d", f.Tenant)
+	}
+	if f.IDHash != "" {
+		w.add("id_hash = $%d", f.IDHash)
+	}
+	if len(f.Status) > 0 {
+		w.in("status", f.Status)
+	}
+	if !f.WithDeleted {
+		w.conds = append(w.conds, "deleted_at IS NULL")
+	}
+	order = "created_at DESC, id DESC"
+	if f.OldestFirst {
+		order = "created_at, id"
+	}
+	return w, order
+}
+
+func (r *sqlRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM scans WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlRepository) AppendAudit(ctx context.Context, e *AuditEvent) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	return r.db.QueryRowContext(ctx, `INSERT INTO audit_log (`+auditColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		e.Time, e.Actor, e.Action, e.ScanID, e.Route, e.Outcome, e.Status, e.ClientIP, e.Detail).Scan(&e.ID)
+}
+
+const auditColumns = `time, actor, action, scan_id, route, outcome, status, client_ip, detail`
+
+func (r *sqlRepository) ListAudit(ctx context.Context, f AuditFilter) ([]*AuditEvent, error) {
+	var w where
+	if !f.From.IsZero() {
+		w.add("time >= $%d", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		w.add("time < $%d", f.To.UTC())
+	}
+	if f.Actor != "" {
+		w.add("actor = $%d", f.Actor)
+	}
+	if f.Action != "" {
+		w.add("action = $%d", f.Action)
+	}
+	if f.ScanID != "" {
+		w.add("scan_id = $%d", f.ScanID)
+	}
+	query, args := w.query(`SELECT id, `+auditColumns+` FROM audit_log`, "time DESC, id DESC", f.Limit, f.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*AuditEvent
+	for rows.Next() {
+		var e AuditEvent
+		if err = rows.Scan(&e.ID, &e.Time, &e.Actor, &e.Action, &e.ScanID, &e.Route, &e.Outcome, &e.Status, &e.ClientIP, &e.Detail); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+func (r *sqlRepository) Usage(ctx context.Context, f UsageFilter) ([]Usage, error) {
+	var w where
+	if !f.From.IsZero() {
+		w.add("created_at >= $%d", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		w.add("created_at < $%d", f.To.UTC())
+	}
+	if f.Tenant != "" {
+		w.add("tenant = $%d", f.Tenant)
+	}
+	if f.KeyID != "" {
+		w.add("key_id = $%d", f.KeyID)
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT tenant, key_id, COUNT(*),
+		COALESCE(SUM(CASE WHEN status <> 'failed' THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN status = 'needs_review' THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(image_bytes), 0)
+		FROM scans`+w.clause()+` GROUP BY tenant, key_id ORDER BY tenant, key_id`, w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var usage []Usage
+	for rows.Next() {
+		var u Usage
+		if err = rows.Scan(&u.Tenant, &u.KeyID, &u.Scans, &u.Succeeded, &u.NeedsReview, &u.ImageBytes); err != nil {
+			return nil, err
+		}
+		u.Failed = u.Scans - u.Succeeded
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+func (r *sqlRepository) SaveTenant(ctx context.Context, t *Tenant) error {
+	now := time.Now().UTC()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	t.UpdatedAt = now
+	_, err := r.db.ExecContext(ctx, `INSERT INTO tenants (`+tenantColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, daily_quota = excluded.daily_quota,
+			monthly_quota = excluded.monthly_quota, disabled = excluded.disabled, webhook_secret = excluded.webhook_secret,
+			updated_at = excluded.updated_at`,
+		t.ID, t.Name, t.DailyQuota, t.MonthlyQuota, t.Disabled, t.WebhookSecret, t.CreatedAt, t.UpdatedAt)
+	return err
+}
+
+const tenantColumns = `id, name, daily_quota, monthly_quota, disabled, webhook_secret, created_at, updated_at`
+
+func (r *sqlRepository) GetTenant(ctx context.Context, id string) (*Tenant, error) {
+	var t Tenant
+	err := r.db.QueryRowContext(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id).
+		Scan(&t.ID, &t.Name, &t.DailyQuota, &t.MonthlyQuota, &t.Disabled, &t.WebhookSecret, &t.CreatedAt, &t.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *sqlRepository) ListTenants(ctx context.Context) ([]*Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tenants []*Tenant
+	for rows.Next() {
+		var t Tenant
+		if err = rows.Scan(&t.ID, &t.Name, &t.DailyQuota, &t.MonthlyQuota, &t.Disabled, &t.WebhookSecret, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, &t)
+	}
+	return tenants, rows.Err()
+}
+
+const keyColumns = `id, tenant, name, roles, hash, previous_hash, previous_expires_at, created_at, rotated_at, revoked_at`
+
+func (r *sqlRepository) SaveKey(ctx context.Context, k *APIKey) error {
+	if k.ID == "" {
+		k.ID = NewID()
+	}
+	if k.CreatedAt.IsZero() {
+		k.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO api_keys (`+keyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET tenant = excluded.tenant, name = excluded.name, roles = excluded.roles,
+			hash = excluded.hash, previous_hash = excluded.previous_hash, previous_expires_at = excluded.previous_expires_at,
+			rotated_at = excluded.rotated_at, revoked_at = excluded.revoked_at`,
+		k.ID, k.Tenant, k.Name, strings.Join(k.Roles, ","), k.Hash, k.PreviousHash, k.PreviousExpiresAt, k.CreatedAt, k.RotatedAt, k.RevokedAt)
+	return err
+}
+
+func (r *sqlRepository) GetKey(ctx context.Context, id string) (*APIKey, error) {
+	return keyRow(r.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE id = $1`, id))
+}
+
+func (r *sqlRepository) FindKey(ctx context.Context, hash string) (*APIKey, error) {
+	return keyRow(r.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM api_keys
+		WHERE hash = $1 OR previous_hash = $1`, hash))
+}
+
+func (r *sqlRepository) ListKeys(ctx context.Context, tenant string) ([]*APIKey, error) {
+	var w where
+	if tenant != "" {
+		w.add("tenant = $%d", tenant)
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+keyColumns+` FROM api_keys`+w.clause()+` ORDER BY created_at, id`, w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []*APIKey
+	for rows.Next() {
+		k, err := keyRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func keyRow(row rowScanner) (*APIKey, error) {
+	var (
+		k                                 APIKey
+		roles                             string
+		previousExpires, rotated, revoked sql.NullTime
+	)
+	err := row.Scan(&k.ID, &k.Tenant, &k.Name, &roles, &k.Hash, &k.PreviousHash, &previousExpires, &k.CreatedAt, &rotated, &revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if roles != "" {
+		k.Roles = strings.Split(roles, ",")
+	}
+	k.PreviousExpiresAt, k.RotatedAt, k.RevokedAt = nullTime(previousExpires), nullTime(rotated), nullTime(revoked)
+	return &k, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// where accumulates AND-ed conditions whose %d is replaced by the
+// placeholder number of their argument.
+type where struct {
+	conds []string
+	args  []any
+}
+
+func (w *where) add(cond string, arg any) {
+	w.args = append(w.args, arg)
+	w.conds = append(w.conds, fmt.Sprintf(cond, len(w.args)))
+}
+
+func (w *where) in(column string, values []string) {
+	in := make([]string, len(values))
+	for i, v := range values {
+		w.args = append(w.args, v)
+		in[i] = fmt.Sprintf("$%d", len(w.args))
+	}
+	w.conds = append(w.conds, column+" IN ("+strings.Join(in, ", ")+")")
+}
+
+func (w *where) clause() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(w.conds, " AND ")
+}
+
+func (w *where) query(base, order string, limit, offset int) (string, []any) {
+	base += w.clause()
+	args := append(w.args, limit, offset)
+	return base + fmt.Sprintf(` ORDER BY %s LIMIT $%d OFFSET $%d`, order, len(args)-1, len(args)), args
+}
+
+func (r *sqlRepository) Close() error {
+	return r.db.Close()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRow(row rowScanner) (*Scan, error) {
+	var (
+		s                                                                          Scan
+		result, confidence, rawOCR, images, consent, liveness, review, corrections sql.NullString
+		deletedAt                                                                  sql.NullTime
+	)
+	err := row.Scan(&s.ID, &s.RequestID, &s.KeyID, &s.Tenant, &s.ClientIP, &s.Route, &s.Document, &s.Status, &s.Error,
+		&s.ImageSHA256, &s.ImageBytes, &s.IDHash, &result, &confidence, &rawOCR, &images, &consent, &liveness, &review, &corrections, &deletedAt, &s.DeletedBy, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	s.Result, s.Confidence, s.RawOCR, s.Liveness = rawJSON(result), rawJSON(confidence), rawJSON(rawOCR), rawJSON(liveness)
+	if deletedAt.Valid {
+		s.DeletedAt = &deletedAt.Time
+	}
+	if images.Valid {
+		if err = json.Unmarshal([]byte(images.String), &s.Images); err != nil {
+			return nil, err
+		}
+	}
+	if consent.Valid {
+		if err = json.Unmarshal([]byte(consent.String), &s.Consent); err != nil {
+			return nil, err
+		}
+	}
+	if review.Valid {
+		if err = json.Unmarshal([]byte(review.String), &s.Review); err != nil {
+			return nil, err
+		}
+	}
+	if corrections.Valid {
+		if err = json.Unmarshal([]byte(corrections.String), &s.Corrections); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+func nullJSON(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+func rawJSON(s sql.NullString) []byte {
+	if !s.Valid {
+		return nil
+	}
+	return []byte(s.String)
+}
+
+// migrate applies the *.sql files in dir that are not yet recorded in
+// schema_migrations, each in its own transaction, in file name order.
+func migrate(ctx context.Context, db *sql.DB, files embed.FS, dir string) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return err
+	}
+	names, err := fs.Glob(files, dir+"/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var applied int
+		if err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, name).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+		stmt, err := files.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err = inTx(ctx, db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(stmt)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`, name, time.Now().UTC())
+			return err
+		}); err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func inTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
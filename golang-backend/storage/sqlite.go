@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+
+	_ "modernc.org/sqlite"
+
+	"golang-backend/config"
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// openSQLite opens the database file named by cfg.DSN. SQLite allows one
+// writer at a time, so the pool is limited to a single connection.
+func openSQLite(ctx context.Context, cfg config.StorageConfig) (Repository, error) {
+	db, err := sql.Open("sqlite", cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	for _, pragma := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000", "PRAGMA foreign_keys = ON"} {
+		if _, err = db.ExecContext(ctx, pragma); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if err = migrate(ctx, db, sqliteMigrations, "migrations/sqlite"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlRepository{db: db}, nil
+}
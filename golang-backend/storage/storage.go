@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+	"golang-backend/service"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusNeedsReview = "needs_review"
+	StatusFailed      = "failed"
+	// StatusRejected is a needs_review scan a reviewer turned down.
+	StatusRejected = "rejected"
+)
+
+var (
+	ErrNotFound      = errors.New("scan not found")
+	ErrImageNotFound = errors.New("image not found")
+)
+
+// Scan is one persisted scan request with its parsed result and the raw OCR
+// labels it was built from.
+type Scan struct {
+	ID          string `json:"id"`
+	RequestID   string `json:"request_id,omitempty"`
+	KeyID       string `json:"key_id,omitempty"`
+	Tenant      string `json:"tenant,omitempty"`
+	ClientIP    string `json:"client_ip,omitempty"`
+	Route       string `json:"route"`
+	Document    string `json:"document"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	ImageSHA256 string `json:"image_sha256,omitempty"`
+	ImageBytes  int64  `json:"image_bytes,omitempty"`
+	// IDHash is the keyed hash of the citizen ID read from the card, so
+	// scans can be looked up by ID without storing it in a searchable form.
+	IDHash     string          `json:"id_hash,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Confidence json.RawMessage `json:"confidence,omitempty"`
+	RawOCR     json.RawMessage `json:"raw_ocr,omitempty"`
+	// Images maps each stored image, such as "front" or "front_cropped",
+	// to its object key.
+	Images    map[string]string `json:"images,omitempty"`
+	Consent   *Consent          `json:"consent,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	// Liveness is the liveness check of the last selfie compared with the
+	// scan's card by POST /verify/face.
+	Liveness json.RawMessage `json:"liveness,omitempty"`
+	Review   *Review         `json:"review,omitempty"`
+	// Corrections lists every change made to a result field, oldest first.
+	Corrections []Correction `json:"corrections,omitempty"`
+	// DeletedAt is set once the scan is soft-deleted; it is then left out
+	// of lists and searches until purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty"`
+
+	uploads map[string][]byte
+}
+
+type Repository interface {
+	Save(ctx context.Context, s *Scan) error
+	Get(ctx context.Context, id string) (*Scan, error)
+	List(ctx context.Context, f Filter) ([]*Scan, error)
+	// Walk calls fn with each scan matching f, ignoring its Limit and
+	// Offset, until fn returns an error.
+	Walk(ctx context.Context, f Filter, fn func(*Scan) error) error
+	Delete(ctx context.Context, id string) error
+	AppendAudit(ctx context.Context, e *AuditEvent) error
+	ListAudit(ctx context.Context, f AuditFilter) ([]*AuditEvent, error)
+	Usage(ctx context.Context, f UsageFilter) ([]Usage, error)
+	SaveTenant(ctx context.Context, t *Tenant) error
+	GetTenant(ctx context.Context, id string) (*Tenant, error)
+	ListTenants(ctx context.Context) ([]*Tenant, error)
+	SaveKey(ctx context.Context, k *APIKey) error
+	GetKey(ctx context.Context, id string) (*APIKey, error)
+	// FindKey returns the key whose current or previous secret has hash.
+	FindKey(ctx context.Context, hash string) (*APIKey, error)
+	ListKeys(ctx context.Context, tenant string) ([]*APIKey, error)
+	Close() error
+}
+
+// Consent is the PDPA consent the data subject gave for a scan.
+type Consent struct {
+	Purpose   string    `json:"purpose"`
+	Version   string    `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Channel   string    `json:"channel"`
+}
+
+// Review is a reviewer's decision on a needs_review scan.
+type Review struct {
+	Decision   string    `json:"decision"`
+	Reviewer   string    `json:"reviewer,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// Correction is one edit of a parsed result field. Original is the value
+// OCR read, kept across later corrections of the same field.
+type Correction struct {
+	Field    string    `json:"field"`
+	Original string    `json:"original"`
+	Value    string    `json:"value"`
+	Editor   string    `json:"editor,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// Filter selects scans for List, newest first unless OldestFirst. Zero
+// fields match all scans; Status matches any of the listed statuses.
+type Filter struct {
+	From   time.Time
+	To     time.Time
+	Status []string
+	KeyID  string
+	Tenant string
+	IDHash string
+	Limit  int
+	Offset int
+
+	OldestFirst bool
+	// WithDeleted includes soft-deleted scans.
+	WithDeleted bool
+}
+
+// minIDHashKeyLen is the shortest id_hash_key accepted. Citizen IDs are
+// only 13 digits, so a guessable key would let anyone holding the hashes
+// recover them.
+const minIDHashKeyLen = 32
+
+// Open connects to the configured database and applies pending migrations.
+// It returns a nil Repository when storage is disabled.
+func Open(ctx context.Context, cfg config.StorageConfig) (Repository, error) {
+	idHashKey = []byte(cfg.IDHashKey)
+	if cfg.Driver == "" {
+		return nil, nil
+	}
+	if len(idHashKey) < minIDHashKeyLen {
+		return nil, fmt.Errorf("id_hash_key must be at least %d bytes", minIDHashKeyLen)
+	}
+	switch cfg.Driver {
+	case "postgres":
+		return openPostgres(ctx, cfg)
+	case "sqlite":
+		return openSQLite(ctx, cfg)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+var idHashKey []byte
+
+// HashCitizenID returns the hex HMAC-SHA256 of the citizen ID under the
+// configured key, ignoring spaces and dashes.
+func HashCitizenID(id string) string {
+	id = strings.NewReplacer(" ", "", "-", "").Replace(id)
+	m := hmac.New(sha256.New, idHashKey)
+	m.Write([]byte(id))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func NewID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SetResult stores the JSON form of result, copying its status and
+// confidence fields when it has them. A non-nil err marks the scan failed.
+func (s *Scan) SetResult(result any, err error) {
+	if err != nil {
+		s.Status, s.Error = StatusFailed, logging.Redact(err.Error())
+		return
+	}
+	s.Status = StatusOK
+	b, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	s.Result = b
+	var fields struct {
+		Status     string          `json:"status"`
+		Confidence json.RawMessage `json:"confidence"`
+		IDNumber   string          `json:"id_number"`
+	}
+	if json.Unmarshal(b, &fields) == nil {
+		if fields.Status != "" {
+			s.Status = fields.Status
+		}
+		s.Confidence = fields.Confidence
+		if fields.IDNumber != "" {
+			s.IDHash = HashCitizenID(fields.IDNumber)
+		}
+	}
+}
+
+// SetCapture copies the document types, image hash, raw OCR labels and
+// images recorded during the scan, and totals the size of the uploads.
+// The images are uploaded when the scan is saved through a Repository
+// returned by WithImages.
+func (s *Scan) SetCapture(c *service.Capture) {
+	s.Document = c.Document()
+	s.ImageSHA256 = c.ImageSHA256()
+	s.uploads = c.Images()
+	for name, b := range s.uploads {
+		if !strings.HasSuffix(name, "_cropped") {
+			s.ImageBytes += int64(len(b))
+		}
+	}
+	if fields := c.Fields(); len(fields) > 0 {
+		s.RawOCR, _ = json.Marshal(fields)
+	}
+}
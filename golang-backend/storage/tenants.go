@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTenantNotFound = errors.New("tenant not found")
+	ErrKeyNotFound    = errors.New("api key not found")
+)
+
+// Tenant holds the settings an admin stored for a tenant. They take
+// precedence over the tenant's config entry. A zero quota is unlimited.
+type Tenant struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name,omitempty"`
+	DailyQuota   int64     `json:"daily_quota"`
+	MonthlyQuota int64     `json:"monthly_quota"`
+	Disabled     bool      `json:"disabled"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// WebhookSecret signs the tenant's webhook deliveries. It is only
+	// shown when a key is issued.
+	WebhookSecret string `json:"-"`
+}
+
+// APIKey is a key issued through the admin API. Only the SHA-256 of the
+// secret is kept. After a rotation the previous secret keeps working until
+// PreviousExpiresAt.
+type APIKey struct {
+	ID                string     `json:"id"`
+	Tenant            string     `json:"tenant,omitempty"`
+	Name              string     `json:"name,omitempty"`
+	Roles             []string   `json:"roles"`
+	Hash              string     `json:"-"`
+	PreviousHash      string     `json:"-"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	RotatedAt         *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Matches reports whether hash is the current secret of a live key, or its
+// previous secret within the rotation grace period.
+func (k *APIKey) Matches(hash string, now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	if hash == k.Hash {
+		return true
+	}
+	return hash == k.PreviousHash && k.PreviousExpiresAt != nil && now.Before(*k.PreviousExpiresAt)
+}
+
+// NewKeySecret returns a random API key secret and its hash.
+func NewKeySecret() (secret, hash string) {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	secret = "tik_" + base64.RawURLEncoding.EncodeToString(b)
+	return secret, HashKey(secret)
+}
+
+// NewWebhookSecret returns a random webhook signing secret.
+func NewWebhookSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// HashKey is the digest stored for an API key secret.
+func HashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
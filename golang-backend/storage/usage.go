@@ -0,0 +1,41 @@
+package storage
+
+import "time"
+
+// Usage totals the scans of one tenant and API key. Succeeded counts every
+// scan that did not fail, including those needing review.
+type Usage struct {
+	Tenant      string `json:"tenant"`
+	KeyID       string `json:"key_id"`
+	Scans       int64  `json:"scans"`
+	Succeeded   int64  `json:"succeeded"`
+	NeedsReview int64  `json:"needs_review"`
+	Failed      int64  `json:"failed"`
+	ImageBytes  int64  `json:"image_bytes"`
+}
+
+// Add accumulates o into u.
+func (u *Usage) Add(o Usage) {
+	u.Scans += o.Scans
+	u.Succeeded += o.Succeeded
+	u.NeedsReview += o.NeedsReview
+	u.Failed += o.Failed
+	u.ImageBytes += o.ImageBytes
+}
+
+// SuccessRate is the share of scans that succeeded, or 0 without scans.
+func (u Usage) SuccessRate() float64 {
+	if u.Scans == 0 {
+		return 0
+	}
+	return float64(u.Succeeded) / float64(u.Scans)
+}
+
+// UsageFilter selects the scans totalled by Usage. Scans removed by
+// retention are no longer counted.
+type UsageFilter struct {
+	From   time.Time
+	To     time.Time
+	Tenant string
+	KeyID  string
+}
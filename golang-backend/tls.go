@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"golang-backend/config"
+)
+
+// configureTLS returns the function that runs srv, over HTTPS when cfg has
+// a certificate or autocert domains. With autocert and an HTTPAddr it also
+// returns the plain HTTP server answering ACME challenges and redirecting
+// everything else to HTTPS.
+func configureTLS(srv *http.Server, cfg config.TLSConfig) (serve func() error, redirect *http.Server, err error) {
+	switch {
+	case cfg.CertFile != "" && len(cfg.AutocertDomains) > 0:
+		return nil, nil, errors.New("set either cert_file or autocert_domains, not both")
+	case cfg.CertFile != "":
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		return func() error { return srv.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile) }, nil, nil
+	case len(cfg.AutocertDomains) > 0:
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		srv.TLSConfig = m.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		if cfg.HTTPAddr != "" {
+			redirect = &http.Server{Addr: cfg.HTTPAddr, Handler: m.HTTPHandler(nil), ReadTimeout: srv.ReadTimeout}
+		}
+		return func() error { return srv.ListenAndServeTLS("", "") }, redirect, nil
+	}
+	return srv.ListenAndServe, nil, nil
+}
@@ -0,0 +1,226 @@
+// Package tus keeps the partial files of resumable uploads made with the
+// tus protocol (https://tus.io/protocols/resumable-upload).
+package tus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("upload not found")
+	ErrOffset   = errors.New("upload offset mismatch")
+	ErrLocked   = errors.New("upload is being written")
+	ErrTooLong  = errors.New("upload exceeds its length")
+)
+
+// Upload describes a resumable upload. KeyID and Tenant are the creator's,
+// and ScanID the job started once all Length bytes arrived.
+type Upload struct {
+	ID        string            `json:"id"`
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	KeyID     string            `json:"key_id,omitempty"`
+	Tenant    string            `json:"tenant,omitempty"`
+	ScanID    string            `json:"scan_id,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+func (u *Upload) Complete() bool {
+	return u.Offset == u.Length
+}
+
+// Store keeps each upload as id.bin with its description in id.json, so
+// uploads survive a restart. Uploads expire ttl after they are created.
+type Store struct {
+	dir string
+	ttl time.Duration
+
+	mu      sync.Mutex
+	writing map[string]bool
+}
+
+// NewStore creates dir and starts removing expired uploads from it.
+func NewStore(dir string, ttl time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, ttl: ttl, writing: make(map[string]bool)}
+	go s.janitor()
+	return s, nil
+}
+
+// Create assigns u an ID and expiry and stores it with no data.
+func (s *Store) Create(u *Upload) error {
+	u.ID = newID()
+	u.Offset = 0
+	u.ExpiresAt = time.Now().Add(s.ttl)
+	f, err := os.OpenFile(s.path(u.ID, ".bin"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return s.save(u)
+}
+
+func (s *Store) Get(id string) (*Upload, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	b, err := os.ReadFile(s.path(id, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var u Upload
+	if err := json.Unmarshal(b, &u); err != nil {
+		return nil, err
+	}
+	if time.Now().After(u.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+// Append writes r at offset, which must be the upload's current offset.
+// Bytes received before r fails are kept, so the client can resume after
+// them; the returned upload has the new offset even when err is not nil.
+func (s *Store) Append(id string, offset int64, r io.Reader) (*Upload, error) {
+	if !s.lock(id) {
+		return nil, ErrLocked
+	}
+	defer s.unlock(id)
+
+	u, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != u.Offset {
+		return u, ErrOffset
+	}
+	f, err := os.OpenFile(s.path(id, ".bin"), os.O_WRONLY, 0)
+	if err != nil {
+		return u, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(u.Offset, io.SeekStart); err != nil {
+		return u, err
+	}
+	n, copyErr := io.CopyN(f, r, u.Length-u.Offset)
+	if errors.Is(copyErr, io.EOF) {
+		copyErr = nil
+	}
+	if copyErr == nil {
+		var extra [1]byte
+		if m, _ := r.Read(extra[:]); m > 0 {
+			copyErr = ErrTooLong
+		}
+	}
+	u.Offset += n
+	if err := s.save(u); err != nil {
+		return u, err
+	}
+	return u, copyErr
+}
+
+// SetScanID records the scan started for a complete upload and deletes
+// its data, which the scan now holds.
+func (s *Store) SetScanID(id, scanID string) error {
+	u, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	u.ScanID = scanID
+	if err := s.save(u); err != nil {
+		return err
+	}
+	return os.Remove(s.path(id, ".bin"))
+}
+
+func (s *Store) Data(id string) ([]byte, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	return os.ReadFile(s.path(id, ".bin"))
+}
+
+func (s *Store) Remove(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	err := os.Remove(s.path(id, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	os.Remove(s.path(id, ".bin"))
+	return err
+}
+
+func (s *Store) save(u *Upload) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	tmp := s.path(u.ID, ".json.tmp")
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(u.ID, ".json"))
+}
+
+func (s *Store) lock(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writing[id] {
+		return false
+	}
+	s.writing[id] = true
+	return true
+}
+
+func (s *Store) unlock(id string) {
+	s.mu.Lock()
+	delete(s.writing, id)
+	s.mu.Unlock()
+}
+
+func (s *Store) janitor() {
+	for range time.Tick(time.Hour) {
+		paths, _ := filepath.Glob(filepath.Join(s.dir, "*.json"))
+		for _, p := range paths {
+			id := filepath.Base(p[:len(p)-len(".json")])
+			if _, err := s.Get(id); errors.Is(err, ErrNotFound) {
+				s.Remove(id)
+			}
+		}
+	}
+}
+
+func (s *Store) path(id, ext string) string {
+	return filepath.Join(s.dir, id+ext)
+}
+
+// validID keeps IDs from naming files outside the store.
+func validID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
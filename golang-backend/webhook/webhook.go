@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-backend/config"
+	"golang-backend/logging"
+	"golang-backend/netguard"
+)
+
+const (
+	SignatureHeader = "X-Signature"
+	TimestampHeader = "X-Signature-Timestamp"
+	NonceHeader     = "X-Signature-Nonce"
+	// MaxSkew is how old a signature receivers should still accept.
+	MaxSkew = 5 * time.Minute
+)
+
+var (
+	settings = config.Default().Webhook
+	client   = newClient(settings)
+	// tenantSecret returns the signing secret of a tenant, or "" to use
+	// settings.Secret.
+	tenantSecret = func(string) string { return "" }
+)
+
+func Configure(cfg *config.Config) {
+	settings = cfg.Webhook
+	client = newClient(cfg.Webhook)
+}
+
+// newClient builds the delivery client. Unless cfg.AllowPrivate is set it
+// dials only public addresses, and redirects are checked like callback
+// URLs, so a callback cannot be pointed at internal hosts.
+func newClient(cfg config.WebhookConfig) *http.Client {
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:           netguard.Dialer(5*time.Second, cfg.AllowPrivate).DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: cfg.Timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("too many redirects")
+			}
+			return ValidateURL(req.URL.String())
+		},
+	}
+}
+
+// ValidateURL checks that a client-supplied callback URL is absolute http(s)
+// and, unless private addresses are allowed, does not name a loopback or
+// private host outright. Hosts resolving to one are refused when dialled.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return errors.New("callback url must be an absolute http(s) url")
+	}
+	if settings.AllowPrivate {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("callback url must not point at localhost")
+	}
+	if a, err := netip.ParseAddr(host); err == nil && !netguard.Public(a) {
+		return errors.New("callback url must not point at a private address")
+	}
+	return nil
+}
+
+// SetTenantSecrets sets how a tenant's signing secret is looked up.
+func SetTenantSecrets(lookup func(tenant string) string) {
+	tenantSecret = lookup
+}
+
+// URLForKey returns the callback registered in config for an API key ID.
+func URLForKey(keyID string) string {
+	return settings.KeyURLs[keyID]
+}
+
+// Deliver POSTs payload as JSON to target, signing it with the tenant's
+// secret, or the configured one for scans without a tenant, and retrying
+// failed deliveries with linear backoff. Each attempt is signed afresh.
+func Deliver(ctx context.Context, tenant, target string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	secret := tenantSecret(tenant)
+	if secret == "" {
+		secret = settings.Secret
+	}
+
+	log := logging.FromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		err = send(ctx, target, secret, body)
+		if err == nil {
+			return nil
+		}
+		log.Warn("webhook delivery failed", "attempt", attempt, "error", err)
+		if attempt >= settings.MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * settings.Backoff):
+		}
+	}
+}
+
+func send(ctx context.Context, target, secret string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		timestamp, nonce := strconv.FormatInt(time.Now().Unix(), 10), newNonce()
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(NonceHeader, nonce)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, nonce, body))
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign is the hex HMAC-SHA256 of "timestamp.nonce.body" under secret.
+func Sign(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Verify checks the signature headers of a delivery received at now, as a
+// receiver should before trusting it: the signature must match body and the
+// timestamp be within MaxSkew. Receivers must also refuse a nonce they have
+// seen within MaxSkew, which Verify cannot track.
+func Verify(secret string, h http.Header, body []byte, now time.Time) error {
+	sig, ok := strings.CutPrefix(h.Get(SignatureHeader), "sha256=")
+	timestamp, nonce := h.Get(TimestampHeader), h.Get(NonceHeader)
+	if !ok || timestamp == "" || nonce == "" {
+		return errors.New("webhook is not signed")
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("malformed webhook timestamp")
+	}
+	if skew := now.Sub(time.Unix(sec, 0)); skew > MaxSkew || skew < -MaxSkew {
+		return errors.New("webhook timestamp outside the allowed skew")
+	}
+	if !hmac.Equal([]byte(sig), []byte(Sign(secret, timestamp, nonce, body))) {
+		return errors.New("webhook signature mismatch")
+	}
+	return nil
+}
+
+// Signing describes how deliveries are signed, for the integrators who
+// must verify them.
+type Signing struct {
+	Secret          string `json:"secret,omitempty"`
+	Algorithm       string `json:"algorithm"`
+	SignatureHeader string `json:"signature_header"`
+	TimestampHeader string `json:"timestamp_header"`
+	NonceHeader     string `json:"nonce_header"`
+	SignedPayload   string `json:"signed_payload"`
+	MaxSkew         string `json:"max_skew"`
+	Verification    string `json:"verification"`
+}
+
+// Describe returns the signing scheme for deliveries signed with secret.
+func Describe(secret string) Signing {
+	return Signing{
+		Secret:          secret,
+		Algorithm:       "HMAC-SHA256, hex encoded",
+		SignatureHeader: SignatureHeader + ": sha256=<signature>",
+		TimestampHeader: TimestampHeader + ": <unix seconds>",
+		NonceHeader:     NonceHeader + ": <random hex>",
+		SignedPayload:   "<timestamp>.<nonce>.<raw request body>",
+		MaxSkew:         MaxSkew.String(),
+		Verification: "Recompute the signature over the signed payload and compare it in constant time; " +
+			"refuse deliveries that are unsigned, mismatched, timestamped more than max_skew from now, " +
+			"or that reuse a nonce seen within max_skew.",
+	}
+}